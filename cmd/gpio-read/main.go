@@ -15,6 +15,7 @@ import (
 
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/gpio/gpioutil"
 )
 
 func printLevel(l gpio.Level) error {
@@ -30,6 +31,7 @@ func mainImpl() error {
 	pullUp := flag.Bool("u", false, "pull up")
 	pullDown := flag.Bool("d", false, "pull down")
 	edges := flag.Bool("e", false, "wait for edges")
+	debounce := flag.Duration("debounce", 0, "ignore edges shorter than this duration")
 	verbose := flag.Bool("v", false, "verbose mode")
 	flag.Parse()
 	if !*verbose {
@@ -60,6 +62,12 @@ func mainImpl() error {
 	if p == nil {
 		return errors.New("specify a valid GPIO pin number")
 	}
+	if *debounce != 0 {
+		var err error
+		if p, err = gpioutil.Debounce(p, *debounce); err != nil {
+			return err
+		}
+	}
 	edge := gpio.NoEdge
 	if *edges {
 		edge = gpio.BothEdges
@@ -16,7 +16,9 @@ import (
 
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/i2ctest"
 	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/trace"
 )
 
 func mainImpl() error {
@@ -26,8 +28,11 @@ func mainImpl() error {
 	// TODO(maruel): This is not generic enough.
 	write := flag.Bool("w", false, "write instead of reading")
 	reg := flag.Int("r", -1, "register to address")
-	hz := flag.Int("hz", 0, "I²C bus speed (may require root)")
+	var hz physic.Frequency
+	flag.Var(&hz, "hz", "I²C bus speed (may require root)")
 	l := flag.Int("l", 1, "length of data to read; ignored if -w is specified")
+	record := flag.String("record", "", "file to save the I/O to; it can be loaded back with i2ctest.Playback.Load()")
+	traceTo := flag.String("trace", "", "trace the bus traffic; \"-\" prints it to stderr, otherwise it is saved in pcap format to this file")
 	flag.Parse()
 	if !*verbose {
 		log.SetOutput(ioutil.Discard)
@@ -78,8 +83,8 @@ func mainImpl() error {
 	}
 	defer bus.Close()
 
-	if *hz != 0 {
-		if err := bus.SetSpeed(physic.Frequency(*hz) * physic.Hertz); err != nil {
+	if hz != 0 {
+		if err := bus.SetSpeed(hz); err != nil {
 			return err
 		}
 	}
@@ -88,11 +93,41 @@ func mainImpl() error {
 			log.Printf("Using pins SCL: %s  SDA: %s", p.SCL(), p.SDA())
 		}
 	}
-	d := i2c.Dev{Bus: bus, Addr: uint16(*addr)}
-	if *write {
+	var b i2c.Bus = bus
+	done := func() error { return nil }
+	if *traceTo != "" {
+		var s trace.Sink
+		if s, done, err = trace.OpenFile(*traceTo, trace.LinkI2C); err != nil {
+			return err
+		}
+		b = trace.NewI2C(b, s)
+	}
+	var rec *i2ctest.Record
+	if *record != "" {
+		rec = &i2ctest.Record{Bus: b}
+		b = rec
+	}
+	d := i2c.Dev{Bus: b, Addr: uint16(*addr)}
+	err = tx(&d, *write, byte(*reg), buf)
+	if rec != nil {
+		// Save even on failure, the I/O up to the error is useful.
+		if err2 := rec.SaveFile(*record); err == nil {
+			err = err2
+		}
+	}
+	if err2 := done(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// tx does the I/O and prints the data read.
+func tx(d *i2c.Dev, write bool, reg byte, buf []byte) error {
+	var err error
+	if write {
 		_, err = d.Write(buf)
 	} else {
-		if err = d.Tx([]byte{byte(*reg)}, buf); err != nil {
+		if err = d.Tx([]byte{reg}, buf); err != nil {
 			return err
 		}
 		for i, b := range buf {
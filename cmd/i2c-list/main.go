@@ -3,6 +3,9 @@
 // that can be found in the LICENSE file.
 
 // i2c-list lists all I²C buses.
+//
+// With -scan, it also probes each bus for responding devices and prints a
+// grid like i2cdetect.
 package main
 
 import (
@@ -15,6 +18,7 @@ import (
 
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/pin"
 	"periph.io/x/periph/conn/pin/pinreg"
 )
@@ -28,8 +32,39 @@ func printPin(fn string, p pin.Pin) {
 	}
 }
 
+// printScan prints the devices found on the bus in a grid like i2cdetect.
+func printScan(b i2c.Bus, p smbus.Probe) error {
+	const first, last = 0x03, 0x77
+	addrs, err := smbus.Scan(b, first, last, p)
+	if err != nil {
+		return err
+	}
+	found := map[uint16]bool{}
+	for _, a := range addrs {
+		found[a] = true
+	}
+	fmt.Printf("       0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f\n")
+	for row := uint16(0); row < 0x80; row += 0x10 {
+		fmt.Printf("  %02x:", row)
+		for addr := row; addr < row+0x10 && addr <= last; addr++ {
+			switch {
+			case addr < first:
+				fmt.Print("   ")
+			case found[addr]:
+				fmt.Printf(" %02x", addr)
+			default:
+				fmt.Print(" --")
+			}
+		}
+		fmt.Print("\n")
+	}
+	return nil
+}
+
 func mainImpl() error {
 	verbose := flag.Bool("v", false, "verbose mode")
+	scan := flag.Bool("scan", false, "scan each bus for responding devices; this may change the state of some devices")
+	probe := flag.String("probe", "auto", "probe used with -scan: auto, quick or read")
 	flag.Parse()
 	if !*verbose {
 		log.SetOutput(ioutil.Discard)
@@ -38,6 +73,17 @@ func mainImpl() error {
 	if flag.NArg() != 0 {
 		return errors.New("unexpected argument, try -help")
 	}
+	var p smbus.Probe
+	switch *probe {
+	case "auto":
+		p = smbus.ProbeAuto
+	case "quick":
+		p = smbus.ProbeQuick
+	case "read":
+		p = smbus.ProbeRead
+	default:
+		return errors.New("invalid -probe value, try -help")
+	}
 
 	if _, err := hostInit(); err != nil {
 		return err
@@ -63,6 +109,11 @@ func mainImpl() error {
 			printPin("SCL", p.SCL())
 			printPin("SDA", p.SDA())
 		}
+		if *scan {
+			if err := printScan(bus, p); err != nil {
+				fmt.Printf("  Failed to scan: %v\n", err)
+			}
+		}
 		if err := bus.Close(); err != nil {
 			return err
 		}
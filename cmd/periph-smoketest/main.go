@@ -55,6 +55,7 @@ var tests = []SmokeTest{
 	&i2csmoketest.SmokeTest{},
 	&odroidc1smoketest.SmokeTest{},
 	&onewiresmoketest.SmokeTest{},
+	&spismoketest.LoopbackTest{},
 	&spismoketest.SmokeTest{},
 	&ssd1306smoketest.SmokeTest{},
 	&sysfssmoketest.Benchmark{},
@@ -0,0 +1,218 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spismoketest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spireg"
+)
+
+// LoopbackTest is imported by periph-smoketest.
+//
+// It requires MOSI to be wired to MISO.
+type LoopbackTest struct {
+}
+
+func (l *LoopbackTest) String() string {
+	return l.Name()
+}
+
+// Name implements the SmokeTest interface.
+func (l *LoopbackTest) Name() string {
+	return "spi-loopback"
+}
+
+// Description implements the SmokeTest interface.
+func (l *LoopbackTest) Description() string {
+	return "Tests the SPI modes, bits per word and speeds supported by a SPI port with MOSI wired to MISO"
+}
+
+// Run implements the SmokeTest interface.
+func (l *LoopbackTest) Run(f *flag.FlagSet, args []string) error {
+	spiID := f.String("spi", "", "SPI port to use")
+	hz := 10 * physic.MegaHertz
+	f.Var(&hz, "hz", "maximum SPI port speed to test")
+	size := f.Int("size", 4096, "number of bytes to transfer for each combination")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 0 {
+		f.Usage()
+		return errors.New("unrecognized arguments")
+	}
+
+	p, err := spireg.Open(*spiID)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %v", *spiID, err)
+	}
+	defer p.Close()
+	results, err := Sweep(p, hz, *size)
+	if err != nil {
+		return err
+	}
+	ok := 0
+	for i := range results {
+		log.Printf("%s: %s", l, &results[i])
+		if results[i].Err == nil {
+			ok++
+		}
+	}
+	log.Printf("%s: %d of %d combinations work", l, ok, len(results))
+	if ok == 0 {
+		return errors.New("spi-loopback: no combination works; is MOSI wired to MISO?")
+	}
+	return nil
+}
+
+// Result is the result of the loopback test for a combination of parameters.
+type Result struct {
+	Mode spi.Mode
+	Bits int
+	Freq physic.Frequency
+	// Err is nil if the combination works.
+	Err error
+	// Bytes is the number of bytes transferred in Duration to measure the
+	// throughput.
+	Bytes    int
+	Duration time.Duration
+}
+
+func (r *Result) String() string {
+	s := fmt.Sprintf("%s, %d bits, %s: ", r.Mode, r.Bits, r.Freq)
+	if r.Err != nil {
+		return s + r.Err.Error()
+	}
+	return s + fmt.Sprintf("%d bytes in %s (%.1fkB/s)", r.Bytes, r.Duration, r.Throughput()/1000)
+}
+
+// Throughput returns the measured throughput in bytes per second.
+func (r *Result) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Duration.Seconds()
+}
+
+// Sweep runs a loopback test on p for each SPI mode, each bits per word in 8,
+// 16 and 32 and each frequency up to maxHz.
+//
+// MOSI must be wired to MISO. Each combination transfers size bytes with
+// spi.TxStream() to measure the throughput, then a few bytes with TxPackets()
+// using packets with different BitsPerWord and KeepCS values. The data read
+// must match the data written.
+//
+// The port is connected once, then reconfigured for each combination via
+// spi.Configurer. When the connection doesn't implement spi.Configurer, only
+// the first combination is tested.
+//
+// It returns an error only if the port speed cannot be limited.
+func Sweep(p spi.PortCloser, maxHz physic.Frequency, size int) ([]Result, error) {
+	if maxHz <= 0 {
+		return nil, errors.New("spi-loopback: invalid maximum frequency")
+	}
+	if size < packetsSize {
+		size = packetsSize
+	}
+	if err := p.LimitSpeed(maxHz); err != nil {
+		return nil, err
+	}
+	var c spi.Conn
+	var cfg spi.Configurer
+	var out []Result
+	for _, m := range []spi.Mode{spi.Mode0, spi.Mode1, spi.Mode2, spi.Mode3} {
+		for _, b := range []int{8, 16, 32} {
+			for _, f := range sweepFreqs(maxHz) {
+				r := Result{Mode: m, Bits: b, Freq: f}
+				switch {
+				case c == nil:
+					if c, r.Err = p.Connect(f, m, b); r.Err == nil {
+						cfg, _ = c.(spi.Configurer)
+					}
+				case cfg != nil:
+					r.Err = cfg.Configure(f, m, b)
+				default:
+					r.Err = errNotConfigurable
+				}
+				if r.Err == nil {
+					r.Bytes, r.Duration, r.Err = verify(c, size)
+				}
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+//
+
+// packetsSize is the number of bytes transferred with TxPackets() by verify.
+const packetsSize = 16
+
+var errNotConfigurable = errors.New("skipped: the connection doesn't implement spi.Configurer")
+
+// sweepFreqs returns the frequencies to test up to max.
+func sweepFreqs(max physic.Frequency) []physic.Frequency {
+	var out []physic.Frequency
+	for _, f := range []physic.Frequency{100 * physic.KiloHertz, physic.MegaHertz, 4 * physic.MegaHertz, 10 * physic.MegaHertz, 20 * physic.MegaHertz, 50 * physic.MegaHertz} {
+		if f < max {
+			out = append(out, f)
+		}
+	}
+	return append(out, max)
+}
+
+// verify transfers size bytes then a few packets on c and checks that the
+// data read is the data written.
+func verify(c spi.Conn, size int) (int, time.Duration, error) {
+	// The size must be a multiple of the largest word size.
+	size &^= 3
+	w := make([]byte, size)
+	for i := range w {
+		w[i] = byte(i*0x1D + 0x55)
+	}
+	r := make([]byte, size)
+	start := time.Now()
+	if err := spi.TxStream(c, w, r); err != nil {
+		return 0, 0, fmt.Errorf("Tx: %v", err)
+	}
+	d := time.Since(start)
+	if err := compare("Tx", w, r); err != nil {
+		return 0, 0, err
+	}
+
+	for i := range r {
+		r[i] = 0
+	}
+	p := []spi.Packet{
+		{W: w[0:4], R: r[0:4], KeepCS: true},
+		{W: w[4:8], R: r[4:8], BitsPerWord: 8, KeepCS: true},
+		{W: w[8:12], R: r[8:12], BitsPerWord: 16},
+		{W: w[12:16], R: r[12:16]},
+	}
+	if err := c.TxPackets(p); err != nil {
+		return 0, 0, fmt.Errorf("TxPackets: %v", err)
+	}
+	if err := compare("TxPackets", w[:packetsSize], r[:packetsSize]); err != nil {
+		return 0, 0, err
+	}
+	return size, d, nil
+}
+
+// compare returns an error describing the first difference between w and r.
+func compare(op string, w, r []byte) error {
+	for i := range w {
+		if w[i] != r[i] {
+			return fmt.Errorf("%s: wrote 0x%02X but read 0x%02X at offset %d", op, w[i], r[i], i)
+		}
+	}
+	return nil
+}
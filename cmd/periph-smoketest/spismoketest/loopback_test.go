@@ -0,0 +1,157 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spismoketest
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestSweep(t *testing.T) {
+	p := &spitest.Loopback{}
+	results, err := Sweep(p, 4*physic.MegaHertz, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 modes * 3 bits * 100kHz, 1MHz and 4MHz.
+	if len(results) != 36 {
+		t.Fatal(len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Bytes != 64 {
+			t.Fatal(r.String())
+		}
+	}
+	if r := results[0]; r.Mode != spi.Mode0 || r.Bits != 8 || r.Freq != 100*physic.KiloHertz {
+		t.Fatal(r.String())
+	}
+	if r := results[35]; r.Mode != spi.Mode3 || r.Bits != 32 || r.Freq != 4*physic.MegaHertz {
+		t.Fatal(r.String())
+	}
+}
+
+func TestSweep_failures(t *testing.T) {
+	p := &fakePort{}
+	results, err := Sweep(p, 3*physic.MegaHertz, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 36 {
+		t.Fatal(len(results))
+	}
+	for _, r := range results {
+		switch {
+		case r.Bits == 32:
+			if r.Err == nil || r.Err.Error() != "32 bits not supported" {
+				t.Fatal(r.String())
+			}
+		case r.Mode == spi.Mode3:
+			if s := r.String(); s != "Mode3, "+strconv.Itoa(r.Bits)+" bits, "+r.Freq.String()+": Tx: wrote 0x55 but read 0x54 at offset 0" {
+				t.Fatal(s)
+			}
+		case r.Mode == spi.Mode2 && r.Freq == 3*physic.MegaHertz:
+			if s := r.String(); s != "Mode2, "+strconv.Itoa(r.Bits)+" bits, 3MHz: TxPackets: packets not supported" {
+				t.Fatal(s)
+			}
+		default:
+			if r.Err != nil || r.Bytes != packetsSize {
+				t.Fatal(r.String())
+			}
+		}
+	}
+}
+
+func TestSweep_not_configurable(t *testing.T) {
+	p := &spitest.Record{Port: &spitest.Loopback{}}
+	results, err := Sweep(p, physic.MegaHertz, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 24 {
+		t.Fatal(len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatal(results[0].String())
+	}
+	for _, r := range results[1:] {
+		if r.Err != errNotConfigurable {
+			t.Fatal(r.String())
+		}
+	}
+}
+
+func TestSweep_err(t *testing.T) {
+	if _, err := Sweep(&spitest.Loopback{}, 0, 0); err == nil {
+		t.Fatal("invalid frequency")
+	}
+}
+
+func TestResult(t *testing.T) {
+	r := Result{Mode: spi.Mode1, Bits: 8, Freq: physic.MegaHertz, Bytes: 1000, Duration: 1000000}
+	if s := r.String(); s != "Mode1, 8 bits, 1MHz: 1000 bytes in 1ms (1000.0kB/s)" {
+		t.Fatal(s)
+	}
+	r = Result{}
+	if v := r.Throughput(); v != 0 {
+		t.Fatal(v)
+	}
+}
+
+//
+
+// fakePort is a loopback that doesn't support 32 bits words, corrupts the
+// data in Mode3 and fails TxPackets in Mode2 at 3MHz.
+type fakePort struct {
+	spitest.Loopback
+}
+
+func (f *fakePort) Connect(freq physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
+	c, err := f.Loopback.Connect(freq, mode, bits)
+	if err != nil {
+		return nil, err
+	}
+	fc := &fakeConn{Conn: c}
+	if err := fc.Configure(freq, mode, bits); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+type fakeConn struct {
+	spi.Conn
+	f    physic.Frequency
+	mode spi.Mode
+}
+
+func (f *fakeConn) Configure(freq physic.Frequency, mode spi.Mode, bits int) error {
+	if bits == 32 {
+		return errors.New("32 bits not supported")
+	}
+	f.f = freq
+	f.mode = mode
+	return f.Conn.(spi.Configurer).Configure(freq, mode, bits)
+}
+
+func (f *fakeConn) Tx(w, r []byte) error {
+	if err := f.Conn.Tx(w, r); err != nil {
+		return err
+	}
+	if f.mode == spi.Mode3 && len(r) != 0 {
+		r[0] ^= 1
+	}
+	return nil
+}
+
+func (f *fakeConn) TxPackets(p []spi.Packet) error {
+	if f.mode == spi.Mode2 && f.f == 3*physic.MegaHertz {
+		return errors.New("packets not supported")
+	}
+	return f.Conn.TxPackets(p)
+}
@@ -8,6 +8,9 @@
 // This assumes the presence of the periph-tester board, which includes these
 // two devices.
 // See https://github.com/periph/periph-tester
+//
+// It also contains a loopback test that verifies which SPI parameters work on
+// a port which has MOSI wired to MISO.
 package spismoketest
 
 import (
@@ -11,6 +11,10 @@
 //
 // For "read only" operation, writes zeros.
 // For "write only" operation, ignore stdout.
+//
+// With -loopback, MOSI must be wired to MISO. It tests each SPI mode, bits per
+// word and speed up to -hz and prints which ones work:
+//   spi-io -b SPI0.0 -hz 10MHz -loopback
 package main
 
 import (
@@ -22,9 +26,12 @@ import (
 	"os"
 	"strconv"
 
+	"periph.io/x/periph/cmd/periph-smoketest/spismoketest"
 	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/conn/spi"
 	"periph.io/x/periph/conn/spi/spireg"
+	"periph.io/x/periph/conn/spi/spitest"
+	"periph.io/x/periph/conn/trace"
 )
 
 // runTx does the I/O.
@@ -88,7 +95,8 @@ func runTx(s spi.Conn, args []string) error {
 
 func mainImpl() error {
 	spiID := flag.String("b", "", "SPI port to use")
-	hz := flag.Int("hz", 1000000, "SPI port speed")
+	hz := physic.MegaHertz
+	flag.Var(&hz, "hz", "SPI port speed")
 
 	nocs := flag.Bool("nocs", false, "do not assert the CS line")
 	half := flag.Bool("half", false, "half duplex mode, sharing MOSI and MISO")
@@ -96,6 +104,11 @@ func mainImpl() error {
 	mode := flag.Int("mode", 0, "CLK and data polarity, between 0 and 3")
 	bits := flag.Int("bits", 8, "bits per word")
 
+	loopback := flag.Bool("loopback", false, "test the parameters supported by the port with MOSI wired to MISO; -hz is the maximum speed")
+	size := flag.Int("size", 4096, "number of bytes to transfer for each combination with -loopback")
+
+	record := flag.String("record", "", "file to save the I/O to; it can be loaded back with spitest.Playback.Load()")
+	traceTo := flag.String("trace", "", "trace the port traffic; \"-\" prints it to stderr, otherwise it is saved in pcap format to this file")
 	verbose := flag.Bool("v", false, "verbose mode")
 	flag.Parse()
 	if !*verbose {
@@ -127,16 +140,69 @@ func mainImpl() error {
 		return err
 	}
 	defer s.Close()
-	c, err := s.Connect(physic.Frequency(*hz)*physic.Hertz, m, *bits)
+	if *loopback {
+		if flag.NArg() != 0 || *record != "" || *traceTo != "" {
+			return errors.New("-loopback cannot be used with arguments, -record or -trace")
+		}
+		return runLoopback(s, hz, *size)
+	}
+	var p spi.PortCloser = s
+	done := func() error { return nil }
+	if *traceTo != "" {
+		var sink trace.Sink
+		if sink, done, err = trace.OpenFile(*traceTo, trace.LinkSPI); err != nil {
+			return err
+		}
+		p = &trace.SPI{Port: p, Sink: sink}
+	}
+	var rec *spitest.Record
+	if *record != "" {
+		rec = &spitest.Record{Port: p}
+		p = rec
+	}
+	// Save and close the trace even if Connect() fails.
+	c, err := p.Connect(hz, m, *bits)
+	if err == nil {
+		if *verbose {
+			if p, ok := c.(spi.Pins); ok {
+				log.Printf("Using pins CLK: %s  MOSI: %s  MISO:  %s", p.CLK(), p.MOSI(), p.MISO())
+			}
+		}
+		err = runTx(c, flag.Args())
+	}
+	if rec != nil {
+		// Save even on failure, the I/O up to the error is useful.
+		if err2 := rec.SaveFile(*record); err == nil {
+			err = err2
+		}
+	}
+	if err2 := done(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// runLoopback runs the loopback test and prints the result for each
+// combination of parameters.
+func runLoopback(p spi.PortCloser, hz physic.Frequency, size int) error {
+	results, err := spismoketest.Sweep(p, hz, size)
 	if err != nil {
 		return err
 	}
-	if *verbose {
-		if p, ok := c.(spi.Pins); ok {
-			log.Printf("Using pins CLK: %s  MOSI: %s  MISO:  %s", p.CLK(), p.MOSI(), p.MISO())
+	ok := 0
+	for i := range results {
+		if results[i].Err == nil {
+			ok++
+		}
+		if _, err := fmt.Printf("%s\n", &results[i]); err != nil {
+			return err
 		}
 	}
-	return runTx(c, flag.Args())
+	if ok == 0 {
+		return errors.New("no combination works; is MOSI wired to MISO?")
+	}
+	_, err = fmt.Printf("%d of %d combinations work\n", ok, len(results))
+	return err
 }
 
 func main() {
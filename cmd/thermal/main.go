@@ -2,7 +2,7 @@
 // Use of this source code is governed under the Apache License, Version 2.0
 // that can be found in the LICENSE file.
 
-// thermal reads the state of thermal sensors exposed via sysfs.
+// thermal reads the state of thermal and hwmon sensors exposed via sysfs.
 package main
 
 import (
@@ -39,9 +39,39 @@ func mainImpl() error {
 		}
 		fmt.Printf("%s: %s: %s\n", t, t.Type(), e.Temperature)
 	}
+	for _, s := range sysfs.HwmonSensors {
+		// Some hwmon inputs are not readable, e.g. a disconnected fan; keep
+		// going with the other ones.
+		v, err := senseHwmon(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %v\n", s, s.Label(), err)
+			continue
+		}
+		fmt.Printf("%s: %s: %s\n", s, s.Label(), v)
+	}
 	return nil
 }
 
+// senseHwmon returns the formatted value of a hwmon input.
+func senseHwmon(s *sysfs.HwmonSensor) (fmt.Stringer, error) {
+	switch s.Type() {
+	case "in":
+		return s.Voltage()
+	case "curr":
+		return s.Current()
+	case "fan":
+		return s.Fan()
+	case "humidity":
+		e := physic.Env{}
+		err := s.Sense(&e)
+		return e.Humidity, err
+	default:
+		e := physic.Env{}
+		err := s.Sense(&e)
+		return e.Temperature, err
+	}
+}
+
 func main() {
 	if err := mainImpl(); err != nil {
 		fmt.Fprintf(os.Stderr, "thermal: %s.\n", err)
@@ -0,0 +1,217 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package conntest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"periph.io/x/periph/conn"
+)
+
+// FileVersion is the version of the recording file format written by Save().
+const FileVersion = 1
+
+// FileHeader is the first line of a recording file.
+//
+// A recording file is in the JSON lines format: the header is followed by one
+// JSON object per operation, one per line. Payloads are encoded in
+// hexadecimal. Fields may be added in later versions but existing ones are
+// never changed, so a file saved by an older version can always be loaded.
+//
+// An example of a file saved by Record.Save():
+//
+//	{"version":1,"kind":"conn","bus":"SPI0.0","time":"2018-03-04T10:00:00Z","meta":{"duplex":"Full"}}
+//	{"w":"0a0b","r":"0c0d"}
+//	{"w":"01"}
+type FileHeader struct {
+	Version int `json:"version"`
+	// Kind is the kind of recording, e.g. "conn", "i2c", "spi" or "onewire".
+	Kind string `json:"kind"`
+	// Bus is the name of the recorded bus, if known.
+	Bus string `json:"bus,omitempty"`
+	// Time is when the recording was saved.
+	Time time.Time `json:"time"`
+	// Meta is metadata specific to the kind of recording, e.g. the duplex of
+	// the connection.
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Hex is a []byte that is encoded in hexadecimal in a recording file.
+type Hex []byte
+
+// MarshalText implements encoding.TextMarshaler.
+func (h Hex) MarshalText() ([]byte, error) {
+	out := make([]byte, hex.EncodedLen(len(h)))
+	hex.Encode(out, h)
+	return out, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Hex) UnmarshalText(b []byte) error {
+	if len(b) == 0 {
+		*h = nil
+		return nil
+	}
+	out := make([]byte, hex.DecodedLen(len(b)))
+	if _, err := hex.Decode(out, b); err != nil {
+		return err
+	}
+	*h = out
+	return nil
+}
+
+// Encode writes a recording file.
+//
+// h.Version and h.Time are set if they are zero. op is called n times to
+// return the operations to write, one per line.
+func Encode(w io.Writer, h *FileHeader, n int, op func(i int) interface{}) error {
+	if h.Version == 0 {
+		h.Version = FileVersion
+	}
+	if h.Time.IsZero() {
+		h.Time = time.Now().UTC()
+	}
+	e := json.NewEncoder(w)
+	if err := e.Encode(h); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := e.Encode(op(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode reads a recording file.
+//
+// op is called to decode each line after the header, until it returns
+// io.EOF.
+func Decode(r io.Reader, op func(d *json.Decoder) error) (*FileHeader, error) {
+	d := json.NewDecoder(r)
+	h := &FileHeader{}
+	if err := d.Decode(h); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("conntest: empty recording file")
+		}
+		return nil, fmt.Errorf("conntest: invalid recording header: %v", err)
+	}
+	if h.Version < 1 || h.Version > FileVersion {
+		return nil, fmt.Errorf("conntest: unsupported recording version %d", h.Version)
+	}
+	for i := 0; ; i++ {
+		if err := op(d); err != nil {
+			if err == io.EOF {
+				return h, nil
+			}
+			return nil, fmt.Errorf("conntest: invalid operation #%d: %v", i, err)
+		}
+	}
+}
+
+// SaveIO writes a recording file of ops.
+func SaveIO(w io.Writer, h *FileHeader, ops []IO) error {
+	return Encode(w, h, len(ops), func(i int) interface{} {
+		return &ioLine{W: ops[i].W, R: ops[i].R}
+	})
+}
+
+// LoadIO reads a recording file saved by SaveIO().
+func LoadIO(r io.Reader) (*FileHeader, []IO, error) {
+	var ops []IO
+	h, err := Decode(r, func(d *json.Decoder) error {
+		l := ioLine{}
+		if err := d.Decode(&l); err != nil {
+			return err
+		}
+		ops = append(ops, IO{W: l.W, R: l.R})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, ops, nil
+}
+
+// SaveFile creates the file path and calls save to write to it.
+//
+// It is meant to implement the SaveFile() method of the recorders, e.g.
+// conntest.SaveFile(path, r.Save).
+func SaveFile(path string, save func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Save writes the recorded operations to w.
+//
+// The file can be loaded back with Playback.Load().
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	h := &FileHeader{Kind: "conn", Meta: map[string]string{}}
+	if r.Conn != nil {
+		h.Bus = r.Conn.String()
+		h.Meta["duplex"] = r.Conn.Duplex().String()
+	}
+	return SaveIO(w, h, r.Ops)
+}
+
+// SaveFile writes the recorded operations to the file path.
+func (r *Record) SaveFile(path string) error {
+	return SaveFile(path, r.Save)
+}
+
+// Load reads the operations saved by Record.Save() from r.
+//
+// Ops and D are replaced and Count is reset.
+func (p *Playback) Load(r io.Reader) error {
+	h, ops, err := LoadIO(r)
+	if err != nil {
+		return err
+	}
+	if h.Kind != "conn" {
+		return fmt.Errorf("conntest: expected a conn recording, got %q", h.Kind)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.D = ParseDuplex(h.Meta["duplex"])
+	p.Count = 0
+	return nil
+}
+
+// ParseDuplex returns the conn.Duplex matching the string s as returned by
+// conn.Duplex.String().
+//
+// It returns conn.DuplexUnknown if s is not recognized.
+func ParseDuplex(s string) conn.Duplex {
+	for _, d := range []conn.Duplex{conn.Half, conn.Full} {
+		if d.String() == s {
+			return d
+		}
+	}
+	return conn.DuplexUnknown
+}
+
+//
+
+// ioLine is an IO as saved in a recording file.
+type ioLine struct {
+	W Hex `json:"w,omitempty"`
+	R Hex `json:"r,omitempty"`
+}
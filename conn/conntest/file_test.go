@@ -0,0 +1,153 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package conntest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn"
+)
+
+func TestRecord_Save_Load(t *testing.T) {
+	r := Record{Conn: &Playback{Ops: []IO{{W: []byte{10}, R: []byte{12, 13}}}, D: conn.Full}}
+	if err := r.Tx([]byte{10}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	r.Conn = nil
+	if err := r.Tx([]byte{1, 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+	r.Conn = &Playback{D: conn.Full}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 4 || lines[3] != "" {
+		t.Fatalf("%q", lines)
+	}
+	if !strings.HasPrefix(lines[0], `{"version":1,"kind":"conn","bus":"playback","time":"`) {
+		t.Fatal(lines[0])
+	}
+	if lines[1] != `{"w":"0a","r":"0c0d"}` || lines[2] != `{"w":"0102"}` {
+		t.Fatalf("%q", lines[1:])
+	}
+
+	p := Playback{Count: 1}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatalf("%#v != %#v", p.Ops, r.Ops)
+	}
+	if p.D != conn.Full || p.Count != 0 {
+		t.Fatal(p.D, p.Count)
+	}
+}
+
+func TestRecord_SaveFile(t *testing.T) {
+	d, err := ioutil.TempDir("", "conntest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	r := Record{Ops: []IO{{W: []byte{1}}}}
+	path := filepath.Join(d, "rec.json")
+	if err := r.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p := Playback{}
+	if err := p.Load(f); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatalf("%#v != %#v", p.Ops, r.Ops)
+	}
+	if err := r.SaveFile(filepath.Join(d, "missing", "rec.json")); err == nil {
+		t.Fatal("expected failure")
+	}
+	if err := SaveFile(filepath.Join(d, "err.json"), func(w io.Writer) error { return errors.New("oops") }); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestEncode_Decode(t *testing.T) {
+	now := time.Date(2018, 3, 4, 10, 0, 0, 0, time.UTC)
+	h := &FileHeader{Kind: "foo", Time: now, Meta: map[string]string{"a": "b"}}
+	buf := bytes.Buffer{}
+	err := Encode(&buf, h, 2, func(i int) interface{} {
+		return Hex{byte(i), 0xff}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\"version\":1,\"kind\":\"foo\",\"time\":\"2018-03-04T10:00:00Z\",\"meta\":{\"a\":\"b\"}}\n\"00ff\"\n\"01ff\"\n"
+	if s := buf.String(); s != expected {
+		t.Fatal(s)
+	}
+	var got []Hex
+	h2, err := Decode(&buf, func(d *json.Decoder) error {
+		var x Hex
+		if err := d.Decode(&x); err != nil {
+			return err
+		}
+		got = append(got, x)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h, h2) {
+		t.Fatalf("%#v != %#v", h, h2)
+	}
+	if !reflect.DeepEqual(got, []Hex{{0, 0xff}, {1, 0xff}}) {
+		t.Fatal(got)
+	}
+}
+
+func TestDecode_err(t *testing.T) {
+	data := []string{
+		"",
+		"foo",
+		`{"version":2,"kind":"conn"}`,
+		`{"version":1,"kind":"conn"}` + "\n" + `{"w":"0g"}`,
+		`{"version":1,"kind":"conn"}` + "\n" + `{"w":`,
+	}
+	for i, line := range data {
+		if _, _, err := LoadIO(strings.NewReader(line)); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+	p := Playback{}
+	if err := p.Load(strings.NewReader(`{"version":1,"kind":"i2c"}`)); err == nil {
+		t.Fatal("expected kind error")
+	}
+}
+
+func TestParseDuplex(t *testing.T) {
+	for _, d := range []conn.Duplex{conn.DuplexUnknown, conn.Half, conn.Full} {
+		if p := ParseDuplex(d.String()); p != d {
+			t.Fatal(p, d)
+		}
+	}
+	if p := ParseDuplex("foo"); p != conn.DuplexUnknown {
+		t.Fatal(p)
+	}
+}
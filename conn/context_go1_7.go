@@ -0,0 +1,84 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package conn
+
+import "context"
+
+// ConnContext is implemented by a Conn that supports cancellation and
+// deadlines of a transaction.
+type ConnContext interface {
+	Conn
+	// TxContext does the same as Tx() but returns early when ctx is done.
+	//
+	// It returns a *TimeoutError when the deadline of ctx expired and
+	// ctx.Err() when ctx was canceled.
+	TxContext(ctx context.Context, w, r []byte) error
+}
+
+// TxContext does a transaction on c and returns early when ctx is done.
+//
+// If c doesn't implement ConnContext, Tx() is run in a goroutine with copies
+// of w and r. When ctx is done first, r is left untouched and the transaction
+// keeps running in the background until the device completes it; the
+// following transactions on c may block until then.
+func TxContext(ctx context.Context, c Conn, w, r []byte) error {
+	if cc, ok := c.(ConnContext); ok {
+		return cc.TxContext(ctx, w, r)
+	}
+	return RunTxContext(ctx, c.String()+".Tx", w, r, c.Tx)
+}
+
+// ContextErr returns nil if ctx is not done, a *TimeoutError for op if the
+// deadline of ctx expired or ctx.Err() otherwise.
+func ContextErr(ctx context.Context, op string) error {
+	switch err := ctx.Err(); err {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return &TimeoutError{Op: op}
+	default:
+		return err
+	}
+}
+
+// RunContext runs f in a goroutine and returns its result, unless ctx is done
+// first, in which case it returns ContextErr(ctx, op) without waiting for f.
+//
+// f must not reference memory owned by the caller since it may still run
+// after RunContext returned. f is not called at all if ctx is already done.
+func RunContext(ctx context.Context, op string, f func() error) error {
+	if err := ContextErr(ctx, op); err != nil {
+		return err
+	}
+	c := make(chan error, 1)
+	go func() {
+		c <- f()
+	}()
+	select {
+	case err := <-c:
+		return err
+	case <-ctx.Done():
+		return ContextErr(ctx, op)
+	}
+}
+
+// RunTxContext runs tx(w, r) in a goroutine via RunContext().
+//
+// tx is called with copies of w and r and r is updated only if tx completed
+// before ctx was done.
+func RunTxContext(ctx context.Context, op string, w, r []byte, tx func(w, r []byte) error) error {
+	wc := make([]byte, len(w))
+	copy(wc, w)
+	rc := make([]byte, len(r))
+	err := RunContext(ctx, op, func() error {
+		return tx(wc, rc)
+	})
+	if err == nil {
+		copy(r, rc)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package conn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTxContext(t *testing.T) {
+	c := &blockingConn{reply: []byte{2}}
+	r := make([]byte, 1)
+	if err := TxContext(context.Background(), c, []byte{1}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{2}) || !bytes.Equal(c.w, []byte{1}) {
+		t.Fatal(r, c.w)
+	}
+}
+
+func TestTxContext_ConnContext(t *testing.T) {
+	c := &ctxConn{}
+	if err := TxContext(context.Background(), c, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !c.called {
+		t.Fatal("TxContext wasn't called")
+	}
+}
+
+func TestTxContext_timeout(t *testing.T) {
+	c := &blockingConn{block: make(chan struct{}), reply: []byte{2}}
+	defer close(c.block)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	r := make([]byte, 1)
+	err := TxContext(ctx, c, []byte{1}, r)
+	if !IsTimeout(err) {
+		t.Fatal(err)
+	}
+	if s := err.Error(); s != "blocking.Tx: timed out" {
+		t.Fatal(s)
+	}
+	if r[0] != 0 {
+		t.Fatal("r must be left untouched")
+	}
+}
+
+func TestTxContext_canceled(t *testing.T) {
+	c := &blockingConn{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := TxContext(ctx, c, nil, nil); err != context.Canceled {
+		t.Fatal(err)
+	}
+	if c.called {
+		t.Fatal("Tx must not be called")
+	}
+}
+
+func TestRunContext_err(t *testing.T) {
+	err := RunContext(context.Background(), "op", func() error {
+		return errors.New("oops")
+	})
+	if err == nil || err.Error() != "oops" {
+		t.Fatal(err)
+	}
+}
+
+//
+
+type blockingConn struct {
+	block  chan struct{}
+	reply  []byte
+	w      []byte
+	called bool
+}
+
+func (b *blockingConn) String() string {
+	return "blocking"
+}
+
+func (b *blockingConn) Tx(w, r []byte) error {
+	b.called = true
+	b.w = append(b.w, w...)
+	if b.block != nil {
+		<-b.block
+	}
+	copy(r, b.reply)
+	return nil
+}
+
+func (b *blockingConn) Duplex() Duplex {
+	return Half
+}
+
+type ctxConn struct {
+	called bool
+}
+
+func (c *ctxConn) String() string {
+	return "ctx"
+}
+
+func (c *ctxConn) Tx(w, r []byte) error {
+	return errors.New("unexpected")
+}
+
+func (c *ctxConn) TxContext(ctx context.Context, w, r []byte) error {
+	c.called = true
+	return nil
+}
+
+func (c *ctxConn) Duplex() Duplex {
+	return Half
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package gpio
+
+import (
+	"context"
+	"time"
+
+	"periph.io/x/periph/conn"
+)
+
+// PinInContext is implemented by a PinIn that supports cancellation and
+// deadlines while waiting for an edge.
+type PinInContext interface {
+	PinIn
+	// WaitForEdgeContext waits for the next edge like WaitForEdge() does but
+	// until ctx is done.
+	//
+	// It returns nil when an edge was detected, a *conn.TimeoutError when the
+	// deadline of ctx expired and ctx.Err() when ctx was canceled.
+	WaitForEdgeContext(ctx context.Context) error
+}
+
+// WaitForEdgeContext waits for the next edge on p until ctx is done.
+//
+// If p doesn't implement PinInContext, WaitForEdge() is called repeatedly
+// with a short timeout, so a cancellation is noticed within 100ms.
+func WaitForEdgeContext(ctx context.Context, p PinIn) error {
+	if pc, ok := p.(PinInContext); ok {
+		return pc.WaitForEdgeContext(ctx)
+	}
+	op := p.String() + ".WaitForEdge"
+	for {
+		if err := conn.ContextErr(ctx, op); err != nil {
+			return err
+		}
+		t := edgePollInterval
+		if d, ok := ctx.Deadline(); ok {
+			if r := d.Sub(time.Now()); r < t {
+				t = r
+			}
+		}
+		if t < 0 {
+			t = 0
+		}
+		if p.WaitForEdge(t) {
+			return nil
+		}
+	}
+}
+
+//
+
+// edgePollInterval is the longest time WaitForEdgeContext() waits before
+// checking whether the context is done, when the pin doesn't implement
+// PinInContext.
+const edgePollInterval = 100 * time.Millisecond
@@ -0,0 +1,58 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package gpio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn"
+)
+
+func TestWaitForEdgeContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := WaitForEdgeContext(ctx, INVALID); !conn.IsTimeout(err) {
+		t.Fatal(err)
+	}
+	if err := WaitForEdgeContext(context.Background(), &edgePin{}); err != nil {
+		t.Fatal(err)
+	}
+	p := &ctxPin{}
+	if err := WaitForEdgeContext(context.Background(), p); err != nil || !p.called {
+		t.Fatal(err, p.called)
+	}
+}
+
+func TestWaitForEdgeContext_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := WaitForEdgeContext(ctx, &edgePin{}); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
+//
+
+type edgePin struct {
+	invalidPin
+}
+
+func (e *edgePin) WaitForEdge(timeout time.Duration) bool {
+	return true
+}
+
+type ctxPin struct {
+	invalidPin
+	called bool
+}
+
+func (c *ctxPin) WaitForEdgeContext(ctx context.Context) error {
+	c.called = true
+	return nil
+}
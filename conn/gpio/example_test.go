@@ -7,6 +7,7 @@ package gpio_test
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpioreg"
@@ -123,3 +124,44 @@ func ExampleRealPin() {
 		log.Printf("%s is not an alias", p)
 	}
 }
+
+func ExamplePinEdgeEvent() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use gpioreg GPIO pin registry to find a GPIO pin by name.
+	p := gpioreg.ByName("GPIO6")
+	if p == nil {
+		log.Fatal("Failed to find GPIO6")
+	}
+	if err := p.In(gpio.PullDown, gpio.BothEdges); err != nil {
+		log.Fatal(err)
+	}
+
+	// The pin may be an alias, resolve it to find out if it supports edge
+	// events.
+	if r, ok := p.(gpio.RealPin); ok {
+		p = r.Real()
+	}
+	e, ok := p.(gpio.PinEdgeEvent)
+	if !ok {
+		log.Fatalf("%s doesn't support edge events", p)
+	}
+
+	// Measure the width of the next high pulse.
+	var start time.Duration
+	for {
+		ev, ok := e.WaitForEdgeEvent(-1)
+		if !ok {
+			log.Fatal("failed to wait for edge")
+		}
+		if ev.Level == gpio.High {
+			start = ev.Timestamp
+		} else if start != 0 {
+			fmt.Printf("Pulse width: %s\n", ev.Timestamp-start)
+			break
+		}
+	}
+}
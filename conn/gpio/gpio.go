@@ -238,6 +238,51 @@ type PinIO interface {
 // INVALID implements PinIO and fails on all access.
 var INVALID PinIO
 
+// EdgeEvent is an edge detected on an input pin.
+type EdgeEvent struct {
+	// Timestamp is when the edge occurred.
+	//
+	// It is a monotonic clock with an unspecified origin; only the difference
+	// between two events of the same pin is meaningful. When supported by the
+	// driver, it is the timestamp taken by the kernel upon the interrupt.
+	Timestamp time.Duration
+	// Level is the level of the pin right after the edge.
+	Level Level
+	// Seq is the sequence number of the event for this pin. It is incremented
+	// for each edge so a gap denotes that edges were lost, when the driver is
+	// able to detect it.
+	Seq uint32
+}
+
+// PinEdgeEvent is an optional interface implemented by input pins that can
+// report the time and the direction of each edge.
+//
+// The purpose of PinEdgeEvent is to measure pulse widths or frequencies, for
+// example from a tachometer, without busy polling Read().
+type PinEdgeEvent interface {
+	// WaitForEdgeEvent is like PinIn.WaitForEdge() but returns the edge event.
+	//
+	// Each call consumes one event. The same rules as WaitForEdge() apply; the
+	// pin must have been setup with In() with a value other than NoEdge.
+	//
+	// Returns false if the timeout occurred.
+	WaitForEdgeEvent(timeout time.Duration) (EdgeEvent, bool)
+}
+
+// PinDebouncer is an optional interface implemented by input pins whose
+// driver can filter out glitches and contact bounce before reporting edges.
+//
+// Use gpioutil.Debounce() to get debouncing on any pin, which uses
+// PinDebouncer when available and falls back to software filtering.
+type PinDebouncer interface {
+	// Debounce sets the period the input must be stable for before an edge is
+	// reported by WaitForEdge(). 0 disables debouncing.
+	//
+	// Returns an error if the driver or the hardware cannot debounce with this
+	// period.
+	Debounce(period time.Duration) error
+}
+
 // RealPin is implemented by aliased pin and allows the retrieval of the real
 // pin underlying an alias.
 //
@@ -250,6 +295,20 @@ type RealPin interface {
 	Real() PinIO // Real returns the real pin behind an Alias
 }
 
+// Real resolves the aliases, including aliases of aliases, and returns the
+// real pin behind p.
+//
+// It returns p as-is if it is not an alias.
+func Real(p PinIO) PinIO {
+	for {
+		r, ok := p.(RealPin)
+		if !ok {
+			return p
+		}
+		p = r.Real()
+	}
+}
+
 //
 
 // errInvalidPin is returned when trying to use INVALID.
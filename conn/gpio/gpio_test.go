@@ -103,6 +103,16 @@ func TestParseDuty(t *testing.T) {
 	}
 }
 
+func TestReal(t *testing.T) {
+	if p := Real(INVALID); p != INVALID {
+		t.Fatal(p)
+	}
+	// An alias of an alias.
+	if p := Real(&aliasPin{&aliasPin{INVALID}}); p != INVALID {
+		t.Fatal(p)
+	}
+}
+
 func TestInvalid(t *testing.T) {
 	// conn.Resource
 	if INVALID.String() != "INVALID" {
@@ -134,3 +144,13 @@ func TestInvalid(t *testing.T) {
 		t.Fatal("can't set func")
 	}
 }
+
+//
+
+type aliasPin struct {
+	PinIO
+}
+
+func (a *aliasPin) Real() PinIO {
+	return a.PinIO
+}
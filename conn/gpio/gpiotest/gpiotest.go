@@ -28,11 +28,12 @@ type Pin struct {
 
 	// Grab the Mutex before accessing the following members.
 	sync.Mutex
-	L         gpio.Level // Used for both input and output
-	P         gpio.Pull
-	EdgesChan chan gpio.Level  // Use it to fake edges
-	D         gpio.Duty        // PWM duty
-	F         physic.Frequency // PWM period
+	L          gpio.Level // Used for both input and output
+	P          gpio.Pull
+	EdgesChan  chan gpio.Level     // Use it to fake edges
+	EventsChan chan gpio.EdgeEvent // Use it to fake timestamped edges
+	D          gpio.Duty           // PWM duty
+	F          physic.Frequency    // PWM period
 }
 
 // String implements conn.Resource.
@@ -87,13 +88,14 @@ func (p *Pin) In(pull gpio.Pull, edge gpio.Edge) error {
 	} else if pull == gpio.PullUp {
 		p.L = gpio.High
 	}
-	if edge != gpio.NoEdge && p.EdgesChan == nil {
+	if edge != gpio.NoEdge && p.EdgesChan == nil && p.EventsChan == nil {
 		return errors.New("gpiotest: please set p.EdgesChan first")
 	}
 	// Flush any buffered edges.
 	for {
 		select {
 		case <-p.EdgesChan:
+		case <-p.EventsChan:
 		default:
 			return nil
 		}
@@ -108,17 +110,39 @@ func (p *Pin) Read() gpio.Level {
 }
 
 // WaitForEdge implements gpio.PinIn.
+//
+// It consumes edges from both EdgesChan and EventsChan.
 func (p *Pin) WaitForEdge(timeout time.Duration) bool {
-	if timeout == -1 {
-		_ = p.Out(<-p.EdgesChan)
-		return true
+	var t <-chan time.Time
+	if timeout != -1 {
+		t = time.After(timeout)
 	}
 	select {
-	case <-time.After(timeout):
+	case <-t:
 		return false
 	case l := <-p.EdgesChan:
 		_ = p.Out(l)
 		return true
+	case e := <-p.EventsChan:
+		_ = p.Out(e.Level)
+		return true
+	}
+}
+
+// WaitForEdgeEvent implements gpio.PinEdgeEvent.
+//
+// It consumes edges from EventsChan.
+func (p *Pin) WaitForEdgeEvent(timeout time.Duration) (gpio.EdgeEvent, bool) {
+	var t <-chan time.Time
+	if timeout != -1 {
+		t = time.After(timeout)
+	}
+	select {
+	case <-t:
+		return gpio.EdgeEvent{}, false
+	case e := <-p.EventsChan:
+		_ = p.Out(e.Level)
+		return e, true
 	}
 }
 
@@ -149,6 +173,85 @@ func (p *Pin) PWM(duty gpio.Duty, f physic.Frequency) error {
 	return nil
 }
 
+// GroupOp is one Out() operation recorded by Group.
+type GroupOp struct {
+	Mask   uint64
+	Values uint64
+}
+
+// Group implements gpio.Group on top of fake Pins.
+//
+// Each Out() call updates the Pins and is recorded in Ops, which is useful to
+// verify the sequence of values written by a parallel bus device driver.
+type Group struct {
+	// These should be immutable.
+	N       string
+	Members []*Pin
+
+	// Grab the Mutex before accessing the following members.
+	sync.Mutex
+	Ops []GroupOp
+}
+
+// String implements conn.Resource.
+func (g *Group) String() string {
+	return g.N
+}
+
+// Halt implements conn.Resource.
+//
+// It has no effect.
+func (g *Group) Halt() error {
+	return nil
+}
+
+// Pins implements gpio.Group.
+func (g *Group) Pins() []gpio.PinIO {
+	out := make([]gpio.PinIO, 0, len(g.Members))
+	for _, p := range g.Members {
+		out = append(out, p)
+	}
+	return out
+}
+
+// In implements gpio.Group.
+func (g *Group) In(pull gpio.Pull) error {
+	for _, p := range g.Members {
+		if err := p.In(pull, gpio.NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.Group.
+func (g *Group) Read() uint64 {
+	var out uint64
+	for i, p := range g.Members {
+		if p.Read() {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+// Out implements gpio.Group.
+func (g *Group) Out(mask, values uint64) error {
+	if mask>>uint(len(g.Members)) != 0 {
+		return errors.New("gpiotest: mask out of range")
+	}
+	g.Lock()
+	defer g.Unlock()
+	for i, p := range g.Members {
+		bit := uint64(1) << uint(i)
+		if mask&bit != 0 {
+			_ = p.Out(gpio.Level(values&bit != 0))
+		}
+	}
+	g.Ops = append(g.Ops, GroupOp{Mask: mask, Values: values & mask})
+	return nil
+}
+
 // LogPinIO logs when its state changes.
 type LogPinIO struct {
 	gpio.PinIO
@@ -193,4 +296,6 @@ func (p *LogPinIO) PWM(duty gpio.Duty, f physic.Frequency) error {
 }
 
 var _ gpio.PinIO = &Pin{}
+var _ gpio.PinEdgeEvent = &Pin{}
 var _ pin.PinFunc = &Pin{}
+var _ gpio.Group = &Group{}
@@ -97,6 +97,40 @@ func TestPin_edge(t *testing.T) {
 	}
 }
 
+func TestPin_edgeEvent(t *testing.T) {
+	p := &Pin{N: "GPIO1", Num: 1, Fn: "I2C1_SDA", EventsChan: make(chan gpio.EdgeEvent, 2)}
+	if err := p.In(gpio.PullNoChange, gpio.BothEdges); err != nil {
+		t.Fatal(err)
+	}
+	p.EventsChan <- gpio.EdgeEvent{Timestamp: time.Second, Level: gpio.High, Seq: 1}
+	p.EventsChan <- gpio.EdgeEvent{Timestamp: time.Second + 20*time.Millisecond, Level: gpio.Low, Seq: 2}
+	e, ok := p.WaitForEdgeEvent(-1)
+	if !ok || e.Level != gpio.High || e.Seq != 1 {
+		t.Fatal(e, ok)
+	}
+	if p.Read() != gpio.High {
+		t.Fatal("expected high")
+	}
+	e2, ok := p.WaitForEdgeEvent(time.Minute)
+	if !ok || e2.Level != gpio.Low || e2.Seq != 2 {
+		t.Fatal(e2, ok)
+	}
+	if d := e2.Timestamp - e.Timestamp; d != 20*time.Millisecond {
+		t.Fatal(d)
+	}
+	if _, ok := p.WaitForEdgeEvent(time.Millisecond); ok {
+		t.Fatal("expected timeout")
+	}
+	// WaitForEdge also consumes events.
+	p.EventsChan <- gpio.EdgeEvent{Level: gpio.High, Seq: 3}
+	if !p.WaitForEdge(time.Minute) {
+		t.Fatal("expected edge")
+	}
+	if p.Read() != gpio.High {
+		t.Fatal("expected high")
+	}
+}
+
 func TestPin_fail(t *testing.T) {
 	p := &Pin{N: "GPIO1", Num: 1, Fn: "I2C1_SDA"}
 	if err := p.In(gpio.Float, gpio.BothEdges); err == nil {
@@ -104,6 +138,44 @@ func TestPin_fail(t *testing.T) {
 	}
 }
 
+func TestGroup(t *testing.T) {
+	g := &Group{N: "bus", Members: []*Pin{{N: "D0"}, {N: "D1"}, {N: "D2"}}}
+	if s := g.String(); s != "bus" {
+		t.Fatal(s)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Pins()) != 3 {
+		t.Fatal(g.Pins())
+	}
+	if err := g.Out(7, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Out(2, 0xFF); err != nil {
+		t.Fatal(err)
+	}
+	if v := g.Read(); v != 7 {
+		t.Fatal(v)
+	}
+	expected := []GroupOp{{Mask: 7, Values: 5}, {Mask: 2, Values: 2}}
+	if !reflect.DeepEqual(g.Ops, expected) {
+		t.Fatal(g.Ops)
+	}
+	if err := g.In(gpio.PullDown); err != nil {
+		t.Fatal(err)
+	}
+	if v := g.Read(); v != 0 {
+		t.Fatal(v)
+	}
+	if g.Out(8, 8) == nil {
+		t.Fatal("mask out of range")
+	}
+	if err := g.In(gpio.Float); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestLogPinIO(t *testing.T) {
 	if !testing.Verbose() {
 		log.SetOutput(ioutil.Discard)
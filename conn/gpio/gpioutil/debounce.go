@@ -0,0 +1,99 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Debounce returns a pin that only reports an edge once the input has been
+// stable for at least period.
+//
+// This filters out both glitches, a short pulse that returns to the previous
+// level, and contact bounce, the train of pulses generated by a mechanical
+// switch or a button.
+//
+// If the real pin implements gpio.PinDebouncer and its driver accepts period,
+// debouncing is done by the driver or the hardware and p is returned as is.
+// Otherwise the returned pin filters the edges in software.
+//
+// The software filter enables edge detection on both edges on p and then
+// reports the edges as requested in In(). WaitForEdge() may return up to
+// period after the timeout, since it needs to wait for the input to settle
+// before deciding if an edge occurred. Read() returns the unfiltered level.
+func Debounce(p gpio.PinIO, period time.Duration) (gpio.PinIO, error) {
+	if p == nil {
+		return nil, errors.New("gpioutil: can't debounce a nil pin")
+	}
+	if period <= 0 {
+		return nil, errors.New("gpioutil: debounce period must be positive")
+	}
+	if d, ok := gpio.Real(p).(gpio.PinDebouncer); ok {
+		if err := d.Debounce(period); err == nil {
+			return p, nil
+		}
+	}
+	return &debounced{PinIO: p, period: period}, nil
+}
+
+//
+
+// debounced implements gpio.PinIO by filtering the edges in software.
+type debounced struct {
+	gpio.PinIO
+	period time.Duration
+
+	// Only accessed by In() and WaitForEdge().
+	edge gpio.Edge
+	last gpio.Level // Last stable level
+}
+
+// In implements gpio.PinIn.
+func (d *debounced) In(pull gpio.Pull, edge gpio.Edge) error {
+	e := edge
+	if e == gpio.RisingEdge || e == gpio.FallingEdge {
+		// Both edges are needed to track the stable level.
+		e = gpio.BothEdges
+	}
+	if err := d.PinIO.In(pull, e); err != nil {
+		return err
+	}
+	d.edge = edge
+	d.last = d.PinIO.Read()
+	return nil
+}
+
+// WaitForEdge implements gpio.PinIn.
+func (d *debounced) WaitForEdge(timeout time.Duration) bool {
+	start := time.Now()
+	for {
+		t := timeout
+		if timeout != -1 {
+			if t -= time.Since(start); t < 0 {
+				t = 0
+			}
+		}
+		if !d.PinIO.WaitForEdge(t) {
+			return false
+		}
+		// Wait for the input to settle.
+		for d.PinIO.WaitForEdge(d.period) {
+		}
+		l := d.PinIO.Read()
+		if l == d.last {
+			// Glitch.
+			continue
+		}
+		d.last = l
+		if d.edge == gpio.BothEdges || (d.edge == gpio.RisingEdge) == bool(l) {
+			return true
+		}
+	}
+}
+
+var _ gpio.PinIO = &debounced{}
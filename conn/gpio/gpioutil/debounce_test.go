@@ -0,0 +1,130 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpioutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestDebounce_glitch(t *testing.T) {
+	f := &gpiotest.Pin{N: "GPIO1", L: gpio.Low, EdgesChan: make(chan gpio.Level, 10)}
+	p, err := Debounce(f, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.In(gpio.Float, gpio.BothEdges); err != nil {
+		t.Fatal(err)
+	}
+	f.EdgesChan <- gpio.High
+	f.EdgesChan <- gpio.Low
+	if p.WaitForEdge(10 * time.Millisecond) {
+		t.Fatal("glitch must be filtered out")
+	}
+	// Bounce on press.
+	f.EdgesChan <- gpio.High
+	f.EdgesChan <- gpio.Low
+	f.EdgesChan <- gpio.High
+	if !p.WaitForEdge(-1) {
+		t.Fatal("expected edge")
+	}
+	if l := p.Read(); l != gpio.High {
+		t.Fatal(l)
+	}
+	if p.WaitForEdge(0) {
+		t.Fatal("bounce must be filtered out")
+	}
+}
+
+func TestDebounce_rising(t *testing.T) {
+	f := &gpiotest.Pin{N: "GPIO1", L: gpio.Low, EdgesChan: make(chan gpio.Level, 10)}
+	p, err := Debounce(f, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.In(gpio.Float, gpio.RisingEdge); err != nil {
+		t.Fatal(err)
+	}
+	f.EdgesChan <- gpio.High
+	if !p.WaitForEdge(time.Second) {
+		t.Fatal("expected rising edge")
+	}
+	f.EdgesChan <- gpio.Low
+	if p.WaitForEdge(10 * time.Millisecond) {
+		t.Fatal("falling edge must be ignored")
+	}
+	f.EdgesChan <- gpio.High
+	if !p.WaitForEdge(time.Second) {
+		t.Fatal("expected rising edge")
+	}
+}
+
+func TestDebounce_hardware(t *testing.T) {
+	f := &debouncerPin{Pin: gpiotest.Pin{N: "GPIO1"}}
+	if err := gpioreg.Register(f); err != nil {
+		t.Fatal(err)
+	}
+	defer gpioreg.Unregister("GPIO1")
+	if err := gpioreg.RegisterAlias("BUTTON", "GPIO1"); err != nil {
+		t.Fatal(err)
+	}
+	defer gpioreg.Unregister("BUTTON")
+	a := gpioreg.ByName("BUTTON")
+	p, err := Debounce(a, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != a {
+		t.Fatal("expected the pin as is")
+	}
+	if f.period != 5*time.Millisecond {
+		t.Fatal(f.period)
+	}
+	f.err = errors.New("unsupported period")
+	if p, err = Debounce(a, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*debounced); !ok {
+		t.Fatal("expected software fallback")
+	}
+}
+
+func TestDebounce_error(t *testing.T) {
+	if _, err := Debounce(nil, time.Millisecond); err == nil {
+		t.Fatal("nil pin")
+	}
+	if _, err := Debounce(&gpiotest.Pin{}, 0); err == nil {
+		t.Fatal("invalid period")
+	}
+	f := &gpiotest.Pin{}
+	p, err := Debounce(f, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.In(gpio.Float, gpio.BothEdges) == nil {
+		t.Fatal("gpiotest.Pin.EdgesChan is not set")
+	}
+}
+
+//
+
+type debouncerPin struct {
+	gpiotest.Pin
+	period time.Duration
+	err    error
+}
+
+func (d *debouncerPin) Debounce(period time.Duration) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.period = period
+	return nil
+}
@@ -0,0 +1,6 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package gpioutil includes utilities to filter or augment GPIOs.
+package gpioutil
@@ -0,0 +1,132 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"periph.io/x/periph/conn"
+)
+
+// Group is a set of GPIO pins that are read and written as a single unit,
+// for example a parallel bus.
+//
+// Bit i of the masks and values is the pin Pins()[i]. A Group contains at most
+// 64 pins.
+type Group interface {
+	conn.Resource
+	// Pins returns the pins in the group, in bit order.
+	Pins() []PinIO
+	// In setups all the pins as input with the specified pull resistor.
+	//
+	// Edge detection is not supported on a Group.
+	In(pull Pull) error
+	// Read returns the current level of all the pins as a bitmask.
+	Read() uint64
+	// Out sets the pins selected in mask to the level of the corresponding bit
+	// in values.
+	//
+	// The pins are set as output if they weren't already. The pins not selected
+	// in mask are not modified. When supported by the driver, all the pins on
+	// the same GPIO port are changed simultaneously.
+	Out(mask, values uint64) error
+}
+
+// PinGrouper is an optional interface implemented by pins whose driver can
+// provide a faster Group implementation than accessing each pin sequentially.
+type PinGrouper interface {
+	// Group returns a Group containing pins.
+	//
+	// It returns an error if one of the pins is not handled by this driver.
+	Group(pins []PinIO) (Group, error)
+}
+
+// NewGroup returns a Group containing pins.
+//
+// If the real pin of the first pin implements PinGrouper and its driver
+// accepts all the pins, the driver's Group is returned. Otherwise the returned
+// Group accesses each pin sequentially.
+func NewGroup(pins ...PinIO) (Group, error) {
+	if len(pins) == 0 {
+		return nil, errors.New("gpio: a group must have at least one pin")
+	}
+	if len(pins) > 64 {
+		return nil, errors.New("gpio: a group can't have more than 64 pins, got " + strconv.Itoa(len(pins)))
+	}
+	for _, p := range pins {
+		if p == nil {
+			return nil, errors.New("gpio: can't add a nil pin to a group")
+		}
+	}
+	if pg, ok := Real(pins[0]).(PinGrouper); ok {
+		if g, err := pg.Group(pins); err == nil {
+			return g, nil
+		}
+	}
+	return &pinGroup{pins: append([]PinIO(nil), pins...)}, nil
+}
+
+//
+
+// pinGroup implements Group by accessing each pin sequentially.
+type pinGroup struct {
+	pins []PinIO
+}
+
+func (g *pinGroup) String() string {
+	names := make([]string, 0, len(g.pins))
+	for _, p := range g.pins {
+		names = append(names, p.Name())
+	}
+	return "Group(" + strings.Join(names, ",") + ")"
+}
+
+func (g *pinGroup) Halt() error {
+	for _, p := range g.pins {
+		if err := p.Halt(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *pinGroup) Pins() []PinIO {
+	return g.pins
+}
+
+func (g *pinGroup) In(pull Pull) error {
+	for _, p := range g.pins {
+		if err := p.In(pull, NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *pinGroup) Read() uint64 {
+	var out uint64
+	for i, p := range g.pins {
+		if p.Read() {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+func (g *pinGroup) Out(mask, values uint64) error {
+	for i, p := range g.pins {
+		bit := uint64(1) << uint(i)
+		if mask&bit != 0 {
+			if err := p.Out(Level(values&bit != 0)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var _ Group = &pinGroup{}
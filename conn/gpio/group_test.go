@@ -0,0 +1,131 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gpio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewGroup(t *testing.T) {
+	pins := []PinIO{&groupPin{name: "A"}, &groupPin{name: "B"}, &groupPin{name: "C"}}
+	g, err := NewGroup(pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "Group(A,B,C)" {
+		t.Fatal(s)
+	}
+	if len(g.Pins()) != 3 {
+		t.Fatal(g.Pins())
+	}
+	if err := g.Out(5, 4); err != nil {
+		t.Fatal(err)
+	}
+	if pins[0].Read() != Low || pins[2].Read() != High {
+		t.Fatal("unexpected levels")
+	}
+	if pins[1].(*groupPin).out {
+		t.Fatal("pin B must not be touched")
+	}
+	if v := g.Read(); v != 4 {
+		t.Fatal(v)
+	}
+	if err := g.In(PullUp); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	pins[1].(*groupPin).err = errors.New("oops")
+	if g.Out(2, 2) == nil {
+		t.Fatal("expected failure")
+	}
+	if g.In(PullUp) == nil {
+		t.Fatal("expected failure")
+	}
+	if g.Halt() == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestNewGroup_grouper(t *testing.T) {
+	p := &grouperPin{groupPin: groupPin{name: "A"}}
+	g, err := NewGroup(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g != p.g {
+		t.Fatal("expected the driver's group")
+	}
+	p.err = errors.New("unsupported")
+	if g, err = NewGroup(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*pinGroup); !ok {
+		t.Fatal("expected fallback")
+	}
+}
+
+func TestNewGroup_error(t *testing.T) {
+	if _, err := NewGroup(); err == nil {
+		t.Fatal("empty")
+	}
+	if _, err := NewGroup(nil); err == nil {
+		t.Fatal("nil")
+	}
+	pins := make([]PinIO, 65)
+	for i := range pins {
+		pins[i] = INVALID
+	}
+	if _, err := NewGroup(pins...); err == nil {
+		t.Fatal("too many pins")
+	}
+}
+
+//
+
+type groupPin struct {
+	invalidPin
+	name string
+	err  error
+	out  bool
+	l    Level
+}
+
+func (g *groupPin) Name() string {
+	return g.name
+}
+
+func (g *groupPin) Halt() error {
+	return g.err
+}
+
+func (g *groupPin) In(Pull, Edge) error {
+	return g.err
+}
+
+func (g *groupPin) Read() Level {
+	return g.l
+}
+
+func (g *groupPin) Out(l Level) error {
+	g.out = true
+	g.l = l
+	return g.err
+}
+
+type grouperPin struct {
+	groupPin
+	g Group
+}
+
+func (g *grouperPin) Group(pins []PinIO) (Group, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	g.g = &pinGroup{pins: pins}
+	return g.g, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package i2c
+
+import (
+	"context"
+
+	"periph.io/x/periph/conn"
+)
+
+// BusContext is implemented by a Bus that supports cancellation and deadlines
+// of a transaction.
+type BusContext interface {
+	Bus
+	// TxContext does the same as Tx() but returns early when ctx is done.
+	//
+	// It returns a *conn.TimeoutError when the deadline of ctx expired and
+	// ctx.Err() when ctx was canceled.
+	TxContext(ctx context.Context, addr uint16, w, r []byte) error
+}
+
+// TxContext does a transaction on b and returns early when ctx is done.
+//
+// If b doesn't implement BusContext, the transaction is run in a goroutine via
+// conn.RunTxContext().
+func TxContext(ctx context.Context, b Bus, addr uint16, w, r []byte) error {
+	if bc, ok := b.(BusContext); ok {
+		return bc.TxContext(ctx, addr, w, r)
+	}
+	return conn.RunTxContext(ctx, b.String()+".Tx", w, r, func(w, r []byte) error {
+		return b.Tx(addr, w, r)
+	})
+}
+
+// TxContext implements conn.ConnContext.
+func (d *Dev) TxContext(ctx context.Context, w, r []byte) error {
+	return TxContext(ctx, d.Bus, d.Addr, w, r)
+}
+
+var _ conn.ConnContext = &Dev{}
@@ -0,0 +1,53 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package i2c
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"periph.io/x/periph/conn"
+)
+
+func TestDev_TxContext(t *testing.T) {
+	b := &fakeBus{r: []byte{2}}
+	d := Dev{Bus: b, Addr: 12}
+	r := make([]byte, 1)
+	if err := d.TxContext(context.Background(), []byte{1}, r); err != nil {
+		t.Fatal(err)
+	}
+	if b.addr != 12 || !bytes.Equal(b.w, []byte{1}) || !bytes.Equal(r, []byte{2}) {
+		t.Fatal(b.addr, b.w, r)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := conn.TxContext(ctx, &d, nil, nil); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
+func TestTxContext_BusContext(t *testing.T) {
+	b := &ctxBus{}
+	if err := TxContext(context.Background(), b, 12, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.addr != 12 {
+		t.Fatal(b.addr)
+	}
+}
+
+//
+
+type ctxBus struct {
+	fakeBus
+}
+
+func (c *ctxBus) TxContext(ctx context.Context, addr uint16, w, r []byte) error {
+	c.addr = addr
+	return nil
+}
@@ -19,8 +19,10 @@
 package i2c
 
 import (
+	"errors"
 	"io"
 	"strconv"
+	"strings"
 
 	"periph.io/x/periph/conn"
 	"periph.io/x/periph/conn/gpio"
@@ -69,6 +71,71 @@ type Pins interface {
 	SDA() gpio.PinIO
 }
 
+// Flags modifies how a message is sent on the bus.
+type Flags uint16
+
+const (
+	// Ten specifies that the message address is a 10 bits address.
+	Ten Flags = 1 << iota
+	// NoStart skips the repeated START condition and the address before this
+	// message, so its data directly continues the previous message. It is
+	// ignored on the first message.
+	NoStart
+	// IgnoreNAK continues the message even if the device doesn't acknowledge.
+	IgnoreNAK
+)
+
+func (f Flags) String() string {
+	var out []string
+	if f&Ten != 0 {
+		out = append(out, "Ten")
+		f &^= Ten
+	}
+	if f&NoStart != 0 {
+		out = append(out, "NoStart")
+		f &^= NoStart
+	}
+	if f&IgnoreNAK != 0 {
+		out = append(out, "IgnoreNAK")
+		f &^= IgnoreNAK
+	}
+	if f != 0 || len(out) == 0 {
+		out = append(out, "0x"+strconv.FormatUint(uint64(f), 16))
+	}
+	return strings.Join(out, "|")
+}
+
+// Msg represents one message when sending multiple messages as a single
+// transaction.
+type Msg struct {
+	Addr  uint16
+	Flags Flags
+	// W and R are the output and input data. Only one of the two can be set.
+	W, R []byte
+}
+
+// MsgBus is implemented by a Bus that can send an arbitrary sequence of
+// messages as a single transaction.
+//
+// This is needed for example to write a 16 bits memory address then read from
+// an EEPROM, or when a device requires a specific combination of repeated
+// START conditions.
+type MsgBus interface {
+	Bus
+	// TxMsgs sends the messages in order, separated by repeated START
+	// conditions, with a single STOP condition at the end.
+	//
+	// The bus is held for the whole duration of the transaction.
+	TxMsgs(m []Msg) error
+}
+
+// ErrMsgUnsupported is returned by MsgBus.TxMsgs() when the bus can't send
+// messages after all, e.g. a wrapper around a Bus that doesn't implement
+// MsgBus.
+//
+// The caller can fall back to Bus.Tx() when possible.
+var ErrMsgUnsupported = errors.New("i2c: TxMsgs() is not supported by this bus")
+
 // Dev is a device on a I²C bus.
 //
 // It implements conn.Conn.
@@ -75,6 +75,23 @@ func TestDevWriteErr(t *testing.T) {
 	}
 }
 
+func TestFlags_String(t *testing.T) {
+	data := []struct {
+		f        Flags
+		expected string
+	}{
+		{0, "0x0"},
+		{Ten, "Ten"},
+		{NoStart | IgnoreNAK, "NoStart|IgnoreNAK"},
+		{Ten | 0x100, "Ten|0x100"},
+	}
+	for i, line := range data {
+		if s := line.f.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+}
+
 //
 
 type fakeBus struct {
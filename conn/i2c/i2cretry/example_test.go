@@ -0,0 +1,42 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2cretry_test
+
+import (
+	"fmt"
+	"log"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/i2cretry"
+	"periph.io/x/periph/host"
+)
+
+func Example() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use i2creg I²C bus registry to find the first available I²C bus.
+	b, err := i2creg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer b.Close()
+
+	// Retry the failed transactions and recover the bus if a device holds SDA
+	// low.
+	r := i2cretry.New(b, &i2cretry.DefaultOpts)
+
+	// Use r like any other bus, e.g. pass it to a device driver.
+	d := &i2c.Dev{Bus: r, Addr: 0x76}
+	var id [1]byte
+	if err := d.Tx([]byte{0xD0}, id[:]); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("id: 0x%02X\n", id[0])
+	fmt.Printf("stats: %+v\n", r.Stats())
+}
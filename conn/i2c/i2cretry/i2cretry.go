@@ -0,0 +1,321 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package i2cretry wraps an i2c.Bus to transparently retry failed
+// transactions.
+//
+// It is meant for long cable runs or noisy environments where a device
+// occasionally NACKs or a glitch leaves a device holding SDA low. When the bus
+// implements i2c.Pins, the bus is recovered between two attempts by clocking
+// SCL until the device releases SDA, as described in section 3.1.16 of the
+// I²C specification UM10204.
+//
+// Only reads are retried by default, see Opts.Idempotent.
+package i2cretry
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Opts defines the retry policy.
+type Opts struct {
+	// Retries is the number of times a failed transaction is retried.
+	Retries int
+	// Backoff is the delay before the first retry. It is doubled after each
+	// retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Recover enables the bus recovery sequence before each retry. It is
+	// ignored if the bus doesn't implement i2c.Pins.
+	Recover bool
+	// Idempotent returns true if a transaction can safely be retried, e.g. a
+	// register read.
+	//
+	// When nil, only reads are retried: a Tx() that reads, typically after
+	// writing the register pointer, or a TxMsgs() whose last message reads.
+	// Retrying a write may apply it twice, e.g. to a FIFO or a counter, so it
+	// must be opted in by returning true for it.
+	//
+	// For TxMsgs(), it is called with the address and the data written of
+	// each message. For SMBusTx(), w is the command code followed by the data
+	// written, if any.
+	Idempotent func(addr uint16, w []byte) bool
+}
+
+// DefaultOpts is the recommended default options.
+var DefaultOpts = Opts{
+	Retries:    3,
+	Backoff:    time.Millisecond,
+	MaxBackoff: 10 * time.Millisecond,
+	Recover:    true,
+}
+
+// Stats is the number of events that happened on a Bus.
+type Stats struct {
+	// Tx is the number of transactions requested.
+	Tx uint64
+	// Retries is the number of attempts done after a failed one.
+	Retries uint64
+	// Failures is the number of transactions that failed after all retries.
+	Failures uint64
+	// Recoveries is the number of times SDA was found stuck low and the bus
+	// was recovered.
+	Recoveries uint64
+}
+
+// Bus is an i2c.BusCloser that retries failed transactions.
+//
+// It also implements i2c.MsgBus, smbus.Bus and i2c.Pins when the wrapped bus
+// does. Transactions are serialized so no other transaction happens while
+// the bus is being recovered.
+type Bus interface {
+	i2c.BusCloser
+	// Stats returns the number of events that happened since the Bus was
+	// created.
+	Stats() Stats
+}
+
+// New returns a Bus that retries the transactions failing on b.
+//
+// opts can be nil to use DefaultOpts.
+func New(b i2c.Bus, opts *Opts) Bus {
+	if opts == nil {
+		opts = &DefaultOpts
+	}
+	r := &bus{bus: b, opts: *opts}
+	_, isMsg := b.(i2c.MsgBus)
+	_, isSMBus := b.(smbus.Bus)
+	_, isPins := b.(i2c.Pins)
+	switch {
+	case isMsg && isSMBus && isPins:
+		return &struct {
+			*bus
+			msgBus
+			smbusBus
+			pinsBus
+		}{r, msgBus{r}, smbusBus{r}, pinsBus{r}}
+	case isMsg && isSMBus:
+		return &struct {
+			*bus
+			msgBus
+			smbusBus
+		}{r, msgBus{r}, smbusBus{r}}
+	case isMsg && isPins:
+		return &struct {
+			*bus
+			msgBus
+			pinsBus
+		}{r, msgBus{r}, pinsBus{r}}
+	case isSMBus && isPins:
+		return &struct {
+			*bus
+			smbusBus
+			pinsBus
+		}{r, smbusBus{r}, pinsBus{r}}
+	case isMsg:
+		return &struct {
+			*bus
+			msgBus
+		}{r, msgBus{r}}
+	case isSMBus:
+		return &struct {
+			*bus
+			smbusBus
+		}{r, smbusBus{r}}
+	case isPins:
+		return &struct {
+			*bus
+			pinsBus
+		}{r, pinsBus{r}}
+	default:
+		return r
+	}
+}
+
+//
+
+// sleep is overridden in unit tests.
+var sleep = time.Sleep
+
+// bus implements Bus for an i2c.Bus.
+type bus struct {
+	bus  i2c.Bus
+	opts Opts
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+func (b *bus) String() string {
+	return b.bus.String()
+}
+
+// Close implements i2c.BusCloser.
+//
+// It closes the bus if it implements io.Closer.
+func (b *bus) Close() error {
+	if c, ok := b.bus.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Tx implements i2c.Bus.
+func (b *bus) Tx(addr uint16, w, r []byte) error {
+	var retry bool
+	if b.opts.Idempotent == nil {
+		retry = len(r) != 0
+	} else {
+		retry = b.opts.Idempotent(addr, w)
+	}
+	return b.do(retry, func() error {
+		return b.bus.Tx(addr, w, r)
+	})
+}
+
+// SetSpeed implements i2c.Bus.
+func (b *bus) SetSpeed(f physic.Frequency) error {
+	return b.bus.SetSpeed(f)
+}
+
+// Stats implements Bus.
+func (b *bus) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// do runs tx until it succeeds, the retries are exhausted or retry is false.
+//
+// The error of the last attempt is returned.
+func (b *bus) do(retry bool, tx func() error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Tx++
+	err := tx()
+	if err == nil || !retry {
+		if err != nil {
+			b.stats.Failures++
+		}
+		return err
+	}
+	backoff := b.opts.Backoff
+	for i := 0; i < b.opts.Retries; i++ {
+		if b.opts.Recover {
+			if p, ok := b.bus.(i2c.Pins); ok {
+				// A failed recovery is not fatal, the retry will report the
+				// actual error.
+				if recovered, _ := Recover(p); recovered {
+					b.stats.Recoveries++
+				}
+			}
+		}
+		sleep(backoff)
+		if backoff *= 2; b.opts.MaxBackoff != 0 && backoff > b.opts.MaxBackoff {
+			backoff = b.opts.MaxBackoff
+		}
+		b.stats.Retries++
+		if err = tx(); err == nil {
+			return nil
+		}
+	}
+	b.stats.Failures++
+	return err
+}
+
+// msgBus adds i2c.MsgBus to a bus wrapping an i2c.MsgBus.
+type msgBus struct {
+	b *bus
+}
+
+// TxMsgs implements i2c.MsgBus.
+//
+// The transaction is retried only if all the messages are idempotent.
+func (m msgBus) TxMsgs(msgs []i2c.Msg) error {
+	b := m.b
+	retry := true
+	if b.opts.Idempotent == nil {
+		retry = len(msgs) != 0 && len(msgs[len(msgs)-1].R) != 0
+	} else {
+		for i := range msgs {
+			if !b.opts.Idempotent(msgs[i].Addr, msgs[i].W) {
+				retry = false
+				break
+			}
+		}
+	}
+	return b.do(retry, func() error {
+		return b.bus.(i2c.MsgBus).TxMsgs(msgs)
+	})
+}
+
+// smbusBus adds smbus.Bus to a bus wrapping a smbus.Bus.
+type smbusBus struct {
+	b *bus
+}
+
+// SMBusSupports implements smbus.Bus.
+func (s smbusBus) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	return s.b.bus.(smbus.Bus).SMBusSupports(p, read, pec)
+}
+
+// SMBusTx implements smbus.Bus.
+//
+// By default only reads are retried; a process call is considered a write.
+func (s smbusBus) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	b := s.b
+	var retry bool
+	if b.opts.Idempotent == nil {
+		retry = read && p != smbus.ProcCall
+	} else {
+		var w []byte
+		if p != smbus.Quick && p != smbus.Byte {
+			w = []byte{cmd}
+		}
+		if p != smbus.Quick && (!read || p == smbus.ProcCall) {
+			w = append(w, data...)
+		}
+		retry = b.opts.Idempotent(addr, w)
+	}
+	var n int
+	err := b.do(retry, func() error {
+		var err error
+		n, err = b.bus.(smbus.Bus).SMBusTx(addr, p, read, cmd, data, pec)
+		return err
+	})
+	return n, err
+}
+
+// pinsBus adds i2c.Pins to a bus wrapping an i2c.Pins.
+type pinsBus struct {
+	b *bus
+}
+
+// SCL implements i2c.Pins.
+func (p pinsBus) SCL() gpio.PinIO {
+	return p.b.bus.(i2c.Pins).SCL()
+}
+
+// SDA implements i2c.Pins.
+func (p pinsBus) SDA() gpio.PinIO {
+	return p.b.bus.(i2c.Pins).SDA()
+}
+
+var _ Bus = &bus{}
+var _ i2c.MsgBus = &struct {
+	*bus
+	msgBus
+}{}
+var _ smbus.Bus = &struct {
+	*bus
+	smbusBus
+}{}
+var _ i2c.Pins = pinsBus{}
@@ -0,0 +1,471 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2cretry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+func TestBus_Tx(t *testing.T) {
+	var delays []time.Duration
+	defer setSleep(&delays)()
+	f := &flakyBus{fail: 3}
+	b := New(f, &Opts{Retries: 3, Backoff: time.Millisecond, MaxBackoff: 3 * time.Millisecond})
+	if s := b.String(); s != "flaky" {
+		t.Fatal(s)
+	}
+	if err := b.Tx(0x10, []byte{1}, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	if f.calls != 4 {
+		t.Fatal(f.calls)
+	}
+	expected := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}
+	if !reflect.DeepEqual(delays, expected) {
+		t.Fatal(delays)
+	}
+	if s := b.Stats(); s != (Stats{Tx: 1, Retries: 3}) {
+		t.Fatal(s)
+	}
+}
+
+func TestBus_Tx_fail(t *testing.T) {
+	defer setSleep(nil)()
+	f := &flakyBus{fail: 10}
+	b := New(f, nil)
+	if err := b.Tx(0x10, nil, []byte{0}); err == nil || err.Error() != "flaky: NACK" {
+		t.Fatal(err)
+	}
+	if f.calls != 4 {
+		t.Fatal(f.calls)
+	}
+	if s := b.Stats(); s != (Stats{Tx: 1, Retries: 3, Failures: 1}) {
+		t.Fatal(s)
+	}
+}
+
+func TestBus_Tx_write(t *testing.T) {
+	defer setSleep(nil)()
+	// Writes are not retried by default.
+	f := &flakyMsgBus{flakyBus{fail: 1}}
+	b := New(f, nil).(i2c.MsgBus)
+	if err := b.Tx(0x10, []byte{1, 2}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	f.fail = 1
+	if err := b.TxMsgs([]i2c.Msg{{Addr: 0x10, R: []byte{0}}, {Addr: 0x10, W: []byte{1}}}); err == nil {
+		t.Fatal("expected error")
+	}
+	if f.calls != 2 {
+		t.Fatal(f.calls)
+	}
+	f.fail = 1
+	if err := b.TxMsgs([]i2c.Msg{{Addr: 0x10, W: []byte{1}}, {Addr: 0x10, R: []byte{0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.(Bus).Stats(); s != (Stats{Tx: 3, Retries: 1, Failures: 2}) {
+		t.Fatal(s)
+	}
+	// Opt-in.
+	opts := DefaultOpts
+	opts.Idempotent = func(addr uint16, w []byte) bool { return true }
+	f.fail = 1
+	if err := New(f, &opts).Tx(0x10, []byte{1, 2}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBus_Tx_notIdempotent(t *testing.T) {
+	defer setSleep(nil)()
+	f := &flakyBus{fail: 1}
+	opts := DefaultOpts
+	opts.Idempotent = func(addr uint16, w []byte) bool {
+		return len(w) == 1
+	}
+	b := New(f, &opts)
+	if err := b.Tx(0x10, []byte{1, 2}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if f.calls != 1 {
+		t.Fatal(f.calls)
+	}
+	if err := b.Tx(0x10, []byte{1}, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.Stats(); s != (Stats{Tx: 2, Failures: 1}) {
+		t.Fatal(s)
+	}
+}
+
+func TestBus_TxMsgs(t *testing.T) {
+	defer setSleep(nil)()
+	f := &flakyMsgBus{flakyBus{fail: 1}}
+	opts := DefaultOpts
+	opts.Idempotent = func(addr uint16, w []byte) bool {
+		return addr == 0x10
+	}
+	b := New(f, &opts)
+	mb, ok := b.(i2c.MsgBus)
+	if !ok {
+		t.Fatal("flakyMsgBus implements i2c.MsgBus")
+	}
+	m := []i2c.Msg{{Addr: 0x10, W: []byte{1}}, {Addr: 0x10, R: []byte{0}}}
+	if err := mb.TxMsgs(m); err != nil {
+		t.Fatal(err)
+	}
+	f.fail = 1
+	m[1].Addr = 0x11
+	if err := mb.TxMsgs(m); err == nil {
+		t.Fatal("expected error")
+	}
+	if s := b.Stats(); s != (Stats{Tx: 2, Retries: 1, Failures: 1}) {
+		t.Fatal(s)
+	}
+}
+
+func TestBus_SMBus(t *testing.T) {
+	defer setSleep(nil)()
+	f := &flakySMBus{flakyBus: flakyBus{fail: 1}}
+	b := New(f, nil)
+	s, ok := b.(smbus.Bus)
+	if !ok {
+		t.Fatal("flakySMBus implements smbus.Bus")
+	}
+	if !s.SMBusSupports(smbus.WordData, true, false) {
+		t.Fatal("expected support")
+	}
+	d := smbus.Dev{Bus: s, Addr: 0x10}
+	if v, err := d.ReadWord(0x20); err != nil || v != 0x0201 {
+		t.Fatal(v, err)
+	}
+	// Writes are not retried by default.
+	f.fail = 1
+	if err := d.WriteByteData(0x20, 1); err == nil {
+		t.Fatal("expected error")
+	}
+	if f.calls != 3 || f.smbus != 3 {
+		t.Fatal(f.calls, f.smbus)
+	}
+	if s := b.Stats(); s != (Stats{Tx: 2, Retries: 1, Failures: 1}) {
+		t.Fatal(s)
+	}
+	// Opt-in; the command code is passed along the data written.
+	var w []byte
+	opts := DefaultOpts
+	opts.Idempotent = func(addr uint16, d []byte) bool {
+		w = d
+		return true
+	}
+	f.fail = 1
+	d.Bus = New(f, &opts).(smbus.Bus)
+	if err := d.WriteByteData(0x20, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(w, []byte{0x20, 1}) {
+		t.Fatal(w)
+	}
+}
+
+func TestBus_Scan(t *testing.T) {
+	defer setSleep(nil)()
+	b := New(&ackBus{ack: 0x40}, nil)
+	if _, ok := b.(i2c.MsgBus); ok {
+		t.Fatal("ackBus doesn't implement i2c.MsgBus")
+	}
+	if _, ok := b.(smbus.Bus); ok {
+		t.Fatal("ackBus doesn't implement smbus.Bus")
+	}
+	found, err := smbus.Scan(b, 0x03, 0x77, smbus.ProbeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(found, []uint16{0x40}) {
+		t.Fatal(found)
+	}
+}
+
+func TestBus_Recover(t *testing.T) {
+	defer setSleep(nil)()
+	scl := &sclPin{Pin: gpiotest.Pin{N: "SCL", Fn: "I2C1_SCL"}}
+	sda := &sdaPin{Pin: gpiotest.Pin{N: "SDA", Fn: "I2C1_SDA"}, scl: scl, hold: 5}
+	f := &flakyPinsBus{flakyBus{fail: 1}, scl, sda}
+	b := New(f, nil)
+	p, ok := b.(i2c.Pins)
+	if !ok {
+		t.Fatal("flakyPinsBus implements i2c.Pins")
+	}
+	if p.SCL() != scl || p.SDA() != sda {
+		t.Fatal("unexpected pins")
+	}
+	if err := b.Tx(0x10, nil, []byte{0}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.Stats(); s != (Stats{Tx: 1, Retries: 1, Recoveries: 1}) {
+		t.Fatal(s)
+	}
+	if scl.fn != "I2C1_SCL" || sda.fn != "I2C1_SDA" {
+		t.Fatal("function not restored", scl.fn, sda.fn)
+	}
+}
+
+func TestBus_misc(t *testing.T) {
+	b := New(&flakyBus{}, nil)
+	if _, ok := b.(i2c.Pins); ok {
+		t.Fatal("flakyBus doesn't implement i2c.Pins")
+	}
+	if _, ok := b.(i2c.MsgBus); ok {
+		t.Fatal("flakyBus doesn't implement i2c.MsgBus")
+	}
+	if err := b.SetSpeed(physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	defer setSleep(nil)()
+	scl := &sclPin{Pin: gpiotest.Pin{Fn: string(gpio.IN)}}
+	sda := &sdaPin{Pin: gpiotest.Pin{Fn: string(gpio.IN)}, scl: scl, hold: 3}
+	if stuck, err := Recover(&pins{scl, sda}); !stuck || err != nil {
+		t.Fatal(stuck, err)
+	}
+	// 3 clocks to release SDA then one for the STOP condition.
+	if scl.clocks != 4 {
+		t.Fatal(scl.clocks)
+	}
+	if sda.Read() != gpio.High {
+		t.Fatal("SDA must be released")
+	}
+	// Not stuck.
+	if stuck, err := Recover(&pins{scl, sda}); stuck || err != nil {
+		t.Fatal(stuck, err)
+	}
+}
+
+func TestRecover_alias(t *testing.T) {
+	defer setSleep(nil)()
+	scl := &sclPin{Pin: gpiotest.Pin{N: "SCL", Fn: "I2C1_SCL"}}
+	sda := &sdaPin{Pin: gpiotest.Pin{N: "SDA", Fn: "I2C1_SDA"}, scl: scl, hold: 2}
+	// The aliases don't implement pin.PinFunc, the real pins do.
+	if stuck, err := Recover(&pins{&aliasPin{scl}, &aliasPin{sda}}); !stuck || err != nil {
+		t.Fatal(stuck, err)
+	}
+	if scl.fn != "I2C1_SCL" || sda.fn != "I2C1_SDA" {
+		t.Fatal("function not restored", scl.fn, sda.fn)
+	}
+}
+
+func TestRecover_unknownFunc(t *testing.T) {
+	defer setSleep(nil)()
+	// The function can't be saved.
+	scl := &sclPin{Pin: gpiotest.Pin{Fn: "I2C1_SCL"}}
+	sda := &gpiotest.Pin{Fn: "I2C1_SDA"}
+	if _, err := Recover(&pins{scl, &noFuncPin{sda}}); err == nil {
+		t.Fatal("expected error")
+	}
+	// The pin is not used as I²C.
+	if _, err := Recover(&pins{scl, &gpiotest.Pin{Fn: "UART1_TX"}}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := Recover(&pins{&gpiotest.Pin{}, sda}); err == nil {
+		t.Fatal("expected error")
+	}
+	if scl.clocks != 0 || scl.fn != "" {
+		t.Fatal("the pins must not be touched")
+	}
+}
+
+func TestRecover_fail(t *testing.T) {
+	defer setSleep(nil)()
+	scl := &sclPin{Pin: gpiotest.Pin{Fn: string(gpio.OUT_HIGH)}}
+	sda := &sdaPin{Pin: gpiotest.Pin{Fn: string(gpio.IN)}, scl: scl, hold: 100}
+	if stuck, err := Recover(&pins{scl, sda}); !stuck || err == nil {
+		t.Fatal(stuck, err)
+	}
+	if scl.clocks != 10 {
+		t.Fatal(scl.clocks)
+	}
+	if _, err := Recover(&pins{gpio.INVALID, gpio.INVALID}); err == nil {
+		t.Fatal("pins are unknown")
+	}
+}
+
+//
+
+func setSleep(delays *[]time.Duration) func() {
+	sleep = func(d time.Duration) {
+		if delays != nil {
+			*delays = append(*delays, d)
+		}
+	}
+	return func() {
+		sleep = time.Sleep
+	}
+}
+
+// flakyBus fails the first fail transactions.
+type flakyBus struct {
+	fail  int
+	calls int
+}
+
+func (f *flakyBus) String() string {
+	return "flaky"
+}
+
+func (f *flakyBus) Tx(addr uint16, w, r []byte) error {
+	f.calls++
+	if f.fail > 0 {
+		f.fail--
+		return errors.New("flaky: NACK")
+	}
+	return nil
+}
+
+func (f *flakyBus) SetSpeed(freq physic.Frequency) error {
+	return nil
+}
+
+type flakyMsgBus struct {
+	flakyBus
+}
+
+func (f *flakyMsgBus) TxMsgs(m []i2c.Msg) error {
+	return f.Tx(m[0].Addr, nil, nil)
+}
+
+type flakySMBus struct {
+	flakyBus
+	smbus int
+}
+
+func (f *flakySMBus) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	return !pec
+}
+
+func (f *flakySMBus) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	f.smbus++
+	if err := f.Tx(addr, nil, nil); err != nil {
+		return 0, err
+	}
+	if !read {
+		return len(data), nil
+	}
+	for i := range data {
+		data[i] = byte(i + 1)
+	}
+	return len(data), nil
+}
+
+// ackBus only ACKs transactions to ack and implements neither i2c.MsgBus nor
+// smbus.Bus.
+type ackBus struct {
+	flakyBus
+	ack uint16
+}
+
+func (a *ackBus) Tx(addr uint16, w, r []byte) error {
+	if addr != a.ack {
+		return errors.New("ack: NACK")
+	}
+	return nil
+}
+
+type flakyPinsBus struct {
+	flakyBus
+	scl, sda gpio.PinIO
+}
+
+func (f *flakyPinsBus) SCL() gpio.PinIO {
+	return f.scl
+}
+
+func (f *flakyPinsBus) SDA() gpio.PinIO {
+	return f.sda
+}
+
+type pins struct {
+	scl, sda gpio.PinIO
+}
+
+func (p *pins) SCL() gpio.PinIO {
+	return p.scl
+}
+
+func (p *pins) SDA() gpio.PinIO {
+	return p.sda
+}
+
+// aliasPin is an alias to a real pin, like the ones in gpioreg. It doesn't
+// implement pin.PinFunc.
+type aliasPin struct {
+	gpio.PinIO
+}
+
+func (a *aliasPin) Real() gpio.PinIO {
+	return a.PinIO
+}
+
+// noFuncPin hides pin.PinFunc and is not an alias.
+type noFuncPin struct {
+	gpio.PinIO
+}
+
+// sclPin counts the clocks and supports switching back to its I²C function.
+type sclPin struct {
+	gpiotest.Pin
+	clocks int
+	fn     pin.Func
+}
+
+func (s *sclPin) Out(l gpio.Level) error {
+	if l == gpio.Low {
+		s.clocks++
+	}
+	s.fn = gpio.OUT
+	return s.Pin.Out(l)
+}
+
+func (s *sclPin) SetFunc(f pin.Func) error {
+	s.fn = f
+	return nil
+}
+
+// sdaPin is held low by a device until SCL has been clocked hold times.
+type sdaPin struct {
+	gpiotest.Pin
+	scl  *sclPin
+	hold int
+	fn   pin.Func
+}
+
+func (s *sdaPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	s.fn = gpio.IN
+	return s.Pin.In(pull, edge)
+}
+
+func (s *sdaPin) Read() gpio.Level {
+	if s.scl.clocks < s.hold {
+		return gpio.Low
+	}
+	return s.Pin.Read()
+}
+
+func (s *sdaPin) SetFunc(f pin.Func) error {
+	s.fn = f
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2cretry
+
+import (
+	"errors"
+	"time"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/pin"
+)
+
+// Recover frees the bus when a device is holding SDA low, for example after
+// it missed clocks in the middle of a byte.
+//
+// SCL and SDA are temporarily used as GPIOs. SDA is released and if it reads
+// low, SCL is clocked up to 9 times until the device releases SDA, then a STOP
+// condition is sent. The pins are then switched back to their I²C function.
+//
+// The real pins behind aliases must implement pin.PinFunc and be set to either
+// their I²C function or a GPIO function, otherwise the pins are not touched
+// and an error is returned, since their function couldn't be restored.
+//
+// It must not be called while a transaction is in progress on the bus.
+//
+// Returns true if SDA was found stuck low and the recovery sequence was sent.
+func Recover(p i2c.Pins) (bool, error) {
+	scl := p.SCL()
+	sda := p.SDA()
+	if scl == nil || sda == nil || scl == gpio.INVALID || sda == gpio.INVALID {
+		return false, errors.New("i2cretry: SCL and SDA pins are unknown")
+	}
+	sclPF, sclFunc, err := i2cFunc(scl)
+	if err != nil {
+		return false, err
+	}
+	sdaPF, sdaFunc, err := i2cFunc(sda)
+	if err != nil {
+		return false, err
+	}
+	stuck, err := unstick(scl, sda)
+	if err2 := restoreFunc(sclPF, sclFunc); err == nil {
+		err = err2
+	}
+	if err2 := restoreFunc(sdaPF, sdaFunc); err == nil {
+		err = err2
+	}
+	return stuck, err
+}
+
+//
+
+// halfPeriod is half a clock cycle at 100kHz.
+const halfPeriod = 5 * time.Microsecond
+
+// unstick does the actual recovery sequence.
+//
+// A line is driven low with Out(Low) and released with In(PullUp) to emulate
+// an open drain output.
+func unstick(scl, sda gpio.PinIO) (bool, error) {
+	if err := sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return false, err
+	}
+	if err := scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return false, err
+	}
+	if sda.Read() == gpio.High {
+		return false, nil
+	}
+	// Page 20, section 3.1.16 Bus clear.
+	for i := 0; i < 9 && sda.Read() == gpio.Low; i++ {
+		if err := scl.Out(gpio.Low); err != nil {
+			return true, err
+		}
+		sleep(halfPeriod)
+		if err := scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
+			return true, err
+		}
+		sleep(halfPeriod)
+	}
+	// STOP condition: SDA goes from low to high while SCL is high.
+	if err := scl.Out(gpio.Low); err != nil {
+		return true, err
+	}
+	sleep(halfPeriod)
+	if err := sda.Out(gpio.Low); err != nil {
+		return true, err
+	}
+	sleep(halfPeriod)
+	if err := scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return true, err
+	}
+	sleep(halfPeriod)
+	if err := sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return true, err
+	}
+	sleep(halfPeriod)
+	if sda.Read() == gpio.Low {
+		return true, errors.New("i2cretry: SDA is still held low")
+	}
+	return true, nil
+}
+
+// i2cFunc returns the real pin of p and the I²C function it is currently set
+// to, or pin.FuncNone if it is already used as a GPIO.
+//
+// It returns an error if the function can't be restored after using the pin
+// as a GPIO.
+func i2cFunc(p gpio.PinIO) (pin.PinFunc, pin.Func, error) {
+	pf, ok := gpio.Real(p).(pin.PinFunc)
+	if !ok {
+		return nil, pin.FuncNone, errors.New("i2cretry: " + p.String() + " doesn't implement pin.PinFunc")
+	}
+	f := pf.Func()
+	switch f {
+	case gpio.IN, gpio.IN_HIGH, gpio.IN_LOW, gpio.OUT, gpio.OUT_OC, gpio.OUT_HIGH, gpio.OUT_LOW, gpio.FLOAT:
+		return pf, pin.FuncNone, nil
+	}
+	if g := f.Generalize(); g != i2c.SCL && g != i2c.SDA {
+		return nil, pin.FuncNone, errors.New("i2cretry: " + p.String() + " is set to " + string(f) + " instead of an I²C function")
+	}
+	return pf, f, nil
+}
+
+// restoreFunc sets p back to the function f returned by i2cFunc().
+func restoreFunc(p pin.PinFunc, f pin.Func) error {
+	if f == pin.FuncNone {
+		return nil
+	}
+	return p.SetFunc(f)
+}
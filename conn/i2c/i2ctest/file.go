@@ -0,0 +1,98 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c"
+)
+
+// Save writes the recorded operations to w in the format described at
+// conntest.FileHeader.
+//
+// Each line is either {"addr":118,"w":"f4","r":"0102"} for a transaction done
+// via Tx() or {"msgs":[{"addr":118,"flags":1,"w":"f4"},...]} for one done via
+// TxMsgs().
+//
+// The file can be loaded back with Playback.Load().
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	h := &conntest.FileHeader{Kind: "i2c"}
+	if r.Bus != nil {
+		h.Bus = r.Bus.String()
+	}
+	return conntest.Encode(w, h, len(r.Ops), func(i int) interface{} {
+		op := &r.Ops[i]
+		if op.Msgs == nil {
+			return &ioLine{Addr: op.Addr, W: op.W, R: op.R}
+		}
+		l := &ioLine{Msgs: make([]msgLine, len(op.Msgs))}
+		for j, m := range op.Msgs {
+			l.Msgs[j] = msgLine{Addr: m.Addr, Flags: m.Flags, W: m.W, R: m.R}
+		}
+		return l
+	})
+}
+
+// SaveFile writes the recorded operations to the file path.
+func (r *Record) SaveFile(path string) error {
+	return conntest.SaveFile(path, r.Save)
+}
+
+// Load reads the operations saved by Record.Save() from r.
+//
+// Ops is replaced and Count is reset.
+func (p *Playback) Load(r io.Reader) error {
+	var ops []IO
+	h, err := conntest.Decode(r, func(d *json.Decoder) error {
+		l := ioLine{}
+		if err := d.Decode(&l); err != nil {
+			return err
+		}
+		op := IO{Addr: l.Addr, W: l.W, R: l.R}
+		if l.Msgs != nil {
+			op = IO{Msgs: make([]i2c.Msg, len(l.Msgs))}
+			for i, m := range l.Msgs {
+				op.Msgs[i] = i2c.Msg{Addr: m.Addr, Flags: m.Flags, W: m.W, R: m.R}
+			}
+		}
+		ops = append(ops, op)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if h.Kind != "i2c" {
+		return fmt.Errorf("i2ctest: expected an i2c recording, got %q", h.Kind)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.Count = 0
+	return nil
+}
+
+//
+
+// ioLine is an IO as saved in a recording file.
+type ioLine struct {
+	Addr uint16       `json:"addr,omitempty"`
+	W    conntest.Hex `json:"w,omitempty"`
+	R    conntest.Hex `json:"r,omitempty"`
+	Msgs []msgLine    `json:"msgs,omitempty"`
+}
+
+// msgLine is an i2c.Msg as saved in a recording file.
+type msgLine struct {
+	Addr  uint16       `json:"addr"`
+	Flags i2c.Flags    `json:"flags,omitempty"`
+	W     conntest.Hex `json:"w,omitempty"`
+	R     conntest.Hex `json:"r,omitempty"`
+}
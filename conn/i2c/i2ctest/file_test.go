@@ -0,0 +1,72 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestRecord_Save_Load(t *testing.T) {
+	r := Record{}
+	if err := r.Tx(0x76, []byte{0xf4, 1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 0x23, Flags: i2c.Ten, W: []byte{2}}, {Addr: 0x23, Flags: i2c.Ten | i2c.NoStart}}); err != nil {
+		t.Fatal(err)
+	}
+	r.Ops[0].R = []byte{3, 4}
+	r.Ops[1].Msgs[1].R = []byte{5}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("%q", lines)
+	}
+	if !strings.HasPrefix(lines[0], `{"version":1,"kind":"i2c","time":"`) {
+		t.Fatal(lines[0])
+	}
+	if lines[1] != `{"addr":118,"w":"f401","r":"0304"}` {
+		t.Fatal(lines[1])
+	}
+	if lines[2] != `{"msgs":[{"addr":35,"flags":1,"w":"02"},{"addr":35,"flags":3,"r":"05"}]}` {
+		t.Fatal(lines[2])
+	}
+
+	p := Playback{Count: 1}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatalf("%#v != %#v", p.Ops, r.Ops)
+	}
+	if p.Count != 0 {
+		t.Fatal(p.Count)
+	}
+	d := i2c.Dev{Bus: &p, Addr: 0x76}
+	b := make([]byte, 2)
+	if err := d.Tx([]byte{0xf4, 1}, b); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte{3, 4}) {
+		t.Fatal(b)
+	}
+}
+
+func TestPlayback_Load_err(t *testing.T) {
+	p := Playback{}
+	if err := p.Load(strings.NewReader(`{"version":1,"kind":"spi"}`)); err == nil {
+		t.Fatal("expected kind error")
+	}
+	if err := p.Load(strings.NewReader(`{"version":1,"kind":"i2c"}` + "\n" + `{"addr":"a"}`)); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
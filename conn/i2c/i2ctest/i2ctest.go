@@ -20,6 +20,9 @@ type IO struct {
 	Addr uint16
 	W    []byte
 	R    []byte
+	// Msgs is set instead of Addr, W and R when the transaction was done via
+	// i2c.MsgBus.TxMsgs().
+	Msgs []i2c.Msg
 }
 
 // Record implements i2c.Bus that records everything written to it.
@@ -63,6 +66,40 @@ func (r *Record) Tx(addr uint16, w, read []byte) error {
 	return nil
 }
 
+// TxMsgs implements i2c.MsgBus.
+//
+// Bus must implement i2c.MsgBus, or be nil if only writes are being recorded.
+// Otherwise i2c.ErrMsgUnsupported is returned so the caller can fall back to
+// Tx(), and nothing is recorded.
+func (r *Record) TxMsgs(m []i2c.Msg) error {
+	io := IO{Msgs: make([]i2c.Msg, len(m))}
+	for i := range m {
+		io.Msgs[i] = i2c.Msg{Addr: m[i].Addr, Flags: m[i].Flags, W: copyBytes(m[i].W)}
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.Bus == nil {
+		for i := range m {
+			if len(m[i].R) != 0 {
+				return conntest.Errorf("i2ctest: read unsupported when no bus is connected")
+			}
+		}
+	} else {
+		b, ok := r.Bus.(i2c.MsgBus)
+		if !ok {
+			return i2c.ErrMsgUnsupported
+		}
+		if err := b.TxMsgs(m); err != nil {
+			return err
+		}
+	}
+	for i := range m {
+		io.Msgs[i].R = copyBytes(m[i].R)
+	}
+	r.Ops = append(r.Ops, io)
+	return nil
+}
+
 // SetSpeed implements i2c.Bus.
 func (r *Record) SetSpeed(f physic.Frequency) error {
 	if r.Bus != nil {
@@ -140,6 +177,38 @@ func (p *Playback) Tx(addr uint16, w, r []byte) error {
 	return nil
 }
 
+// TxMsgs implements i2c.MsgBus.
+func (p *Playback) TxMsgs(m []i2c.Msg) error {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.Ops) <= p.Count {
+		return errorf(p.DontPanic, "i2ctest: unexpected TxMsgs() (count #%d) expecting i2ctest.IO{Msgs:%#v}", p.Count, m)
+	}
+	ops := p.Ops[p.Count].Msgs
+	if len(ops) != len(m) {
+		return errorf(p.DontPanic, "i2ctest: unexpected number of messages (count #%d) %d != %d", p.Count, len(m), len(ops))
+	}
+	for i := range m {
+		if m[i].Addr != ops[i].Addr {
+			return errorf(p.DontPanic, "i2ctest: unexpected addr (count #%d, msg #%d) %d != %d", p.Count, i, m[i].Addr, ops[i].Addr)
+		}
+		if m[i].Flags != ops[i].Flags {
+			return errorf(p.DontPanic, "i2ctest: unexpected flags (count #%d, msg #%d) %s != %s", p.Count, i, m[i].Flags, ops[i].Flags)
+		}
+		if !bytes.Equal(ops[i].W, m[i].W) {
+			return errorf(p.DontPanic, "i2ctest: unexpected write (count #%d, msg #%d) %#v != %#v", p.Count, i, m[i].W, ops[i].W)
+		}
+		if len(ops[i].R) != len(m[i].R) {
+			return errorf(p.DontPanic, "i2ctest: unexpected read buffer length (count #%d, msg #%d) %d != %d", p.Count, i, len(m[i].R), len(ops[i].R))
+		}
+	}
+	for i := range m {
+		copy(m[i].R, ops[i].R)
+	}
+	p.Count++
+	return nil
+}
+
 // SetSpeed implements i2c.Bus.
 func (p *Playback) SetSpeed(f physic.Frequency) error {
 	return nil
@@ -157,6 +226,16 @@ func (p *Playback) SDA() gpio.PinIO {
 
 //
 
+// copyBytes returns a copy of b, or nil if b is empty.
+func copyBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	out := make([]byte, len(b))
+	copy(out, b)
+	return out
+}
+
 // errorf is the internal implementation that optionally panic.
 //
 // If dontPanic is false, it panics instead.
@@ -169,6 +248,8 @@ func errorf(dontPanic bool, format string, a ...interface{}) error {
 }
 
 var _ i2c.Bus = &Record{}
+var _ i2c.MsgBus = &Record{}
 var _ i2c.Pins = &Record{}
 var _ i2c.Bus = &Playback{}
+var _ i2c.MsgBus = &Playback{}
 var _ i2c.Pins = &Playback{}
@@ -10,6 +10,8 @@ import (
 	"periph.io/x/periph/conn/conntest"
 	"periph.io/x/periph/conn/gpio"
 	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/physic"
 )
 
 func TestRecord_empty(t *testing.T) {
@@ -165,3 +167,84 @@ func TestRecord_Playback(t *testing.T) {
 		t.Fatal("Playback.Ops is empty")
 	}
 }
+
+func TestRecord_TxMsgs_empty(t *testing.T) {
+	r := Record{}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 1, W: []byte{'a'}}, {Addr: 1, Flags: i2c.NoStart, W: []byte{'b'}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 1 || len(r.Ops[0].Msgs) != 2 || r.Ops[0].Msgs[1].W[0] != 'b' {
+		t.Fatal(r.Ops)
+	}
+	if r.TxMsgs([]i2c.Msg{{Addr: 1, R: []byte{'a'}}}) == nil {
+		t.Fatal("Bus is nil")
+	}
+	r.Bus = &nonMsgBus{}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 1}}); err != i2c.ErrMsgUnsupported {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 1 {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestPlayback_TxMsgs(t *testing.T) {
+	p := Playback{
+		Ops: []IO{
+			{
+				Msgs: []i2c.Msg{
+					{Addr: 0x50, W: []byte{0x01, 0x02}},
+					{Addr: 0x50, R: []byte{12, 13}},
+				},
+			},
+		},
+		DontPanic: true,
+	}
+	v := [2]byte{}
+	data := [][]i2c.Msg{
+		{{Addr: 0x50, W: []byte{0x01, 0x02}}},
+		{{Addr: 0x51, W: []byte{0x01, 0x02}}, {Addr: 0x50, R: v[:]}},
+		{{Addr: 0x50, W: []byte{0x01, 0x02}, Flags: i2c.IgnoreNAK}, {Addr: 0x50, R: v[:]}},
+		{{Addr: 0x50, W: []byte{0x01}}, {Addr: 0x50, R: v[:]}},
+		{{Addr: 0x50, W: []byte{0x01, 0x02}}, {Addr: 0x50, R: v[:1]}},
+	}
+	for i, m := range data {
+		if p.TxMsgs(m) == nil {
+			t.Fatalf("#%d: expected failure", i)
+		}
+	}
+	r := Record{Bus: &p}
+	if err := r.TxMsgs([]i2c.Msg{{Addr: 0x50, W: []byte{0x01, 0x02}}, {Addr: 0x50, R: v[:]}}); err != nil {
+		t.Fatal(err)
+	}
+	if v[0] != 12 || v[1] != 13 {
+		t.Fatal(v)
+	}
+	if len(r.Ops) != 1 || r.Ops[0].Msgs[1].R[1] != 13 {
+		t.Fatal(r.Ops)
+	}
+	if p.TxMsgs(nil) == nil {
+		t.Fatal("Playback.Ops is empty")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// nonMsgBus implements i2c.Bus but not i2c.MsgBus.
+type nonMsgBus struct {
+}
+
+func (n *nonMsgBus) String() string {
+	return "nonMsgBus"
+}
+
+func (n *nonMsgBus) Tx(addr uint16, w, r []byte) error {
+	return nil
+}
+
+func (n *nonMsgBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Loopback implements i2c.Bus and i2c.TargetBus by connecting the controller
+// side of the bus directly to targets emulated in memory.
+//
+// This permits testing a device driver against a simulated device, or the
+// code of a target against a simulated controller.
+//
+// A Tx() to an address without target fails, like a NACK would.
+type Loopback struct {
+	mu      sync.Mutex
+	targets map[uint16]*loopbackTarget
+}
+
+func (l *Loopback) String() string {
+	return "loopback"
+}
+
+// Tx implements i2c.Bus.
+func (l *Loopback) Tx(addr uint16, w, r []byte) error {
+	l.mu.Lock()
+	t := l.targets[addr]
+	l.mu.Unlock()
+	if t == nil {
+		return conntest.Errorf("i2ctest: no target at address %d", addr)
+	}
+	t.write(w)
+	t.read(r)
+	return nil
+}
+
+// SetSpeed implements i2c.Bus.
+func (l *Loopback) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+// NewTarget implements i2c.TargetBus.
+func (l *Loopback) NewTarget(addr uint16, size int, h i2c.TargetHandler) (i2c.Target, error) {
+	if addr >= 0x80 {
+		return nil, errors.New("i2ctest: invalid address")
+	}
+	if size < 1 || size > 256 {
+		return nil, errors.New("i2ctest: invalid size")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.targets == nil {
+		l.targets = map[uint16]*loopbackTarget{}
+	}
+	if l.targets[addr] != nil {
+		return nil, errors.New("i2ctest: a target already exists at address " + strconv.Itoa(int(addr)))
+	}
+	t := &loopbackTarget{l: l, addr: addr, h: h, mem: make([]byte, size)}
+	l.targets[addr] = t
+	return t, nil
+}
+
+//
+
+// loopbackTarget is a target emulated by Loopback.
+type loopbackTarget struct {
+	l    *Loopback
+	addr uint16
+	h    i2c.TargetHandler
+
+	mu  sync.Mutex
+	mem []byte
+	reg int
+}
+
+func (t *loopbackTarget) String() string {
+	return "loopback(" + strconv.Itoa(int(t.addr)) + ")"
+}
+
+// Halt implements conn.Resource.
+//
+// It removes the target from the bus.
+func (t *loopbackTarget) Halt() error {
+	t.l.mu.Lock()
+	defer t.l.mu.Unlock()
+	if t.l.targets[t.addr] == t {
+		delete(t.l.targets, t.addr)
+	}
+	return nil
+}
+
+// Addr implements i2c.Target.
+func (t *loopbackTarget) Addr() uint16 {
+	return t.addr
+}
+
+// ReadAt implements io.ReaderAt.
+func (t *loopbackTarget) ReadAt(p []byte, off int64) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if off < 0 {
+		return 0, errors.New("i2ctest: invalid offset")
+	}
+	if off >= int64(len(t.mem)) {
+		return 0, io.EOF
+	}
+	n := copy(p, t.mem[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt.
+func (t *loopbackTarget) WriteAt(p []byte, off int64) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if off < 0 || off+int64(len(p)) > int64(len(t.mem)) {
+		return 0, errors.New("i2ctest: write out of range")
+	}
+	return copy(t.mem[off:], p), nil
+}
+
+// write handles a write message from the controller.
+func (t *loopbackTarget) write(w []byte) {
+	if len(w) == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.reg = int(w[0]) % len(t.mem)
+	start := t.reg
+	for _, b := range w[1:] {
+		t.mem[t.reg] = b
+		t.reg = (t.reg + 1) % len(t.mem)
+	}
+	t.mu.Unlock()
+	if t.h != nil && len(w) > 1 {
+		t.h.OnWrite(start, w[1:])
+	}
+}
+
+// read handles a read message from the controller.
+func (t *loopbackTarget) read(r []byte) {
+	if len(r) == 0 {
+		return
+	}
+	if t.h != nil {
+		t.mu.Lock()
+		reg := t.reg
+		t.mu.Unlock()
+		t.h.OnRead(reg, len(r))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range r {
+		r[i] = t.mem[t.reg]
+		t.reg = (t.reg + 1) % len(t.mem)
+	}
+}
+
+var _ i2c.Bus = &Loopback{}
+var _ i2c.TargetBus = &Loopback{}
+var _ i2c.Target = &loopbackTarget{}
@@ -0,0 +1,171 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestLoopback(t *testing.T) {
+	l := Loopback{}
+	if s := l.String(); s != "loopback" {
+		t.Fatal(s)
+	}
+	if err := l.SetSpeed(0); err != nil {
+		t.Fatal(err)
+	}
+	h := handler{}
+	tgt, err := l.NewTarget(0x42, 16, &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tgt.String(); s != "loopback(66)" {
+		t.Fatal(s)
+	}
+	if a := tgt.Addr(); a != 0x42 {
+		t.Fatal(a)
+	}
+	if _, err := tgt.WriteAt([]byte{1, 2, 3}, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// Controller side.
+	d := i2c.Dev{Bus: &l, Addr: 0x42}
+	r := make([]byte, 3)
+	if err := d.Tx([]byte{4}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{1, 2, 3}) {
+		t.Fatal(r)
+	}
+	// The register address auto-increments and wraps around.
+	if err := d.Tx([]byte{15, 10, 11}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Tx(nil, r[:1]); err != nil || r[0] != 0 {
+		t.Fatal(r, err)
+	}
+	b := make([]byte, 16)
+	if n, err := tgt.ReadAt(b, 0); err != nil || n != 16 || b[0] != 11 || b[15] != 10 {
+		t.Fatal(b, n, err)
+	}
+	if len(h.writes) != 1 || h.writes[0].reg != 15 || !bytes.Equal(h.writes[0].data, []byte{10, 11}) {
+		t.Fatal(h.writes)
+	}
+	if len(h.reads) != 2 || h.reads[0] != [2]int{4, 3} || h.reads[1] != [2]int{1, 1} {
+		t.Fatal(h.reads)
+	}
+
+	// A write of only the register address doesn't call OnWrite.
+	if err := d.Tx([]byte{0}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.writes) != 1 {
+		t.Fatal(h.writes)
+	}
+	if err := tgt.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Tx([]byte{0}, nil); err == nil {
+		t.Fatal("target was removed")
+	}
+}
+
+func TestLoopback_OnRead(t *testing.T) {
+	l := Loopback{}
+	h := counter{}
+	tgt, err := l.NewTarget(0x10, 1, &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.t = tgt
+	var r [1]byte
+	for i := 1; i < 4; i++ {
+		if err := l.Tx(0x10, nil, r[:]); err != nil || r[0] != byte(i) {
+			t.Fatal(r, err)
+		}
+	}
+}
+
+func TestLoopback_err(t *testing.T) {
+	l := Loopback{}
+	if _, err := l.NewTarget(0x80, 16, nil); err == nil {
+		t.Fatal("invalid address")
+	}
+	if _, err := l.NewTarget(0x10, 0, nil); err == nil {
+		t.Fatal("invalid size")
+	}
+	if _, err := l.NewTarget(0x10, 257, nil); err == nil {
+		t.Fatal("invalid size")
+	}
+	tgt, err := l.NewTarget(0x10, 4, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.NewTarget(0x10, 4, nil); err == nil {
+		t.Fatal("duplicate address")
+	}
+	if _, err := tgt.WriteAt([]byte{1, 2}, 3); err == nil {
+		t.Fatal("out of range")
+	}
+	if _, err := tgt.WriteAt([]byte{1}, -1); err == nil {
+		t.Fatal("out of range")
+	}
+	b := make([]byte, 2)
+	if _, err := tgt.ReadAt(b, -1); err == nil {
+		t.Fatal("invalid offset")
+	}
+	if n, err := tgt.ReadAt(b, 3); err != io.EOF || n != 1 {
+		t.Fatal(n, err)
+	}
+	if n, err := tgt.ReadAt(b, 4); err != io.EOF || n != 0 {
+		t.Fatal(n, err)
+	}
+	// Without handler.
+	if err := l.Tx(0x10, []byte{1, 2}, b); err != nil {
+		t.Fatal(err)
+	}
+	if b[0] != 0 || b[1] != 0 {
+		t.Fatal(b)
+	}
+}
+
+//
+
+type write struct {
+	reg  int
+	data []byte
+}
+
+type handler struct {
+	writes []write
+	reads  [][2]int
+}
+
+func (h *handler) OnWrite(reg int, data []byte) {
+	h.writes = append(h.writes, write{reg, append([]byte{}, data...)})
+}
+
+func (h *handler) OnRead(reg, n int) {
+	h.reads = append(h.reads, [2]int{reg, n})
+}
+
+// counter increments the register each time it is read.
+type counter struct {
+	t i2c.Target
+	n byte
+}
+
+func (c *counter) OnWrite(reg int, data []byte) {
+}
+
+func (c *counter) OnRead(reg, n int) {
+	c.n++
+	c.t.WriteAt([]byte{c.n}, 0)
+}
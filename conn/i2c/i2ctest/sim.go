@@ -0,0 +1,186 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Device is a simulated I²C device exposing a register file, to be attached
+// to a SimBus.
+//
+// A write message starts with the register address, followed by data
+// written to consecutive registers. A read message returns the content of
+// consecutive registers starting at the current register address. This is
+// the protocol used by most sensors and by conn/mmr.
+//
+// Unlike Playback, the test doesn't depend on the exact sequence of
+// transactions done by the driver, only on the resulting register content.
+type Device struct {
+	// Regs is the register file.
+	Regs []byte
+	// AddrSize is the number of bytes of the register address. The default
+	// value 0 means 1.
+	AddrSize int
+	// AddrOrder is the byte order of the register address when AddrSize is
+	// more than 1. The default value nil means binary.BigEndian.
+	AddrOrder binary.ByteOrder
+	// NoAutoIncrement keeps the register address constant during a message,
+	// e.g. for FIFO registers.
+	NoAutoIncrement bool
+	// NACK makes the device not acknowledge its address.
+	NACK bool
+	// OnRead, if set, is called for each register read by the controller and
+	// returns the value to send instead of Regs[reg]. It can modify Regs, e.g.
+	// to clear a status flag on read. Returning an error simulates a NACK.
+	OnRead func(d *Device, reg int) (byte, error)
+	// OnWrite, if set, is called for each register written by the controller,
+	// after the value is stored in Regs. Returning an error simulates a NACK.
+	OnWrite func(d *Device, reg int, v byte) error
+
+	reg int // Current register address.
+}
+
+// SimBus implements i2c.Bus and i2c.MsgBus with simulated devices.
+//
+// A transaction to an address without device or to a device with NACK set
+// fails.
+type SimBus struct {
+	sync.Mutex
+	Devices map[uint16]*Device
+}
+
+func (s *SimBus) String() string {
+	return "simbus"
+}
+
+// Tx implements i2c.Bus.
+func (s *SimBus) Tx(addr uint16, w, r []byte) error {
+	var m [2]i2c.Msg
+	msgs := m[:0]
+	if len(w) != 0 || len(r) == 0 {
+		msgs = append(msgs, i2c.Msg{Addr: addr, W: w})
+	}
+	if len(r) != 0 {
+		msgs = append(msgs, i2c.Msg{Addr: addr, R: r})
+	}
+	return s.TxMsgs(msgs)
+}
+
+// TxMsgs implements i2c.MsgBus.
+func (s *SimBus) TxMsgs(m []i2c.Msg) error {
+	s.Lock()
+	defer s.Unlock()
+	var d *Device
+	for i := range m {
+		if len(m[i].W) != 0 && len(m[i].R) != 0 {
+			return conntest.Errorf("i2ctest: a message can't both read and write")
+		}
+		if i == 0 || m[i].Flags&i2c.NoStart == 0 {
+			if d = s.Devices[m[i].Addr]; d == nil || d.NACK {
+				return conntest.Errorf("i2ctest: no device acknowledged address %d", m[i].Addr)
+			}
+			if len(m[i].W) != 0 {
+				if err := d.setReg(m[i].W); err != nil {
+					return err
+				}
+				if err := d.write(m[i].W[d.addrSize():]); err != nil {
+					return err
+				}
+				continue
+			}
+		} else if err := d.write(m[i].W); err != nil {
+			return err
+		}
+		if err := d.read(m[i].R); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSpeed implements i2c.Bus.
+func (s *SimBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+//
+
+func (d *Device) addrSize() int {
+	if d.AddrSize == 0 {
+		return 1
+	}
+	return d.AddrSize
+}
+
+// setReg sets the register address from the beginning of a write message.
+func (d *Device) setReg(w []byte) error {
+	l := d.addrSize()
+	if len(w) < l {
+		return conntest.Errorf("i2ctest: expected %d bytes of register address, got %d", l, len(w))
+	}
+	switch l {
+	case 1:
+		d.reg = int(w[0])
+	case 2:
+		o := d.AddrOrder
+		if o == nil {
+			o = binary.BigEndian
+		}
+		d.reg = int(o.Uint16(w))
+	default:
+		return conntest.Errorf("i2ctest: unsupported register address size %d", l)
+	}
+	return nil
+}
+
+// write writes data at the current register address.
+func (d *Device) write(w []byte) error {
+	for _, b := range w {
+		if d.reg >= len(d.Regs) {
+			return conntest.Errorf("i2ctest: register 0x%x is out of range", d.reg)
+		}
+		d.Regs[d.reg] = b
+		if d.OnWrite != nil {
+			if err := d.OnWrite(d, d.reg, b); err != nil {
+				return err
+			}
+		}
+		if !d.NoAutoIncrement {
+			d.reg++
+		}
+	}
+	return nil
+}
+
+// read reads data from the current register address.
+func (d *Device) read(r []byte) error {
+	for i := range r {
+		if d.reg >= len(d.Regs) {
+			return conntest.Errorf("i2ctest: register 0x%x is out of range", d.reg)
+		}
+		if d.OnRead != nil {
+			v, err := d.OnRead(d, d.reg)
+			if err != nil {
+				return err
+			}
+			r[i] = v
+		} else {
+			r[i] = d.Regs[d.reg]
+		}
+		if !d.NoAutoIncrement {
+			d.reg++
+		}
+	}
+	return nil
+}
+
+var _ i2c.Bus = &SimBus{}
+var _ i2c.MsgBus = &SimBus{}
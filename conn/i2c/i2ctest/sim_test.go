@@ -0,0 +1,166 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2ctest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/mmr"
+)
+
+func TestSimBus(t *testing.T) {
+	dev := &Device{Regs: make([]byte, 8)}
+	s := SimBus{Devices: map[uint16]*Device{0x40: dev}}
+	if n := s.String(); n != "simbus" {
+		t.Fatal(n)
+	}
+	if err := s.SetSpeed(0); err != nil {
+		t.Fatal(err)
+	}
+	// Probe.
+	if err := s.Tx(0x40, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tx(0x41, nil, nil); err == nil {
+		t.Fatal("no device")
+	}
+	if err := s.Tx(0x40, []byte{2, 1, 2, 3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dev.Regs, []byte{0, 0, 1, 2, 3, 0, 0, 0}) {
+		t.Fatal(dev.Regs)
+	}
+	r := make([]byte, 2)
+	if err := s.Tx(0x40, []byte{3}, r); err != nil || !bytes.Equal(r, []byte{2, 3}) {
+		t.Fatal(r, err)
+	}
+	// The register address auto-incremented.
+	if err := s.Tx(0x40, nil, r); err != nil || !bytes.Equal(r, []byte{0, 0}) {
+		t.Fatal(r, err)
+	}
+	if err := s.Tx(0x40, nil, r); err == nil {
+		t.Fatal("out of range")
+	}
+	if err := s.Tx(0x40, []byte{7, 1, 2}, nil); err == nil {
+		t.Fatal("out of range")
+	}
+	dev.NACK = true
+	if err := s.Tx(0x40, nil, nil); err == nil {
+		t.Fatal("NACK")
+	}
+}
+
+func TestSimBus_hooks(t *testing.T) {
+	var writes []int
+	dev := &Device{
+		Regs:            []byte{0x80, 0},
+		NoAutoIncrement: true,
+		OnRead: func(d *Device, reg int) (byte, error) {
+			if reg == 1 {
+				return 0, errors.New("NACK")
+			}
+			// Clear on read.
+			v := d.Regs[reg]
+			d.Regs[reg] = 0
+			return v, nil
+		},
+		OnWrite: func(d *Device, reg int, v byte) error {
+			writes = append(writes, reg)
+			if v == 0xFF {
+				return errors.New("NACK")
+			}
+			return nil
+		},
+	}
+	s := SimBus{Devices: map[uint16]*Device{0x40: dev}}
+	r := make([]byte, 2)
+	if err := s.Tx(0x40, []byte{0}, r); err != nil || !bytes.Equal(r, []byte{0x80, 0}) {
+		t.Fatal(r, err)
+	}
+	if dev.Regs[0] != 0 {
+		t.Fatal(dev.Regs)
+	}
+	if err := s.Tx(0x40, []byte{1}, r); err == nil {
+		t.Fatal("read hook failure")
+	}
+	// No auto-increment: all the bytes go to register 1.
+	if err := s.Tx(0x40, []byte{1, 5, 6}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if dev.Regs[1] != 6 || len(writes) != 2 || writes[0] != 1 || writes[1] != 1 {
+		t.Fatal(dev.Regs, writes)
+	}
+	if err := s.Tx(0x40, []byte{1, 0xFF}, nil); err == nil {
+		t.Fatal("write hook failure")
+	}
+}
+
+func TestSimBus_TxMsgs(t *testing.T) {
+	dev := &Device{Regs: make([]byte, 0x200), AddrSize: 2}
+	dev.Regs[0x100] = 42
+	s := SimBus{Devices: map[uint16]*Device{0x50: dev}}
+	r := make([]byte, 1)
+	m := []i2c.Msg{
+		{Addr: 0x50, W: []byte{0x01}},
+		{Addr: 0x50, W: []byte{0x00}, Flags: i2c.NoStart},
+		{Addr: 0x50, R: r},
+	}
+	// The register address is split across two messages, which isn't
+	// supported.
+	if err := s.TxMsgs(m); err == nil {
+		t.Fatal("short register address")
+	}
+	m = []i2c.Msg{
+		{Addr: 0x50, W: []byte{0x01, 0x00}},
+		{Addr: 0x50, W: []byte{7}, Flags: i2c.NoStart},
+		{Addr: 0x50, R: r},
+	}
+	if err := s.TxMsgs(m); err != nil || r[0] != 0 {
+		t.Fatal(r, err)
+	}
+	if dev.Regs[0x100] != 7 {
+		t.Fatal(dev.Regs[0x100])
+	}
+	if err := s.TxMsgs([]i2c.Msg{{Addr: 0x50, W: []byte{1}, R: r}}); err == nil {
+		t.Fatal("read and write")
+	}
+	dev.AddrSize = 3
+	if err := s.TxMsgs([]i2c.Msg{{Addr: 0x50, W: []byte{1, 2, 3}}}); err == nil {
+		t.Fatal("unsupported address size")
+	}
+}
+
+func TestSimBus_mmr(t *testing.T) {
+	s := SimBus{
+		Devices: map[uint16]*Device{
+			0x10: {Regs: make([]byte, 16)},
+			0x20: {Regs: make([]byte, 0x1010), AddrSize: 2, AddrOrder: binary.LittleEndian},
+		},
+	}
+	d8 := mmr.Dev8{Conn: &i2c.Dev{Bus: &s, Addr: 0x10}, Order: binary.BigEndian}
+	if err := d8.WriteUint16(4, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d8.ReadUint8(5); err != nil || v != 0x34 {
+		t.Fatal(v, err)
+	}
+	if v, err := d8.ReadUint16(4); err != nil || v != 0x1234 {
+		t.Fatal(v, err)
+	}
+	d16 := mmr.Dev16{Conn: &i2c.Dev{Bus: &s, Addr: 0x20}, Order: binary.LittleEndian}
+	if err := d16.WriteUint32(0x1000, 0x12345678); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := d16.ReadUint32(0x1000); err != nil || v != 0x12345678 {
+		t.Fatal(v, err)
+	}
+	if r := s.Devices[0x20].Regs[0x1000]; r != 0x78 {
+		t.Fatal(r)
+	}
+}
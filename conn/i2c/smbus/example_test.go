@@ -0,0 +1,46 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus_test
+
+import (
+	"fmt"
+	"log"
+
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/host"
+)
+
+func Example() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use i2creg I²C bus registry to find the first available I²C bus.
+	b, err := i2creg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer b.Close()
+
+	// Talk to a smart battery with Packet Error Checking enabled.
+	d := &smbus.Dev{Bus: b, Addr: 0x0B, PEC: true}
+
+	// Read the voltage in mV at command code 0x09.
+	v, err := d.ReadWord(0x09)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%dmV\n", v)
+
+	// Read the manufacturer name at command code 0x20.
+	var name [smbus.BlockMax]byte
+	n, err := d.ReadBlock(0x20, name[:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s\n", name[:n])
+}
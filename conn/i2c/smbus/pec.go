@@ -0,0 +1,26 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+// CalcPEC calculates the SMBus Packet Error Code across the buffer of bytes
+// and returns it.
+//
+// The PEC is a CRC-8 with the polynomial x⁸+x²+x+1 (0x07). buf must contain
+// all the bytes as sent on the wire, including the address bytes with their
+// R/W bit.
+func CalcPEC(buf []byte) byte {
+	var crc byte
+	for _, b := range buf {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+import (
+	"errors"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// Probe is the strategy used by Scan to detect a device.
+type Probe int
+
+const (
+	// ProbeAuto uses ProbeRead in the address ranges 0x30-0x37 and 0x50-0x5F
+	// and ProbeQuick elsewhere, like i2cdetect does. This is the safest default
+	// since a quick write can corrupt some EEPROMs, and a read can lock up
+	// some write-only chips.
+	ProbeAuto Probe = 0
+	// ProbeQuick sends a quick write command.
+	ProbeQuick Probe = 1
+	// ProbeRead receives a single byte.
+	ProbeRead Probe = 2
+)
+
+// Scan probes the addresses from first to last inclusively on the bus and
+// returns the addresses that acknowledged.
+//
+// Use first = 0x03 and last = 0x77 to scan all the addresses that are not
+// reserved by the specification.
+//
+// Scanning is not without risk: probing may change the state of some devices.
+// An address is reported as not responding on any error returned by the bus.
+// For example host/sysfs refuses native SMBus transactions to an address bound
+// to a kernel driver unless sysfs.I2C.SetForce(true) was called, so such an
+// address is usually missing from the result, where i2cdetect shows "UU".
+func Scan(b i2c.Bus, first, last uint16, p Probe) ([]uint16, error) {
+	if first > last || last > 0x7F {
+		return nil, errors.New("smbus: invalid scan range")
+	}
+	if p < ProbeAuto || p > ProbeRead {
+		return nil, errors.New("smbus: invalid probe")
+	}
+	var out []uint16
+	d := Dev{Bus: b}
+	for addr := first; addr <= last; addr++ {
+		d.Addr = addr
+		if d.probe(p) == nil {
+			out = append(out, addr)
+		}
+	}
+	return out, nil
+}
+
+//
+
+// probe sends a probe to the device.
+func (d *Dev) probe(p Probe) error {
+	if p == ProbeAuto {
+		p = ProbeQuick
+		if (d.Addr >= 0x30 && d.Addr <= 0x37) || (d.Addr >= 0x50 && d.Addr <= 0x5F) {
+			p = ProbeRead
+		}
+	}
+	if p == ProbeQuick {
+		// Fall back to a read on a bus without quick support, as i2cdetect
+		// does.
+		if n, ok := d.Bus.(Bus); ok && n.SMBusSupports(Quick, false, false) {
+			return d.Quick(false)
+		}
+		if _, ok := d.Bus.(i2c.MsgBus); ok {
+			if err := d.Quick(false); err != i2c.ErrMsgUnsupported {
+				return err
+			}
+		}
+	}
+	_, err := d.ReadByte()
+	return err
+}
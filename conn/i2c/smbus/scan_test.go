@@ -0,0 +1,136 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+import (
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestScan(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Msgs: []i2c.Msg{{Addr: 0x2E}}},
+			{Msgs: []i2c.Msg{{Addr: 0x2F}}},
+			{Addr: 0x30, R: []byte{0}},
+		},
+	}
+	// Errors from Playback are reported as non-responding addresses.
+	bus.DontPanic = true
+	addrs, err := Scan(&bus, 0x2E, 0x31, ProbeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 3 || addrs[0] != 0x2E || addrs[1] != 0x2F || addrs[2] != 0x30 {
+		t.Fatal(addrs)
+	}
+	if bus.Count != 3 {
+		t.Fatal(bus.Count)
+	}
+}
+
+func TestScan_probe(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Msgs: []i2c.Msg{{Addr: 0x50}}},
+			{Addr: 0x10, R: []byte{0}},
+		},
+	}
+	if addrs, err := Scan(&bus, 0x50, 0x50, ProbeQuick); err != nil || len(addrs) != 1 {
+		t.Fatal(addrs, err)
+	}
+	if addrs, err := Scan(&bus, 0x10, 0x10, ProbeRead); err != nil || len(addrs) != 1 {
+		t.Fatal(addrs, err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScan_wrapper(t *testing.T) {
+	// The quick write must reach the bus through a wrapper that doesn't
+	// implement smbus.Bus.
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Msgs: []i2c.Msg{{Addr: 0x10}}},
+		},
+		DontPanic: true,
+	}
+	r := i2ctest.Record{Bus: &bus}
+	if addrs, err := Scan(&r, 0x10, 0x11, ProbeQuick); err != nil || len(addrs) != 1 || addrs[0] != 0x10 {
+		t.Fatal(addrs, err)
+	}
+	if len(r.Ops) != 1 || len(r.Ops[0].Msgs) != 1 || r.Ops[0].Msgs[0].Addr != 0x10 {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestScan_wrapper_noMsgBus(t *testing.T) {
+	// The wrapper implements i2c.MsgBus but the bus it wraps doesn't, so it
+	// must fall back to a read.
+	r := i2ctest.Record{Bus: &txBus{ack: 0x40}}
+	if addrs, err := Scan(&r, 0x03, 0x77, ProbeAuto); err != nil || len(addrs) != 1 || addrs[0] != 0x40 {
+		t.Fatal(addrs, err)
+	}
+	if len(r.Ops) != 1 || r.Ops[0].Addr != 0x40 || len(r.Ops[0].R) != 1 {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestScan_native(t *testing.T) {
+	bus := nativeBus{}
+	if addrs, err := Scan(&bus, 0x03, 0x77, ProbeAuto); err != nil || len(addrs) != 0x75 {
+		t.Fatal(addrs, err)
+	}
+	if len(bus.ops) != 0x75 || bus.ops[0] != Quick || bus.ops[0x50-3] != Byte {
+		t.Fatal(bus.ops)
+	}
+	// Without quick support, it falls back to read byte.
+	bus = nativeBus{noQuick: true}
+	if addrs, err := Scan(&bus, 0x03, 0x03, ProbeQuick); err != nil || len(addrs) != 1 {
+		t.Fatal(addrs, err)
+	}
+	if len(bus.ops) != 1 || bus.ops[0] != Byte {
+		t.Fatal(bus.ops)
+	}
+}
+
+func TestScan_err(t *testing.T) {
+	if _, err := Scan(&nativeBus{}, 0x10, 0x08, ProbeAuto); err == nil {
+		t.Fatal("invalid range")
+	}
+	if _, err := Scan(&nativeBus{}, 0x10, 0x80, ProbeAuto); err == nil {
+		t.Fatal("invalid range")
+	}
+	if _, err := Scan(&nativeBus{}, 0x10, 0x20, Probe(3)); err == nil {
+		t.Fatal("invalid probe")
+	}
+}
+
+//
+
+// txBus only implements i2c.Bus and only acknowledges ack.
+type txBus struct {
+	ack uint16
+}
+
+func (t *txBus) String() string {
+	return "tx"
+}
+
+func (t *txBus) Tx(addr uint16, w, r []byte) error {
+	if addr != t.ack {
+		return errors.New("tx: NACK")
+	}
+	return nil
+}
+
+func (t *txBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package smbus implements the System Management Bus protocol on top of an
+// I²C bus.
+//
+// SMBus is a subset of I²C with well defined transactions, e.g. "read a 16
+// bits word at a command code", and optional Packet Error Checking (PEC).
+//
+// When the I²C bus natively supports SMBus transactions, for example an
+// SMBus-only adapter on linux, Dev uses them. Otherwise the transactions are
+// emulated over i2c.Bus.Tx().
+//
+// The specification can be found at http://smbus.org/specs/.
+package smbus
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// BlockMax is the maximum number of bytes in a block transfer.
+const BlockMax = 32
+
+// Protocol is a type of SMBus transaction.
+type Protocol int
+
+const (
+	// Quick sends only the R/W bit, without data.
+	Quick Protocol = 0
+	// Byte sends or receives a single byte without command code.
+	Byte Protocol = 1
+	// ByteData writes or reads one byte at a command code.
+	ByteData Protocol = 2
+	// WordData writes or reads a 16 bits little endian word at a command code.
+	WordData Protocol = 3
+	// ProcCall writes a 16 bits word at a command code and reads a 16 bits word
+	// back.
+	ProcCall Protocol = 4
+	// BlockData writes or reads up to BlockMax bytes at a command code, prefixed
+	// with the byte count.
+	BlockData Protocol = 5
+)
+
+const protocolName = "QuickByteByteDataWordDataProcCallBlockData"
+
+var protocolIndex = [...]uint8{0, 5, 9, 17, 25, 33, 42}
+
+func (i Protocol) String() string {
+	if i < 0 || i >= Protocol(len(protocolIndex)-1) {
+		return "Protocol(" + strconv.Itoa(int(i)) + ")"
+	}
+	return protocolName[protocolIndex[i]:protocolIndex[i+1]]
+}
+
+// Bus is implemented by an i2c.Bus that supports native SMBus transactions.
+//
+// This interface is meant to be implemented by the bus driver, e.g.
+// host/sysfs, and is consumed by Dev. A device driver should use Dev instead.
+type Bus interface {
+	i2c.Bus
+	// SMBusSupports returns true if the protocol is natively supported in the
+	// specified direction, with or without PEC.
+	SMBusSupports(p Protocol, read, pec bool) bool
+	// SMBusTx executes a single SMBus transaction at the specified 7 bits
+	// device address.
+	//
+	// The content of data depends on the protocol:
+	//   - Quick: data is ignored; read is sent as the R/W bit.
+	//   - Byte: cmd is ignored; data[0] is sent or received.
+	//   - ByteData: data[0] is sent or received.
+	//   - WordData: data[:2] is sent or received, in little endian.
+	//   - ProcCall: read is ignored; data[:2] is sent then replaced with the
+	//     reply.
+	//   - BlockData: data is sent or filled with the block read.
+	//
+	// It returns the number of bytes of data received for reads.
+	SMBusTx(addr uint16, p Protocol, read bool, cmd byte, data []byte, pec bool) (int, error)
+}
+
+// Dev is a device on a SMBus.
+//
+// It saves from repeatedly specifying the device address and whether Packet
+// Error Checking is enabled.
+//
+// Native transactions are subject to the bus driver's policy. For example
+// host/sysfs refuses an address bound to a kernel driver unless
+// sysfs.I2C.SetForce(true) was called.
+type Dev struct {
+	Bus  i2c.Bus
+	Addr uint16
+	// PEC enables Packet Error Checking on all transactions.
+	PEC bool
+}
+
+func (d *Dev) String() string {
+	s := "<nil>"
+	if d.Bus != nil {
+		s = d.Bus.String()
+	}
+	return s + "(" + strconv.Itoa(int(d.Addr)) + ")"
+}
+
+// Quick sends the device address with the R/W bit set to read, without data.
+//
+// It is usually used to probe for a device or to toggle it on or off. When
+// not supported natively, only a write can be sent, as a single zero-length
+// message via i2c.MsgBus. i2c.Bus.Tx() is not used since a bus may return
+// without touching the wire when there is no data.
+func (d *Dev) Quick(read bool) error {
+	if n, ok := d.native(Quick, read); ok {
+		_, err := n.SMBusTx(d.Addr, Quick, read, 0, nil, false)
+		return err
+	}
+	if read {
+		return errors.New("smbus: quick read requires native support")
+	}
+	m, ok := d.Bus.(i2c.MsgBus)
+	if !ok {
+		return errors.New("smbus: quick write requires native support or i2c.MsgBus")
+	}
+	return m.TxMsgs([]i2c.Msg{{Addr: d.Addr}})
+}
+
+// ReadByte receives a single byte from the device without command code.
+//
+// It implements io.ByteReader.
+func (d *Dev) ReadByte() (byte, error) {
+	var b [1]byte
+	if n, ok := d.native(Byte, true); ok {
+		_, err := n.SMBusTx(d.Addr, Byte, true, 0, b[:], d.PEC)
+		return b[0], err
+	}
+	err := d.read(nil, b[:], false)
+	return b[0], err
+}
+
+// WriteByte sends a single byte to the device without command code.
+//
+// It implements io.ByteWriter.
+func (d *Dev) WriteByte(c byte) error {
+	if n, ok := d.native(Byte, false); ok {
+		_, err := n.SMBusTx(d.Addr, Byte, false, 0, []byte{c}, d.PEC)
+		return err
+	}
+	return d.write([]byte{c})
+}
+
+// ReadByteData reads one byte at the command code cmd.
+func (d *Dev) ReadByteData(cmd byte) (byte, error) {
+	var b [1]byte
+	if n, ok := d.native(ByteData, true); ok {
+		_, err := n.SMBusTx(d.Addr, ByteData, true, cmd, b[:], d.PEC)
+		return b[0], err
+	}
+	err := d.read([]byte{cmd}, b[:], false)
+	return b[0], err
+}
+
+// WriteByteData writes one byte at the command code cmd.
+func (d *Dev) WriteByteData(cmd, b byte) error {
+	if n, ok := d.native(ByteData, false); ok {
+		_, err := n.SMBusTx(d.Addr, ByteData, false, cmd, []byte{b}, d.PEC)
+		return err
+	}
+	return d.write([]byte{cmd, b})
+}
+
+// ReadWord reads a 16 bits word at the command code cmd.
+//
+// SMBus words are sent in little endian.
+func (d *Dev) ReadWord(cmd byte) (uint16, error) {
+	var b [2]byte
+	if n, ok := d.native(WordData, true); ok {
+		_, err := n.SMBusTx(d.Addr, WordData, true, cmd, b[:], d.PEC)
+		return uint16(b[0]) | uint16(b[1])<<8, err
+	}
+	err := d.read([]byte{cmd}, b[:], false)
+	return uint16(b[0]) | uint16(b[1])<<8, err
+}
+
+// WriteWord writes a 16 bits word at the command code cmd.
+func (d *Dev) WriteWord(cmd byte, w uint16) error {
+	b := []byte{byte(w), byte(w >> 8)}
+	if n, ok := d.native(WordData, false); ok {
+		_, err := n.SMBusTx(d.Addr, WordData, false, cmd, b, d.PEC)
+		return err
+	}
+	return d.write([]byte{cmd, b[0], b[1]})
+}
+
+// ProcessCall writes a 16 bits word at the command code cmd and returns the
+// 16 bits word replied by the device, in a single transaction.
+func (d *Dev) ProcessCall(cmd byte, w uint16) (uint16, error) {
+	b := []byte{byte(w), byte(w >> 8)}
+	if n, ok := d.native(ProcCall, false); ok {
+		_, err := n.SMBusTx(d.Addr, ProcCall, false, cmd, b, d.PEC)
+		return uint16(b[0]) | uint16(b[1])<<8, err
+	}
+	var r [2]byte
+	err := d.read([]byte{cmd, b[0], b[1]}, r[:], false)
+	return uint16(r[0]) | uint16(r[1])<<8, err
+}
+
+// ReadBlock reads a block at the command code cmd into b and returns the
+// number of bytes read.
+//
+// The device decides how many bytes are returned, up to BlockMax. When
+// emulated over i2c.Bus.Tx(), len(b) bytes are read past the byte count since
+// the length of the transfer has to be known in advance, so b must be large
+// enough to hold the block the device is expected to return.
+func (d *Dev) ReadBlock(cmd byte, b []byte) (int, error) {
+	if n, ok := d.native(BlockData, true); ok {
+		var buf [BlockMax]byte
+		l, err := n.SMBusTx(d.Addr, BlockData, true, cmd, buf[:], d.PEC)
+		if err != nil {
+			return 0, err
+		}
+		if l > len(b) {
+			return 0, fmt.Errorf("smbus: block of %d bytes doesn't fit in %d bytes", l, len(b))
+		}
+		return copy(b, buf[:l]), nil
+	}
+	if len(b) > BlockMax {
+		b = b[:BlockMax]
+	}
+	r := make([]byte, len(b)+1)
+	if err := d.read([]byte{cmd}, r, true); err != nil {
+		return 0, err
+	}
+	if l := int(r[0]); l > len(b) {
+		return 0, fmt.Errorf("smbus: block of %d bytes doesn't fit in %d bytes", l, len(b))
+	}
+	return copy(b, r[1:1+r[0]]), nil
+}
+
+// WriteBlock writes the block b at the command code cmd.
+//
+// b must not be larger than BlockMax.
+func (d *Dev) WriteBlock(cmd byte, b []byte) error {
+	if len(b) > BlockMax {
+		return fmt.Errorf("smbus: block of %d bytes is larger than %d bytes", len(b), BlockMax)
+	}
+	if n, ok := d.native(BlockData, false); ok {
+		_, err := n.SMBusTx(d.Addr, BlockData, false, cmd, b, d.PEC)
+		return err
+	}
+	w := make([]byte, 0, len(b)+3)
+	w = append(w, cmd, byte(len(b)))
+	return d.write(append(w, b...))
+}
+
+//
+
+// native returns the native SMBus bus if it supports the protocol.
+func (d *Dev) native(p Protocol, read bool) (Bus, bool) {
+	n, ok := d.Bus.(Bus)
+	if !ok || !n.SMBusSupports(p, read, d.PEC) {
+		return nil, false
+	}
+	return n, true
+}
+
+// write emulates a SMBus write over i2c.Bus.Tx(), appending the PEC if
+// enabled.
+func (d *Dev) write(w []byte) error {
+	if d.PEC {
+		w = append(w, CalcPEC(append([]byte{byte(d.Addr << 1)}, w...)))
+	}
+	return d.Bus.Tx(d.Addr, w, nil)
+}
+
+// read emulates a SMBus read over i2c.Bus.Tx(), verifying the PEC if enabled.
+//
+// For block reads, r[0] is the byte count and the PEC immediately follows the
+// block.
+func (d *Dev) read(w, r []byte, block bool) error {
+	if !d.PEC {
+		return d.Bus.Tx(d.Addr, w, r)
+	}
+	buf := make([]byte, len(r)+1)
+	if err := d.Bus.Tx(d.Addr, w, buf); err != nil {
+		return err
+	}
+	l := len(r)
+	if block {
+		if l = 1 + int(buf[0]); l > len(r) {
+			return fmt.Errorf("smbus: block of %d bytes doesn't fit in %d bytes", buf[0], len(r)-1)
+		}
+	}
+	// The PEC covers all the bytes on the wire, including the addresses.
+	var msg []byte
+	if len(w) != 0 {
+		msg = append([]byte{byte(d.Addr << 1)}, w...)
+	}
+	msg = append(msg, byte(d.Addr<<1|1))
+	msg = append(msg, buf[:l]...)
+	if c := CalcPEC(msg); c != buf[l] {
+		return fmt.Errorf("smbus: invalid PEC 0x%02x; expected 0x%02x", buf[l], c)
+	}
+	copy(r, buf[:l])
+	return nil
+}
@@ -0,0 +1,291 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package smbus
+
+import (
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestProtocol_String(t *testing.T) {
+	data := []struct {
+		p        Protocol
+		expected string
+	}{
+		{Quick, "Quick"},
+		{Byte, "Byte"},
+		{ByteData, "ByteData"},
+		{WordData, "WordData"},
+		{ProcCall, "ProcCall"},
+		{BlockData, "BlockData"},
+		{Protocol(-1), "Protocol(-1)"},
+		{Protocol(6), "Protocol(6)"},
+	}
+	for i, line := range data {
+		if s := line.p.String(); s != line.expected {
+			t.Fatalf("#%d: %q != %q", i, s, line.expected)
+		}
+	}
+}
+
+func TestCalcPEC(t *testing.T) {
+	if c := CalcPEC([]byte("123456789")); c != 0xF4 {
+		t.Fatalf("0x%02x", c)
+	}
+	if c := CalcPEC(nil); c != 0 {
+		t.Fatalf("0x%02x", c)
+	}
+}
+
+func TestDev_String(t *testing.T) {
+	d := Dev{Addr: 16}
+	if s := d.String(); s != "<nil>(16)" {
+		t.Fatal(s)
+	}
+	d.Bus = &i2ctest.Playback{}
+	if s := d.String(); s != "playback(16)" {
+		t.Fatal(s)
+	}
+}
+
+func TestDev_emulated(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Msgs: []i2c.Msg{{Addr: 0x10}}},
+			{Addr: 0x10, R: []byte{0x42}},
+			{Addr: 0x10, W: []byte{0x42}},
+			{Addr: 0x10, W: []byte{0x01}, R: []byte{0x42}},
+			{Addr: 0x10, W: []byte{0x01, 0x42}},
+			{Addr: 0x10, W: []byte{0x02}, R: []byte{0x34, 0x12}},
+			{Addr: 0x10, W: []byte{0x02, 0x34, 0x12}},
+			{Addr: 0x10, W: []byte{0x04, 0x34, 0x12}, R: []byte{0x78, 0x56}},
+			{Addr: 0x10, W: []byte{0x03}, R: []byte{0x02, 0xAA, 0xBB, 0xFF}},
+			{Addr: 0x10, W: []byte{0x03, 0x02, 0xAA, 0xBB}},
+		},
+	}
+	d := Dev{Bus: &bus, Addr: 0x10}
+	if err := d.Quick(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Quick(true); err == nil {
+		t.Fatal("quick read can't be emulated")
+	}
+	if err := (&Dev{Bus: &nativeBus{noQuick: true}, Addr: 0x10}).Quick(false); err == nil {
+		t.Fatal("quick write can't be emulated without i2c.MsgBus")
+	}
+	if b, err := d.ReadByte(); err != nil || b != 0x42 {
+		t.Fatal(b, err)
+	}
+	if err := d.WriteByte(0x42); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := d.ReadByteData(0x01); err != nil || b != 0x42 {
+		t.Fatal(b, err)
+	}
+	if err := d.WriteByteData(0x01, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := d.ReadWord(0x02); err != nil || w != 0x1234 {
+		t.Fatal(w, err)
+	}
+	if err := d.WriteWord(0x02, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := d.ProcessCall(0x04, 0x1234); err != nil || w != 0x5678 {
+		t.Fatal(w, err)
+	}
+	b := make([]byte, 3)
+	if n, err := d.ReadBlock(0x03, b); err != nil || n != 2 || b[0] != 0xAA || b[1] != 0xBB {
+		t.Fatal(n, b, err)
+	}
+	if err := d.WriteBlock(0x03, []byte{0xAA, 0xBB}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.WriteBlock(0x03, make([]byte, BlockMax+1)); err == nil {
+		t.Fatal("block too large")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_emulated_PEC(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x10, R: []byte{0x42, 0x72}},
+			{Addr: 0x10, W: []byte{0x01, 0x42, 0x9F}},
+			{Addr: 0x10, W: []byte{0x01}, R: []byte{0x42, 0xD7}},
+			{Addr: 0x10, W: []byte{0x02}, R: []byte{0x34, 0x12, 0xB3}},
+			{Addr: 0x10, W: []byte{0x04, 0x34, 0x12}, R: []byte{0x78, 0x56, 0x10}},
+			{Addr: 0x10, W: []byte{0x03}, R: []byte{0x02, 0xAA, 0xBB, 0x21, 0xFF}},
+			{Addr: 0x10, W: []byte{0x03, 0x02, 0xAA, 0xBB, 0x3A}},
+			// Invalid PEC.
+			{Addr: 0x10, W: []byte{0x01}, R: []byte{0x42, 0x00}},
+			// Block doesn't fit.
+			{Addr: 0x10, W: []byte{0x03}, R: []byte{0x04, 0xAA, 0xBB, 0x21}},
+		},
+	}
+	d := Dev{Bus: &bus, Addr: 0x10, PEC: true}
+	if b, err := d.ReadByte(); err != nil || b != 0x42 {
+		t.Fatal(b, err)
+	}
+	if err := d.WriteByteData(0x01, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := d.ReadByteData(0x01); err != nil || b != 0x42 {
+		t.Fatal(b, err)
+	}
+	if w, err := d.ReadWord(0x02); err != nil || w != 0x1234 {
+		t.Fatal(w, err)
+	}
+	if w, err := d.ProcessCall(0x04, 0x1234); err != nil || w != 0x5678 {
+		t.Fatal(w, err)
+	}
+	b := make([]byte, 3)
+	if n, err := d.ReadBlock(0x03, b); err != nil || n != 2 || b[0] != 0xAA || b[1] != 0xBB {
+		t.Fatal(n, b, err)
+	}
+	if err := d.WriteBlock(0x03, []byte{0xAA, 0xBB}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.ReadByteData(0x01); err == nil || err.Error() != "smbus: invalid PEC 0x00; expected 0xd7" {
+		t.Fatal(err)
+	}
+	if _, err := d.ReadBlock(0x03, make([]byte, 2)); err == nil || err.Error() != "smbus: block of 4 bytes doesn't fit in 2 bytes" {
+		t.Fatal(err)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_ReadBlock_too_large(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{{Addr: 0x10, W: []byte{0x03}, R: []byte{0x03, 0xAA, 0xBB}}},
+	}
+	d := Dev{Bus: &bus, Addr: 0x10}
+	if _, err := d.ReadBlock(0x03, make([]byte, 2)); err == nil || err.Error() != "smbus: block of 3 bytes doesn't fit in 2 bytes" {
+		t.Fatal(err)
+	}
+}
+
+func TestDev_native(t *testing.T) {
+	bus := nativeBus{}
+	d := Dev{Bus: &bus, Addr: 0x10, PEC: true}
+	if err := d.Quick(true); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := d.ReadByte(); err != nil || b != 0x42 {
+		t.Fatal(b, err)
+	}
+	if err := d.WriteByte(0x42); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := d.ReadByteData(0x01); err != nil || b != 0x42 {
+		t.Fatal(b, err)
+	}
+	if err := d.WriteByteData(0x01, 0x42); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := d.ReadWord(0x02); err != nil || w != 0x4242 {
+		t.Fatal(w, err)
+	}
+	if err := d.WriteWord(0x02, 0x1234); err != nil {
+		t.Fatal(err)
+	}
+	if w, err := d.ProcessCall(0x04, 0x1234); err != nil || w != 0x4242 {
+		t.Fatal(w, err)
+	}
+	b := make([]byte, 3)
+	if n, err := d.ReadBlock(0x03, b); err != nil || n != 3 || b[2] != 0x42 {
+		t.Fatal(n, b, err)
+	}
+	if _, err := d.ReadBlock(0x03, make([]byte, 2)); err == nil {
+		t.Fatal("block doesn't fit")
+	}
+	if err := d.WriteBlock(0x03, []byte{0xAA, 0xBB}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []Protocol{Quick, Byte, Byte, ByteData, ByteData, WordData, WordData, ProcCall, BlockData, BlockData, BlockData}
+	if len(bus.ops) != len(expected) {
+		t.Fatal(bus.ops)
+	}
+	for i := range expected {
+		if bus.ops[i] != expected[i] {
+			t.Fatalf("#%d: %s != %s", i, bus.ops[i], expected[i])
+		}
+	}
+	bus.err = errors.New("oops")
+	if _, err := d.ReadBlock(0x03, b); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestDev_native_unsupported(t *testing.T) {
+	// The bus doesn't support PEC so the transaction is emulated.
+	bus := nativeBus{noPEC: true}
+	d := Dev{Bus: &bus, Addr: 0x10, PEC: true}
+	if err := d.WriteByte(0x42); err != nil {
+		t.Fatal(err)
+	}
+	if len(bus.ops) != 0 || len(bus.tx) != 1 || len(bus.tx[0]) != 2 {
+		t.Fatal(bus.ops, bus.tx)
+	}
+}
+
+//
+
+// nativeBus is a fake smbus.Bus that returns 0x42 for all bytes read.
+type nativeBus struct {
+	noPEC   bool
+	noQuick bool
+	err     error
+	ops     []Protocol
+	tx      [][]byte
+}
+
+func (n *nativeBus) String() string {
+	return "native"
+}
+
+func (n *nativeBus) Tx(addr uint16, w, r []byte) error {
+	n.tx = append(n.tx, w)
+	return nil
+}
+
+func (n *nativeBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+func (n *nativeBus) SMBusSupports(p Protocol, read, pec bool) bool {
+	if p == Quick && n.noQuick {
+		return false
+	}
+	return !pec || !n.noPEC
+}
+
+func (n *nativeBus) SMBusTx(addr uint16, p Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	n.ops = append(n.ops, p)
+	if n.err != nil {
+		return 0, n.err
+	}
+	if !read && p != ProcCall {
+		return 0, nil
+	}
+	l := len(data)
+	if p == BlockData {
+		l = 3
+	}
+	for i := 0; i < l; i++ {
+		data[i] = 0x42
+	}
+	return l, nil
+}
+
+var _ Bus = &nativeBus{}
@@ -0,0 +1,58 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package i2c
+
+import (
+	"io"
+
+	"periph.io/x/periph/conn"
+)
+
+// Target is a I²C target device emulated by the host. In this mode, the host
+// answers to a controller on the bus instead of driving it.
+//
+// The target exposes a memory of 8 bits registers to the controller, like an
+// EEPROM: the first byte of a write message sets the register address and
+// the following bytes are stored in consecutive registers. A read message
+// returns the content of consecutive registers starting at the current
+// register address. The register address wraps around at the end of the
+// memory.
+//
+// The host accesses the registers with ReadAt() and WriteAt().
+//
+// Halt() stops responding on the bus.
+type Target interface {
+	conn.Resource
+	io.ReaderAt
+	io.WriterAt
+	// Addr returns the address the target responds to.
+	Addr() uint16
+}
+
+// TargetHandler is implemented by the application to be notified of the
+// accesses done by the controller to a Target.
+//
+// The callbacks are called synchronously from the bus driver and must not
+// block.
+type TargetHandler interface {
+	// OnWrite is called after the controller wrote data to the registers
+	// starting at reg.
+	OnWrite(reg int, data []byte)
+	// OnRead is called before the controller reads n bytes from the registers
+	// starting at reg, so the registers can be updated with Target.WriteAt().
+	//
+	// Backends that can't intercept reads, like the Linux slave-eeprom backend,
+	// never call it.
+	OnRead(reg, n int)
+}
+
+// TargetBus is implemented by a Bus that can emulate target devices.
+type TargetBus interface {
+	// NewTarget starts responding at addr with a memory of size registers,
+	// initially zeroed.
+	//
+	// The maximum supported size is 256. h can be nil.
+	NewTarget(addr uint16, size int, h TargetHandler) (Target, error)
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package onewire
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestTxContext(t *testing.T) {
+	b := &fakeBus{r: []byte{2}}
+	r := make([]byte, 1)
+	if err := TxContext(context.Background(), b, []byte{1}, r, StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	if b.power != StrongPullup || !bytes.Equal(b.w, []byte{1}) || !bytes.Equal(r, []byte{2}) {
+		t.Fatal(b.power, b.w, r)
+	}
+	c := &ctxBus{}
+	if err := TxContext(context.Background(), c, nil, nil, StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	if c.power != StrongPullup {
+		t.Fatal("TxContext wasn't called")
+	}
+}
+
+//
+
+type ctxBus struct {
+	fakeBus
+}
+
+func (c *ctxBus) TxContext(ctx context.Context, w, r []byte, power Pullup) error {
+	c.power = power
+	return nil
+}
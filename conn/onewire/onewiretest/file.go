@@ -0,0 +1,68 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewiretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/onewire"
+)
+
+// Save writes the recorded operations to w in the format described at
+// conntest.FileHeader.
+//
+// Each line is in the form {"w":"cc44","r":"0102","pull":true}, where pull is
+// true for onewire.StrongPullup.
+//
+// The file can be loaded back with Playback.Load().
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	h := &conntest.FileHeader{Kind: "onewire"}
+	if r.Bus != nil {
+		h.Bus = r.Bus.String()
+	}
+	return conntest.Encode(w, h, len(r.Ops), func(i int) interface{} {
+		return &ioLine{W: r.Ops[i].W, R: r.Ops[i].R, Pull: bool(r.Ops[i].Pull)}
+	})
+}
+
+// Load reads the operations saved by Record.Save() from r.
+//
+// Ops is replaced and Count is reset. Devices is left untouched.
+func (p *Playback) Load(r io.Reader) error {
+	var ops []IO
+	h, err := conntest.Decode(r, func(d *json.Decoder) error {
+		l := ioLine{}
+		if err := d.Decode(&l); err != nil {
+			return err
+		}
+		ops = append(ops, IO{W: l.W, R: l.R, Pull: onewire.Pullup(l.Pull)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if h.Kind != "onewire" {
+		return fmt.Errorf("onewiretest: expected a onewire recording, got %q", h.Kind)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.Count = 0
+	return nil
+}
+
+//
+
+// ioLine is an IO as saved in a recording file.
+type ioLine struct {
+	W    conntest.Hex `json:"w,omitempty"`
+	R    conntest.Hex `json:"r,omitempty"`
+	Pull bool         `json:"pull,omitempty"`
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package onewiretest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/onewire"
+)
+
+func TestRecord_Save_Load(t *testing.T) {
+	r := Record{}
+	if err := r.Tx([]byte{0xcc, 0x44}, nil, onewire.StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Tx([]byte{0xcc, 0xbe}, nil, onewire.WeakPullup); err != nil {
+		t.Fatal(err)
+	}
+	r.Ops[1].R = []byte{1, 2}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("%q", lines)
+	}
+	if !strings.HasPrefix(lines[0], `{"version":1,"kind":"onewire","time":"`) {
+		t.Fatal(lines[0])
+	}
+	if lines[1] != `{"w":"cc44","pull":true}` || lines[2] != `{"w":"ccbe","r":"0102"}` {
+		t.Fatalf("%q", lines[1:])
+	}
+
+	p := Playback{}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatalf("%#v != %#v", p.Ops, r.Ops)
+	}
+	if err := p.Load(strings.NewReader(`{"version":1,"kind":"i2c"}`)); err == nil {
+		t.Fatal("expected kind error")
+	}
+}
@@ -5,6 +5,8 @@
 package physic
 
 import (
+	"errors"
+	"sync"
 	"time"
 
 	"periph.io/x/periph/conn"
@@ -40,3 +42,99 @@ type SenseEnv interface {
 	// or doing oversampling in software. Refer to its datasheet if available.
 	Precision(env *Env)
 }
+
+// Poller implements continuous sensing for devices that can only be polled.
+//
+// It calls a function at a regular interval from a goroutine until Halt() is
+// called. Embed it in a device to implement SenseEnv.SenseContinuous() on top
+// of SenseEnv.Sense(), and call Halt() from the device's Halt().
+//
+// The zero value is ready to use.
+type Poller struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start calls poll right away, then every interval, until Halt() is called or
+// poll returns false. done is called, if not nil, once the polling stopped.
+//
+// poll is called with a channel that is closed when Halt() is called; poll
+// must return promptly when it is closed.
+//
+// A previous polling is stopped first.
+func (p *Poller) Start(interval time.Duration, poll func(stop <-chan struct{}) bool, done func()) error {
+	if interval <= 0 {
+		return errors.New("physic: invalid interval")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.halt()
+	stop := make(chan struct{})
+	p.stop = stop
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if done != nil {
+			defer done()
+		}
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for poll(stop) {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// SenseContinuous implements SenseEnv.SenseContinuous() on top of sense,
+// usually the device's Sense() method.
+//
+// The first measurement is done right away. If sense fails, the sensing stops
+// and the channel is closed.
+//
+// It's the responsibility of the caller to retrieve the values from the channel
+// as fast as possible, otherwise the interval may not be respected.
+func (p *Poller) SenseContinuous(interval time.Duration, sense func(e *Env) error) (<-chan Env, error) {
+	c := make(chan Env)
+	poll := func(stop <-chan struct{}) bool {
+		e := Env{}
+		if err := sense(&e); err != nil {
+			return false
+		}
+		select {
+		case c <- e:
+			return true
+		case <-stop:
+			return false
+		}
+	}
+	if err := p.Start(interval, poll, func() { close(c) }); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Halt stops the polling, if any, and waits for it to terminate.
+//
+// It must not be called while holding a lock needed by the poll function.
+func (p *Poller) Halt() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.halt()
+}
+
+// halt stops the polling.
+//
+// lock must be held.
+func (p *Poller) halt() {
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+		p.wg.Wait()
+	}
+}
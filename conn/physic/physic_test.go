@@ -0,0 +1,96 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package physic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoller_SenseContinuous(t *testing.T) {
+	var p Poller
+	i := 0
+	sense := func(e *Env) error {
+		i++
+		e.Temperature = Temperature(i) * Kelvin
+		return nil
+	}
+	if _, err := p.SenseContinuous(0, sense); err == nil {
+		t.Fatal("invalid interval")
+	}
+	c, err := p.SenseContinuous(time.Nanosecond, sense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := 1; j < 4; j++ {
+		if e := <-c; e.Temperature != Temperature(j)*Kelvin {
+			t.Fatal(j, e)
+		}
+	}
+	p.Halt()
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	// Halt is idempotent.
+	p.Halt()
+}
+
+func TestPoller_SenseContinuous_restart(t *testing.T) {
+	var p Poller
+	sense := func(e *Env) error {
+		e.Humidity = PercentRH
+		return nil
+	}
+	c1, err := p.SenseContinuous(time.Hour, sense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.SenseContinuous(time.Hour, sense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first polling was stopped before it could send its first measurement.
+	if _, ok := <-c1; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if e := <-c2; e.Humidity != PercentRH {
+		t.Fatal(e)
+	}
+	p.Halt()
+	if _, ok := <-c2; ok {
+		t.Fatal("expected channel to be closed")
+	}
+}
+
+func TestPoller_SenseContinuous_fail(t *testing.T) {
+	var p Poller
+	c, err := p.SenseContinuous(time.Hour, func(e *Env) error { return errors.New("oops") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	p.Halt()
+}
+
+func TestPoller_Start(t *testing.T) {
+	var p Poller
+	i := 0
+	done := make(chan struct{})
+	poll := func(stop <-chan struct{}) bool {
+		i++
+		return i < 3
+	}
+	if err := p.Start(time.Nanosecond, poll, func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if i != 3 {
+		t.Fatal(i)
+	}
+	p.Halt()
+}
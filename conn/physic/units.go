@@ -5,7 +5,9 @@
 package physic
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -54,6 +56,47 @@ func (a Angle) String() string {
 	}
 }
 
+// Set sets the angle to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "°" or "rad", the latter optionally with a S.I. prefix, e.g.
+// "12.5°" or "1.5rad". A value without unit is in degree. Degrees are parsed
+// with a precision of 0.000001°.
+func (a *Angle) Set(s string) error {
+	if strings.HasSuffix(s, "rad") {
+		v, err := parseSI(s, "rad", 9)
+		if err != nil {
+			return err
+		}
+		*a = Angle(v)
+		return nil
+	}
+	v, err := parseDecimal(strings.TrimSuffix(s, "°"), 6)
+	if err != nil {
+		return errors.New("physic: can't parse " + strconv.Quote(s) + ": " + err.Error())
+	}
+	// Split the calculation to not overflow.
+	i := v / 1000000
+	if i > maxInt64/int64(Degree)-1 || i < -maxInt64/int64(Degree)+1 {
+		return errors.New("physic: can't parse " + strconv.Quote(s) + ": value out of range")
+	}
+	*a = Angle(i)*Degree + Angle(v%1000000)*Degree/1000000
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+//
+// The angle is formatted in radian since degrees are parsed with a lower
+// precision.
+func (a Angle) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(a)) + "rad"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Angle) UnmarshalText(text []byte) error {
+	return a.Set(string(text))
+}
+
 const (
 	NanoRadian  Angle = 1
 	MicroRadian Angle = 1000 * NanoRadian
@@ -78,6 +121,30 @@ func (d Distance) String() string {
 	return nanoAsString(int64(d)) + "m"
 }
 
+// Set sets the distance to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "m", optionally with a S.I. prefix, e.g. "1.5km". A
+// value without unit is in m.
+func (d *Distance) Set(s string) error {
+	v, err := parseSI(s, "m", 9)
+	if err != nil {
+		return err
+	}
+	*d = Distance(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Distance) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(d)) + "m"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Distance) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
 const (
 	NanoMetre  Distance = 1
 	MicroMetre Distance = 1000 * NanoMetre
@@ -107,6 +174,30 @@ func (e ElectricCurrent) String() string {
 	return nanoAsString(int64(e)) + "A"
 }
 
+// Set sets the current to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "A", optionally with a S.I. prefix, e.g. "20mA". A
+// value without unit is in A.
+func (e *ElectricCurrent) Set(s string) error {
+	v, err := parseSI(s, "A", 9)
+	if err != nil {
+		return err
+	}
+	*e = ElectricCurrent(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e ElectricCurrent) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(e)) + "A"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *ElectricCurrent) UnmarshalText(text []byte) error {
+	return e.Set(string(text))
+}
+
 const (
 	NanoAmpere  ElectricCurrent = 1
 	MicroAmpere ElectricCurrent = 1000 * NanoAmpere
@@ -125,6 +216,30 @@ func (e ElectricPotential) String() string {
 	return nanoAsString(int64(e)) + "V"
 }
 
+// Set sets the tension to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "V", optionally with a S.I. prefix, e.g. "3.3V". A
+// value without unit is in V.
+func (e *ElectricPotential) Set(s string) error {
+	v, err := parseSI(s, "V", 9)
+	if err != nil {
+		return err
+	}
+	*e = ElectricPotential(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e ElectricPotential) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(e)) + "V"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *ElectricPotential) UnmarshalText(text []byte) error {
+	return e.Set(string(text))
+}
+
 const (
 	// Volt is W/A, kg⋅m²/s³/A.
 	NanoVolt  ElectricPotential = 1
@@ -145,6 +260,30 @@ func (e ElectricResistance) String() string {
 	return nanoAsString(int64(e)) + "Ω"
 }
 
+// Set sets the resistance to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "Ω", optionally with a S.I. prefix, e.g. "4.7kΩ". A
+// value without unit is in Ω.
+func (e *ElectricResistance) Set(s string) error {
+	v, err := parseSI(s, "Ω", 9)
+	if err != nil {
+		return err
+	}
+	*e = ElectricResistance(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e ElectricResistance) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(e)) + "Ω"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (e *ElectricResistance) UnmarshalText(text []byte) error {
+	return e.Set(string(text))
+}
+
 const (
 	// Ohm is V/A, kg⋅m²/s³/A².
 	NanoOhm  ElectricResistance = 1
@@ -170,6 +309,30 @@ func (f Force) String() string {
 	return nanoAsString(int64(f)) + "N"
 }
 
+// Set sets the force to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "N", optionally with a S.I. prefix, e.g. "9.807N". A
+// value without unit is in N.
+func (f *Force) Set(s string) error {
+	v, err := parseSI(s, "N", 9)
+	if err != nil {
+		return err
+	}
+	*f = Force(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (f Force) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(f)) + "N"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Force) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
 const (
 	// Newton is kg⋅m/s².
 	NanoNewton  Force = 1
@@ -198,6 +361,30 @@ func (f Frequency) String() string {
 	return microAsString(int64(f)) + "Hz"
 }
 
+// Set sets the frequency to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "Hz", optionally with a S.I. prefix, e.g. "400kHz". A
+// value without unit is in Hz.
+func (f *Frequency) Set(s string) error {
+	v, err := parseSI(s, "Hz", 6)
+	if err != nil {
+		return err
+	}
+	*f = Frequency(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (f Frequency) MarshalText() ([]byte, error) {
+	return []byte(microAsExactString(int64(f)) + "Hz"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Frequency) UnmarshalText(text []byte) error {
+	return f.Set(string(text))
+}
+
 // Duration returns the duration of one cycle at this frequency.
 func (f Frequency) Duration() time.Duration {
 	return time.Second * time.Duration(Hertz) / time.Duration(f)
@@ -230,6 +417,30 @@ func (m Mass) String() string {
 	return nanoAsString(int64(m)) + "g"
 }
 
+// Set sets the mass to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "g", optionally with a S.I. prefix, e.g. "2.5kg". A
+// value without unit is in g.
+func (m *Mass) Set(s string) error {
+	v, err := parseSI(s, "g", 9)
+	if err != nil {
+		return err
+	}
+	*m = Mass(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m Mass) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(m)) + "g"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Mass) UnmarshalText(text []byte) error {
+	return m.Set(string(text))
+}
+
 const (
 	NanoGram  Mass = 1
 	MicroGram Mass = 1000 * NanoGram
@@ -261,6 +472,30 @@ func (p Pressure) String() string {
 	return nanoAsString(int64(p)) + "Pa"
 }
 
+// Set sets the pressure to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "Pa", optionally with a S.I. prefix, e.g. "101.325kPa". A
+// value without unit is in Pa.
+func (p *Pressure) Set(s string) error {
+	v, err := parseSI(s, "Pa", 9)
+	if err != nil {
+		return err
+	}
+	*p = Pressure(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p Pressure) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(p)) + "Pa"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Pressure) UnmarshalText(text []byte) error {
+	return p.Set(string(text))
+}
+
 const (
 	// Pascal is N/m², kg/m/s².
 	NanoPascal  Pressure = 1
@@ -289,6 +524,34 @@ func (r RelativeHumidity) String() string {
 	return strconv.Itoa(int(r)/10) + "." + strconv.Itoa(frac) + "%rH"
 }
 
+// Set sets the humidity to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "%rH" or "%", e.g. "45.5%rH". A value without unit is in
+// %rH.
+func (r *RelativeHumidity) Set(s string) error {
+	t := strings.TrimSuffix(s, "rH")
+	v, err := parseDecimal(strings.TrimSuffix(t, "%"), 5)
+	if err == nil && (v > 2147483647 || v < -2147483648) {
+		err = errors.New("value out of range")
+	}
+	if err != nil {
+		return errors.New("physic: can't parse " + strconv.Quote(s) + ": " + err.Error())
+	}
+	*r = RelativeHumidity(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r RelativeHumidity) MarshalText() ([]byte, error) {
+	return []byte(decimalString(int64(r), 5) + "%rH"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (r *RelativeHumidity) UnmarshalText(text []byte) error {
+	return r.Set(string(text))
+}
+
 const (
 	TenthMicroRH RelativeHumidity = 1                 // 0.00001%rH
 	MicroRH      RelativeHumidity = 10 * TenthMicroRH // 0.0001%rH
@@ -307,6 +570,30 @@ func (s Speed) String() string {
 	return nanoAsString(int64(s)) + "m/s"
 }
 
+// Set sets the speed to the value represented by str. It implements
+// flag.Value.
+//
+// Units must be "m/s", optionally with a S.I. prefix, e.g. "340m/s". A
+// value without unit is in m/s.
+func (s *Speed) Set(str string) error {
+	v, err := parseSI(str, "m/s", 9)
+	if err != nil {
+		return err
+	}
+	*s = Speed(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Speed) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(s)) + "m/s"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Speed) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
+
 const (
 	// MetrePerSecond is m/s.
 	NanoMetrePerSecond  Speed = 1
@@ -335,6 +622,54 @@ func (t Temperature) String() string {
 	return nanoAsString(int64(t-ZeroCelsius)) + "°C"
 }
 
+// Set sets the temperature to the value represented by s. It implements
+// flag.Value.
+//
+// Units must be "°C", "°F" or "K", optionally with a S.I. prefix, e.g.
+// "25.5°C", "78°F" or "300K". A value without unit is in °C.
+func (t *Temperature) Set(s string) error {
+	switch {
+	case strings.HasSuffix(s, "K"):
+		v, err := parseSI(s, "K", 9)
+		if err != nil {
+			return err
+		}
+		*t = Temperature(v)
+	case strings.HasSuffix(s, "°F"):
+		v, err := parseSI(s, "°F", 9)
+		if err != nil {
+			return err
+		}
+		// Convert in K: (F + 459.67) * 5 / 9. Split the calculation to not
+		// overflow.
+		if v > maxInt64-459670000000 {
+			return errors.New("physic: can't parse " + strconv.Quote(s) + ": value out of range")
+		}
+		v += 459670000000
+		*t = Temperature(v/9*5 + v%9*5/9)
+	default:
+		v, err := parseSI(s, "°C", 9)
+		if err != nil {
+			return err
+		}
+		if v > maxInt64-int64(ZeroCelsius) {
+			return errors.New("physic: can't parse " + strconv.Quote(s) + ": value out of range")
+		}
+		*t = Temperature(v) + ZeroCelsius
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Temperature) MarshalText() ([]byte, error) {
+	return []byte(nanoAsExactString(int64(t-ZeroCelsius)) + "°C"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Temperature) UnmarshalText(text []byte) error {
+	return t.Set(string(text))
+}
+
 const (
 	NanoKelvin  Temperature = 1
 	MicroKelvin Temperature = 1000 * NanoKelvin
@@ -470,3 +805,131 @@ func microAsString(v int64) string {
 	}
 	return sign + strconv.Itoa(base) + "." + prefixZeros(3, frac) + unit
 }
+
+// nanoAsExactString is like nanoAsString() but keeps all the digits so the
+// value can be parsed back by parseSI() without loss. This is used by the
+// MarshalText() implementations, while String() is meant to be readable.
+func nanoAsExactString(v int64) string {
+	return exactString(v, []string{"n", "µ", "m", "", "k", "M", "G"})
+}
+
+// microAsExactString is like nanoAsExactString() for a value in micro unit.
+func microAsExactString(v int64) string {
+	return exactString(v, []string{"µ", "m", "", "k", "M", "G", "T"})
+}
+
+// exactString formats v with the largest of prefixes that keeps an integer
+// part. Each prefix is 1000 times the previous one, starting at the unit v is
+// stored in.
+func exactString(v int64, prefixes []string) string {
+	if v == 0 {
+		return "0"
+	}
+	u := uint64(v)
+	if v < 0 {
+		u = -u
+	}
+	shift := (len(strconv.FormatUint(u, 10)) - 1) / 3
+	return decimalString(v, 3*shift) + prefixes[shift]
+}
+
+// decimalString formats v divided by 10^exp, e.g. "-1.25", without trailing
+// zeros in the fraction. It is the reverse of parseDecimal().
+func decimalString(v int64, exp int) string {
+	sign := ""
+	u := uint64(v)
+	if v < 0 {
+		sign = "-"
+		u = -u
+	}
+	s := strconv.FormatUint(u, 10)
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	i, f := s[:len(s)-exp], strings.TrimRight(s[len(s)-exp:], "0")
+	if f == "" {
+		return sign + i
+	}
+	return sign + i + "." + f
+}
+
+const maxInt64 = 1<<63 - 1
+
+// siPrefixes is the S.I. prefixes supported by parseSI and their exponent.
+var siPrefixes = []struct {
+	prefix string
+	exp    int
+}{
+	{"n", -9},
+	{"µ", -6},
+	{"u", -6},
+	{"m", -3},
+	{"k", 3},
+	{"M", 6},
+	{"G", 9},
+	{"T", 12},
+}
+
+// parseSI parses a value in unit with an optional S.I. prefix, e.g. "1.5kHz",
+// and returns it as a multiple of 10^-exp of the unit. For example exp is 9
+// for a value stored in nano unit.
+//
+// A value without unit, e.g. "1500", is accepted as is. A S.I. prefix is only
+// accepted along the unit.
+func parseSI(s, unit string, exp int) (int64, error) {
+	n := s
+	if strings.HasSuffix(n, unit) {
+		n = n[:len(n)-len(unit)]
+		for _, p := range siPrefixes {
+			if strings.HasSuffix(n, p.prefix) {
+				n = n[:len(n)-len(p.prefix)]
+				exp += p.exp
+				break
+			}
+		}
+	}
+	v, err := parseDecimal(n, exp)
+	if err != nil {
+		return 0, errors.New("physic: can't parse " + strconv.Quote(s) + ": " + err.Error())
+	}
+	return v, nil
+}
+
+// parseDecimal parses a decimal number, e.g. "-1.25", and returns it
+// multiplied by 10^exp.
+//
+// It fails if the result is not an integer or doesn't fit an int64.
+func parseDecimal(s string, exp int) (int64, error) {
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign = s[:1]
+		s = s[1:]
+	}
+	i, f := s, ""
+	if j := strings.IndexByte(s, '.'); j != -1 {
+		i, f = s[:j], s[j+1:]
+	}
+	if len(i) == 0 && len(f) == 0 {
+		return 0, errors.New("not a number")
+	}
+	digits := i + f
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, errors.New("not a number")
+		}
+	}
+	for exp -= len(f); exp < 0; exp++ {
+		if digits[len(digits)-1] != '0' {
+			return 0, errors.New("too many decimals")
+		}
+		if digits = digits[:len(digits)-1]; len(digits) == 0 {
+			digits = "0"
+		}
+	}
+	digits += strings.Repeat("0", exp)
+	v, err := strconv.ParseInt(sign+digits, 10, 64)
+	if err != nil {
+		return 0, errors.New("value out of range")
+	}
+	return v, nil
+}
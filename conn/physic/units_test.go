@@ -6,7 +6,12 @@ package physic
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -265,6 +270,257 @@ func TestMicroAsString(t *testing.T) {
 	}
 }
 
+func TestSet(t *testing.T) {
+	var a Angle
+	var d Distance
+	var c ElectricCurrent
+	var p ElectricPotential
+	var r ElectricResistance
+	var f Force
+	var h Frequency
+	var m Mass
+	var pr Pressure
+	var rh RelativeHumidity
+	var s Speed
+	var te Temperature
+	data := []struct {
+		v        flagValue
+		in       string
+		expected string
+	}{
+		{&a, "12.5°", "12.500°"},
+		{&a, "-90", "-90.00°"},
+		{&a, "3.141592653rad", "180.0°"},
+		{&a, "1000mrad", "57.296°"},
+		{&d, "1.5km", "1.500km"},
+		{&d, "5m", "5m"},
+		{&d, "5mm", "5mm"},
+		{&d, "0", "0m"},
+		{&c, "20mA", "20mA"},
+		{&c, "-1.25A", "-1.250A"},
+		{&p, "3.3V", "3.300V"},
+		{&p, "12uV", "12µV"},
+		{&r, "4.7kΩ", "4.700kΩ"},
+		{&f, "9.807N", "9.807N"},
+		{&h, "400kHz", "400kHz"},
+		{&h, "1000000", "1MHz"},
+		{&h, "2.4GHz", "2.400GHz"},
+		{&h, "1THz", "1THz"},
+		{&h, "1µHz", "1µHz"},
+		{&m, "2.5kg", "2.500kg"},
+		{&pr, "101.325kPa", "101.325kPa"},
+		{&rh, "45.5%rH", "45.5%rH"},
+		{&rh, "90%", "90%rH"},
+		{&rh, "12", "12%rH"},
+		{&s, "340m/s", "340m/s"},
+		{&s, "447.04mm/s", "447.040mm/s"},
+		{&te, "25.5°C", "25.500°C"},
+		{&te, "-40°C", "-40°C"},
+		{&te, "20", "20°C"},
+		{&te, "273.15K", "0°C"},
+		{&te, "-40°F", "-40°C"},
+		{&te, "212°F", "100°C"},
+	}
+	for i, line := range data {
+		if err := line.v.Set(line.in); err != nil {
+			t.Fatalf("#%d: Set(%q) = %v", i, line.in, err)
+		}
+		if s := line.v.String(); s != line.expected {
+			t.Fatalf("#%d: Set(%q) = %s; expected %s", i, line.in, s, line.expected)
+		}
+	}
+}
+
+func TestSet_err(t *testing.T) {
+	var a Angle
+	var d Distance
+	var h Frequency
+	var rh RelativeHumidity
+	var te Temperature
+	data := []struct {
+		v  flagValue
+		in string
+	}{
+		{&a, "x°"},
+		{&a, "1.0000001°"},
+		{&a, "1000000000000°"},
+		{&a, "1.5nrad"},
+		{&d, ""},
+		{&d, "m"},
+		{&d, "."},
+		{&d, "1.2.3m"},
+		{&d, "1km2"},
+		{&d, "1.5nm"},
+		{&d, "10Gm"},
+		{&d, "1xm"},
+		{&h, "1.5µHz"},
+		{&h, "10THz"},
+		{&rh, "1.000001%rH"},
+		{&rh, "100000%rH"},
+		{&te, "1.5nK"},
+		{&te, "9223372036°C"},
+		{&te, "9223372036°F"},
+		{&te, "a°C"},
+		{&te, "aK"},
+		{&te, "a°F"},
+	}
+	for i, line := range data {
+		if err := line.v.Set(line.in); err == nil {
+			t.Fatalf("#%d: Set(%q) should have failed", i, line.in)
+		}
+	}
+}
+
+// TestSet_roundtrip confirms that parsing the output of String() returns the
+// same String() output.
+func TestSet_roundtrip(t *testing.T) {
+	values := []flagValue{
+		angle(Degree), angle(-Pi), angle(Radian), angle(100000000000 * Degree),
+		distance(Mile), distance(-Inch), distance(9 * MegaMetre),
+		current(MilliAmpere), current(-3 * NanoAmpere),
+		potential(3300 * MilliVolt), potential(-KiloVolt),
+		resistance(4700 * Ohm), resistance(MegaOhm),
+		force(EarthGravity), force(PoundForce),
+		frequency(Hertz), frequency(33333 * MilliHertz), frequency(9 * GigaHertz * 1000),
+		mass(PoundMass), mass(Slug),
+		pressure(101325 * Pascal), pressure(MicroPascal),
+		humidity(506000 * MicroRH), humidity(100 * PercentRH),
+		speed(LightSpeed), speed(MilePerHour), speed(KilometrePerHour),
+		temperature(ZeroCelsius), temperature(0), temperature(ZeroFahrenheit), temperature(ZeroCelsius + 36600*MilliCelsius),
+	}
+	for i, v := range values {
+		s := v.String()
+		if err := v.Set(s); err != nil {
+			t.Fatalf("#%d: Set(%q) = %v", i, s, err)
+		}
+		if s2 := v.String(); s2 != s {
+			t.Fatalf("#%d: Set(%q) = %s", i, s, s2)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	h := 400 * KiloHertz
+	b, err := h.MarshalText()
+	if err != nil || string(b) != "400kHz" {
+		t.Fatal(string(b), err)
+	}
+	if err := h.UnmarshalText([]byte("1.5MHz")); err != nil || h != 1500*KiloHertz {
+		t.Fatal(h, err)
+	}
+	if err := h.UnmarshalText([]byte("oops")); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestText_roundTrip(t *testing.T) {
+	data := []struct {
+		v        encoding.TextMarshaler
+		expected string
+	}{
+		{Angle(-1234567), "-1.234567mrad"},
+		{Distance(1234567), "1.234567mm"},
+		{Distance(-9223372036854775808), "-9.223372036854775808Gm"},
+		{ElectricCurrent(1000000001), "1.000000001A"},
+		{ElectricPotential(999), "999nV"},
+		{ElectricResistance(4700000000001), "4.700000000001kΩ"},
+		{Force(1), "1nN"},
+		{Frequency(1234567), "1.234567Hz"},
+		{Frequency(9223372036854775807), "9.223372036854775807THz"},
+		{Mass(1234567890123), "1.234567890123kg"},
+		{Pressure(101325000000001), "101.325000000001kPa"},
+		{RelativeHumidity(4567891), "45.67891%rH"},
+		{RelativeHumidity(-1), "-0.00001%rH"},
+		{Speed(1001), "1.001µm/s"},
+		{ZeroCelsius + 1234567, "1.234567m°C"},
+		{ZeroCelsius - 25*Celsius - 1, "-25.000000001°C"},
+	}
+	for i, line := range data {
+		b, err := line.v.MarshalText()
+		if err != nil || string(b) != line.expected {
+			t.Fatalf("#%d: %q != %q; %v", i, b, line.expected, err)
+		}
+		// Unmarshal in a new value of the same type.
+		p := reflect.New(reflect.TypeOf(line.v))
+		if err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText(b); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if v := p.Elem().Interface(); v != line.v {
+			t.Fatalf("#%d: %v != %v", i, v, line.v)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type config struct {
+		A  Angle
+		D  Distance
+		C  ElectricCurrent
+		P  ElectricPotential
+		R  ElectricResistance
+		F  Force
+		H  Frequency
+		M  Mass
+		Pr Pressure
+		RH RelativeHumidity
+		S  Speed
+		T  Temperature
+	}
+	c := config{
+		A:  90 * Degree,
+		D:  1500 * MilliMetre,
+		C:  20 * MilliAmpere,
+		P:  3300 * MilliVolt,
+		R:  4700 * Ohm,
+		F:  Newton,
+		H:  400 * KiloHertz,
+		M:  KiloGram,
+		Pr: KiloPascal,
+		RH: 45 * PercentRH,
+		S:  MetrePerSecond,
+		T:  ZeroCelsius + 25*Celsius,
+	}
+	b, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"A":"1.57079637rad","D":"1.5m","C":"20mA","P":"3.3V","R":"4.7kΩ","F":"1N","H":"400kHz","M":"1kg","Pr":"1kPa","RH":"45%rH","S":"1m/s","T":"25°C"}`
+	if string(b) != expected {
+		t.Fatal(string(b))
+	}
+	var c2 config
+	if err := json.Unmarshal(b, &c2); err != nil {
+		t.Fatal(err)
+	}
+	if c2 != c {
+		t.Fatalf("%#v != %#v", c2, c)
+	}
+	if err := json.Unmarshal([]byte(`{"H":"fast"}`), &c2); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestFlag(t *testing.T) {
+	f := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	h := MegaHertz
+	f.Var(&h, "hz", "speed")
+	te := ZeroCelsius
+	f.Var(&te, "t", "temperature")
+	if err := f.Parse([]string{"-hz", "400kHz", "-t", "25.5°C"}); err != nil {
+		t.Fatal(err)
+	}
+	if h != 400*KiloHertz {
+		t.Fatal(h)
+	}
+	if te != ZeroCelsius+25500*MilliCelsius {
+		t.Fatal(te)
+	}
+	if err := f.Parse([]string{"-hz", "fast"}); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
 func BenchmarkCelsiusString(b *testing.B) {
 	v := 10*Celsius + ZeroCelsius
 	buf := bytes.Buffer{}
@@ -291,3 +547,26 @@ func BenchmarkCelsiusFloatg(b *testing.B) {
 		buf.Reset()
 	}
 }
+
+// flagValue is implemented by all the units.
+type flagValue interface {
+	String() string
+	Set(s string) error
+}
+
+func angle(v Angle) *Angle                                { return &v }
+func distance(v Distance) *Distance                       { return &v }
+func current(v ElectricCurrent) *ElectricCurrent          { return &v }
+func potential(v ElectricPotential) *ElectricPotential    { return &v }
+func resistance(v ElectricResistance) *ElectricResistance { return &v }
+func force(v Force) *Force                                { return &v }
+func frequency(v Frequency) *Frequency                    { return &v }
+func mass(v Mass) *Mass                                   { return &v }
+func pressure(v Pressure) *Pressure                       { return &v }
+func humidity(v RelativeHumidity) *RelativeHumidity       { return &v }
+func speed(v Speed) *Speed                                { return &v }
+func temperature(v Temperature) *Temperature              { return &v }
+
+var _ flag.Value = new(Angle)
+var _ encoding.TextMarshaler = new(Angle)
+var _ encoding.TextUnmarshaler = new(Angle)
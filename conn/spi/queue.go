@@ -0,0 +1,149 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"sync"
+
+	"periph.io/x/periph/conn"
+)
+
+// AsyncConn is implemented by a Conn that can enqueue transactions, so the
+// caller can do other work while a transaction is in progress.
+type AsyncConn interface {
+	Conn
+	// TxAsync enqueues a transaction and returns immediately.
+	//
+	// The transaction can be larger than conn.Limits.MaxTxSize(), as with
+	// TxStream(). The transactions are done in the order they were enqueued,
+	// including the ones done with Tx() and TxPackets().
+	//
+	// The result is sent on the returned channel once the transaction
+	// completed. w and r must not be modified or read until then.
+	TxAsync(w, r []byte) <-chan error
+}
+
+// NewAsync returns c if it implements AsyncConn, otherwise it returns a Queue
+// over c.
+func NewAsync(c Conn) AsyncConn {
+	if a, ok := c.(AsyncConn); ok {
+		return a
+	}
+	return NewQueue(c)
+}
+
+// Queue implements AsyncConn over any Conn.
+//
+// The transactions are done by a worker goroutine, which is started on demand
+// and exits as soon as the queue is empty, so a Queue doesn't need to be
+// closed.
+type Queue struct {
+	c Conn
+
+	mu      sync.Mutex
+	jobs    []job
+	running bool
+}
+
+// NewQueue returns a Queue that does the transactions on c.
+//
+// c must not be used directly afterward, as it would not respect the order of
+// the transactions.
+func NewQueue(c Conn) *Queue {
+	return &Queue{c: c}
+}
+
+func (q *Queue) String() string {
+	return q.c.String()
+}
+
+// Tx implements conn.Conn.
+//
+// It waits for the transactions enqueued before to complete.
+func (q *Queue) Tx(w, r []byte) error {
+	return <-q.enqueue(func() error {
+		return q.c.Tx(w, r)
+	})
+}
+
+// TxPackets implements Conn.
+//
+// It waits for the transactions enqueued before to complete.
+func (q *Queue) TxPackets(p []Packet) error {
+	return <-q.enqueue(func() error {
+		return q.c.TxPackets(p)
+	})
+}
+
+// TxStream implements Streamer.
+//
+// It waits for the transactions enqueued before to complete.
+func (q *Queue) TxStream(w, r []byte) error {
+	return <-q.TxAsync(w, r)
+}
+
+// TxAsync implements AsyncConn.
+func (q *Queue) TxAsync(w, r []byte) <-chan error {
+	return q.enqueue(func() error {
+		return TxStream(q.c, w, r)
+	})
+}
+
+// Duplex implements conn.Conn.
+func (q *Queue) Duplex() conn.Duplex {
+	return q.c.Duplex()
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// Returns 0 if the Conn doesn't implement conn.Limits.
+func (q *Queue) MaxTxSize() int {
+	if l, ok := q.c.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+//
+
+// job is a transaction in a Queue.
+type job struct {
+	f    func() error
+	done chan error
+}
+
+// enqueue adds f to the queue and starts the worker if needed.
+func (q *Queue) enqueue(f func() error) <-chan error {
+	j := job{f: f, done: make(chan error, 1)}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, j)
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+	return j.done
+}
+
+// run does the transactions until the queue is empty.
+func (q *Queue) run() {
+	for {
+		q.mu.Lock()
+		if len(q.jobs) == 0 {
+			q.running = false
+			q.mu.Unlock()
+			return
+		}
+		j := q.jobs[0]
+		q.jobs[0] = job{}
+		q.jobs = q.jobs[1:]
+		q.mu.Unlock()
+		j.done <- j.f()
+	}
+}
+
+var _ AsyncConn = &Queue{}
+var _ Streamer = &Queue{}
+var _ conn.Limits = &Queue{}
@@ -0,0 +1,96 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestQueue(t *testing.T) {
+	r := &spitest.Record{Port: &spitest.Loopback{}, MaxTxSize: 2}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := spi.NewAsync(c)
+	if s := q.String(); s != "record" {
+		t.Fatal(s)
+	}
+	if d := q.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+	if l := q.(conn.Limits).MaxTxSize(); l != 2 {
+		t.Fatal(l)
+	}
+	if spi.NewAsync(q) != q {
+		t.Fatal("expected the same AsyncConn")
+	}
+	// Enqueue multiple transactions; they must be done in order.
+	var pending []<-chan error
+	for i := byte(0); i < 10; i++ {
+		pending = append(pending, q.TxAsync([]byte{i}, nil))
+	}
+	read := make([]byte, 3)
+	a := q.TxAsync([]byte{10, 11, 12}, read)
+	if err := q.TxPackets([]spi.Packet{{W: []byte{13}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pending {
+		if err := <-p; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := <-a; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, []byte{10, 11, 12}) {
+		t.Fatal(read)
+	}
+	if err := q.Tx([]byte{14}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.(spi.Streamer).TxStream([]byte{15}, nil); err != nil {
+		t.Fatal(err)
+	}
+	var expected []conntest.IO
+	for i := byte(0); i < 10; i++ {
+		expected = append(expected, conntest.IO{W: []byte{i}})
+	}
+	expected = append(expected,
+		conntest.IO{W: []byte{10, 11}, R: []byte{10, 11}},
+		conntest.IO{W: []byte{12}, R: []byte{12}},
+		conntest.IO{W: []byte{13}},
+		conntest.IO{W: []byte{14}},
+		conntest.IO{W: []byte{15}})
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestQueue_err(t *testing.T) {
+	r := &spitest.Record{}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := spi.NewQueue(c)
+	if err := <-q.TxAsync(nil, []byte{0}); err == nil {
+		t.Fatal("Port is nil")
+	}
+	if err := q.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if q.MaxTxSize() != 0 {
+		t.Fatal(q.MaxTxSize())
+	}
+}
@@ -107,8 +107,9 @@ type Packet struct {
 	// clock cycle before the clock starts again for the next packet. This seems
 	// to be independent of the port clock speed but this wasn't fully verified.
 	//
-	// It cannot be expected that the driver will correctly keep CS asserted even
-	// if KeepCS:true on the last packet.
+	// KeepCS:true on the last packet requests the driver to keep CS asserted
+	// until the next transaction, which is how TxStream() keeps CS asserted
+	// across chunks. Not all drivers support it.
 	//
 	// KeepCS is ignored when NoCS was specified to Connect.
 	KeepCS bool
@@ -125,8 +126,9 @@ type Conn interface {
 	// The maximum number of bytes can be limited depending on the driver. Query
 	// conn.Limits.MaxTxSize() can be used to determine the limit.
 	//
-	// If the last packet has KeepCS:true, the behavior is undefined. The CS line
-	// will likely not stay asserted. This is a driver limitation.
+	// If the last packet has KeepCS:true, the CS line stays asserted until the
+	// next transaction if the driver supports it. Otherwise the CS line will
+	// likely not stay asserted. This is a driver limitation.
 	TxPackets(p []Packet) error
 }
 
@@ -170,6 +172,18 @@ type PortCloser interface {
 	LimitSpeed(f physic.Frequency) error
 }
 
+// Configurer is implemented by a Conn that supports changing its
+// communication parameters after Port.Connect().
+//
+// It is used to share a port between multiple devices that each need a
+// different configuration.
+type Configurer interface {
+	// Configure changes the parameters that were specified to Port.Connect().
+	//
+	// The arguments have the same meaning than for Port.Connect().
+	Configure(f physic.Frequency, mode Mode, bits int) error
+}
+
 // Pins defines the pins that a SPI port interconnect is using on the host.
 //
 // It is expected that a implementer of ConnCloser or Conn also implement Pins
@@ -0,0 +1,53 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spibus_test
+
+import (
+	"log"
+
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spibus"
+	"periph.io/x/periph/conn/spi/spireg"
+	"periph.io/x/periph/host"
+)
+
+func Example() {
+	// Make sure periph is initialized.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Use spireg SPI port registry to find the first available SPI port.
+	p, err := spireg.Open("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	b := spibus.New(p)
+	defer b.Close()
+
+	// Each device has its own chip select line.
+	for _, name := range []string{"GPIO5", "GPIO6"} {
+		cs := gpioreg.ByName(name)
+		if cs == nil {
+			log.Fatalf("failed to find %s", name)
+		}
+		d, err := b.NewPort(cs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Pass d to a device driver, like a spi.Port.
+		c, err := d.Connect(physic.MegaHertz, spi.Mode3, 8)
+		if err != nil {
+			log.Fatal(err)
+		}
+		read := make([]byte, 2)
+		if err := c.Tx([]byte{0x10, 0x00}, read); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: %#v", d, read)
+	}
+}
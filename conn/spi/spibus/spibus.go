@@ -0,0 +1,325 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package spibus shares one SPI port between multiple devices, each selected
+// with its own GPIO used as a chip select line.
+//
+// This is useful when more devices are connected than the host provides
+// hardware chip select lines for. The port is connected with spi.NoCS and
+// the chip select line of each device is asserted in software around each
+// transaction.
+//
+// Transactions from the devices are serialized. When a device uses a
+// different speed, mode or number of bits per word than the previous one, the
+// connection is reconfigured through spi.Configurer before the transaction.
+package spibus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+// Bus is a SPI port shared by multiple devices.
+type Bus struct {
+	mu   sync.Mutex
+	port spi.Port
+	c    spi.Conn
+	cfg  config
+}
+
+// New returns a Bus that shares p.
+//
+// p must not be connected yet. It is connected on the first transaction with
+// the parameters of the device doing it.
+func New(p spi.Port) *Bus {
+	return &Bus{port: p}
+}
+
+func (b *Bus) String() string {
+	return b.port.String()
+}
+
+// Close closes the port if it implements io.Closer.
+func (b *Bus) Close() error {
+	if c, ok := b.port.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// NewPort returns a spi.PortCloser for the device selected by cs.
+//
+// cs is active low. It is set high, that is deasserted, immediately.
+func (b *Bus) NewPort(cs gpio.PinOut) (*Port, error) {
+	if cs == nil || cs == gpio.INVALID {
+		return nil, errors.New("spibus: cs is required")
+	}
+	if err := cs.Out(gpio.High); err != nil {
+		return nil, err
+	}
+	return &Port{b: b, cs: cs}, nil
+}
+
+// Port is a device on a Bus.
+//
+// It implements spi.PortCloser.
+type Port struct {
+	b  *Bus
+	cs gpio.PinOut
+
+	mu        sync.Mutex
+	limit     physic.Frequency
+	connected bool
+}
+
+func (p *Port) String() string {
+	return fmt.Sprintf("%s/%s", p.b, p.cs)
+}
+
+// Close implements spi.PortCloser.
+//
+// It doesn't close the Bus.
+func (p *Port) Close() error {
+	return nil
+}
+
+// LimitSpeed implements spi.PortCloser.
+func (p *Port) LimitSpeed(f physic.Frequency) error {
+	if f <= 0 {
+		return fmt.Errorf("spibus: invalid speed %s", f)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limit = f
+	return nil
+}
+
+// Connect implements spi.PortCloser.
+//
+// When mode contains spi.NoCS, the chip select line is not touched.
+func (p *Port) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
+	if bits < 1 || bits >= 256 {
+		return nil, fmt.Errorf("spibus: invalid bits %d", bits)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.connected {
+		return nil, errors.New("spibus: Connect() can only be called exactly once")
+	}
+	p.connected = true
+	if p.limit != 0 && (f == 0 || p.limit < f) {
+		f = p.limit
+	}
+	return &Conn{p: p, cfg: config{f: f, mode: mode | spi.NoCS, bits: bits}, cs: mode&spi.NoCS == 0}, nil
+}
+
+// CLK implements spi.Pins.
+func (p *Port) CLK() gpio.PinOut {
+	if pins, ok := p.b.port.(spi.Pins); ok {
+		return pins.CLK()
+	}
+	return gpio.INVALID
+}
+
+// MOSI implements spi.Pins.
+func (p *Port) MOSI() gpio.PinOut {
+	if pins, ok := p.b.port.(spi.Pins); ok {
+		return pins.MOSI()
+	}
+	return gpio.INVALID
+}
+
+// MISO implements spi.Pins.
+func (p *Port) MISO() gpio.PinIn {
+	if pins, ok := p.b.port.(spi.Pins); ok {
+		return pins.MISO()
+	}
+	return gpio.INVALID
+}
+
+// CS implements spi.Pins.
+func (p *Port) CS() gpio.PinOut {
+	return p.cs
+}
+
+// Conn is a connection to a device on a Bus.
+//
+// It implements spi.Conn.
+type Conn struct {
+	p   *Port
+	cfg config
+	cs  bool
+}
+
+func (c *Conn) String() string {
+	return c.p.String()
+}
+
+// Tx implements spi.Conn.
+func (c *Conn) Tx(w, r []byte) error {
+	return c.run(func(bc spi.Conn) error {
+		return bc.Tx(w, r)
+	})
+}
+
+// TxPackets implements spi.Conn.
+//
+// The chip select line is deasserted between two packets unless KeepCS is
+// set. It is always deasserted after the last packet.
+func (c *Conn) TxPackets(p []spi.Packet) error {
+	return c.run(func(bc spi.Conn) error {
+		start := 0
+		for i := range p {
+			if p[i].KeepCS && i != len(p)-1 {
+				continue
+			}
+			if err := bc.TxPackets(p[start : i+1]); err != nil {
+				return err
+			}
+			if i != len(p)-1 {
+				if err := c.toggle(); err != nil {
+					return err
+				}
+			}
+			start = i + 1
+		}
+		return nil
+	})
+}
+
+// TxStream implements spi.Streamer.
+//
+// The chip select line is asserted for the whole transaction, so it is not
+// affected when spi.TxStream() splits the transaction in chunks on the port.
+func (c *Conn) TxStream(w, r []byte) error {
+	return c.run(func(bc spi.Conn) error {
+		return spi.TxStream(bc, w, r)
+	})
+}
+
+// Duplex implements conn.Conn.
+func (c *Conn) Duplex() conn.Duplex {
+	if c.cfg.mode&spi.HalfDuplex != 0 {
+		return conn.Half
+	}
+	return conn.Full
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// Returns 0 if the port's connection doesn't implement conn.Limits or if no
+// transaction happened yet.
+func (c *Conn) MaxTxSize() int {
+	c.p.b.mu.Lock()
+	defer c.p.b.mu.Unlock()
+	if l, ok := c.p.b.c.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+// CLK implements spi.Pins.
+func (c *Conn) CLK() gpio.PinOut {
+	return c.p.CLK()
+}
+
+// MOSI implements spi.Pins.
+func (c *Conn) MOSI() gpio.PinOut {
+	return c.p.MOSI()
+}
+
+// MISO implements spi.Pins.
+func (c *Conn) MISO() gpio.PinIn {
+	return c.p.MISO()
+}
+
+// CS implements spi.Pins.
+func (c *Conn) CS() gpio.PinOut {
+	return c.p.CS()
+}
+
+//
+
+// config is the parameters of a connection.
+type config struct {
+	f    physic.Frequency
+	mode spi.Mode
+	bits int
+}
+
+// run selects the device, calls tx with the port's connection then deselects
+// the device.
+func (c *Conn) run(tx func(bc spi.Conn) error) error {
+	b := c.p.b
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.apply(c.cfg); err != nil {
+		return err
+	}
+	if err := c.assert(gpio.Low); err != nil {
+		return err
+	}
+	err := tx(b.c)
+	if err2 := c.assert(gpio.High); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// toggle deasserts then asserts the chip select line between two packets.
+func (c *Conn) toggle() error {
+	if err := c.assert(gpio.High); err != nil {
+		return err
+	}
+	return c.assert(gpio.Low)
+}
+
+// assert sets the chip select line to l, unless NoCS was specified.
+func (c *Conn) assert(l gpio.Level) error {
+	if !c.cs {
+		return nil
+	}
+	return c.p.cs.Out(l)
+}
+
+// apply connects or reconfigures the port's connection to cfg.
+//
+// mu must be held.
+func (b *Bus) apply(cfg config) error {
+	if b.c == nil {
+		c, err := b.port.Connect(cfg.f, cfg.mode, cfg.bits)
+		if err != nil {
+			return err
+		}
+		b.c = c
+		b.cfg = cfg
+		return nil
+	}
+	if b.cfg == cfg {
+		return nil
+	}
+	cf, ok := b.c.(spi.Configurer)
+	if !ok {
+		return errors.New("spibus: " + b.port.String() + " doesn't implement spi.Configurer; all devices must use the same parameters")
+	}
+	if err := cf.Configure(cfg.f, cfg.mode, cfg.bits); err != nil {
+		return err
+	}
+	b.cfg = cfg
+	return nil
+}
+
+var _ spi.PortCloser = &Port{}
+var _ spi.Pins = &Port{}
+var _ spi.Conn = &Conn{}
+var _ spi.Streamer = &Conn{}
+var _ conn.Limits = &Conn{}
+var _ spi.Pins = &Conn{}
@@ -0,0 +1,259 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spibus
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestBus_Tx(t *testing.T) {
+	r := &spitest.Record{Port: &spitest.Loopback{}}
+	b := New(r)
+	a := newConn(t, b, r, "A", physic.MegaHertz, spi.Mode0)
+	c := newConn(t, b, r, "B", physic.MegaHertz, spi.Mode0)
+	buf := make([]byte, 2)
+	if err := a.Tx([]byte{1, 2}, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf, []byte{1, 2}) {
+		t.Fatal(buf)
+	}
+	if err := c.Tx([]byte{3}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := []conntest.IO{{W: []byte{1, 2}, R: []byte{1, 2}}, {W: []byte{3}}}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+	events := []spitest.CSEvent{
+		{Pin: "A", L: gpio.Low, Op: 0},
+		{Pin: "A", L: gpio.High, Op: 1},
+		{Pin: "B", L: gpio.Low, Op: 1},
+		{Pin: "B", L: gpio.High, Op: 2},
+	}
+	if !reflect.DeepEqual(r.CSEvents, events) {
+		t.Fatal(r.CSEvents)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBus_TxPackets(t *testing.T) {
+	r := &spitest.Record{Port: &spitest.Loopback{}}
+	b := New(r)
+	a := newConn(t, b, r, "A", physic.MegaHertz, spi.Mode0)
+	p := []spi.Packet{
+		{W: []byte{1}, KeepCS: true},
+		{W: []byte{2}},
+		{W: []byte{3}, KeepCS: true},
+	}
+	if err := a.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 3 {
+		t.Fatal(r.Ops)
+	}
+	// CS stays asserted between the first two packets.
+	events := []spitest.CSEvent{
+		{Pin: "A", L: gpio.Low, Op: 0},
+		{Pin: "A", L: gpio.High, Op: 2},
+		{Pin: "A", L: gpio.Low, Op: 2},
+		{Pin: "A", L: gpio.High, Op: 3},
+	}
+	if !reflect.DeepEqual(r.CSEvents, events) {
+		t.Fatal(r.CSEvents)
+	}
+}
+
+func TestBus_TxStream(t *testing.T) {
+	r := &spitest.Record{Port: &spitest.Loopback{}, MaxTxSize: 2}
+	b := New(r)
+	a := newConn(t, b, r, "A", physic.MegaHertz, spi.Mode0)
+	if err := spi.TxStream(a, []byte{1, 2, 3, 4, 5}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 3 {
+		t.Fatal(r.Ops)
+	}
+	// CS stays asserted for the whole stream.
+	events := []spitest.CSEvent{
+		{Pin: "A", L: gpio.Low, Op: 0},
+		{Pin: "A", L: gpio.High, Op: 3},
+	}
+	if !reflect.DeepEqual(r.CSEvents, events) {
+		t.Fatal(r.CSEvents)
+	}
+}
+
+func TestBus_Configure(t *testing.T) {
+	f := &fakePort{}
+	b := New(f)
+	a := newConn(t, b, nil, "A", physic.MegaHertz, spi.Mode0)
+	c := newConn(t, b, nil, "B", 10*physic.MegaHertz, spi.Mode3|spi.HalfDuplex)
+	for _, x := range []spi.Conn{a, a, c, a} {
+		if err := x.Tx([]byte{1}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{
+		"Connect(1MHz, Mode0|NoCS, 8)",
+		"Configure(10MHz, Mode3|HalfDuplex|NoCS, 8)",
+		"Configure(1MHz, Mode0|NoCS, 8)",
+	}
+	if !reflect.DeepEqual(f.calls, expected) {
+		t.Fatal(f.calls)
+	}
+	if d := c.Duplex(); d != conn.Half {
+		t.Fatal(d)
+	}
+	if d := a.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+}
+
+func TestBus_Configure_unsupported(t *testing.T) {
+	r := &spitest.Record{}
+	b := New(r)
+	a := newConn(t, b, r, "A", physic.MegaHertz, spi.Mode0)
+	c := newConn(t, b, r, "B", physic.MegaHertz, spi.Mode3)
+	if err := a.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{1}, nil); err == nil {
+		t.Fatal("record doesn't implement spi.Configurer")
+	}
+	if len(r.Ops) != 1 {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestPort(t *testing.T) {
+	b := New(&spitest.Record{})
+	if _, err := b.NewPort(gpio.INVALID); err == nil {
+		t.Fatal("cs is required")
+	}
+	cs := &gpiotest.Pin{N: "CS"}
+	p, err := b.NewPort(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Read() != gpio.High {
+		t.Fatal("cs must be deasserted")
+	}
+	if s := p.String(); s != "record/CS(0)" {
+		t.Fatal(s)
+	}
+	if p.LimitSpeed(0) == nil {
+		t.Fatal("invalid speed")
+	}
+	if err := p.LimitSpeed(physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Connect(physic.MegaHertz, spi.Mode0, 0); err == nil {
+		t.Fatal("invalid bits")
+	}
+	c, err := p.Connect(physic.MegaHertz, spi.Mode0|spi.NoCS, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Connect(physic.MegaHertz, spi.Mode0, 8); err == nil {
+		t.Fatal("Connect() can only be called once")
+	}
+	if c.(*Conn).cfg.f != physic.KiloHertz {
+		t.Fatal(c.(*Conn).cfg.f)
+	}
+	cs.Out(gpio.Low)
+	if err := c.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cs.Read() != gpio.Low {
+		t.Fatal("cs must not be touched with NoCS")
+	}
+	if c.(spi.Pins).CS() != cs || c.(spi.Pins).CLK() != gpio.INVALID || c.(spi.Pins).MOSI() != gpio.INVALID || c.(spi.Pins).MISO() != gpio.INVALID {
+		t.Fatal("unexpected pins")
+	}
+	if s := c.String(); s != "record/CS(0)" {
+		t.Fatal(s)
+	}
+	if l := c.(conn.Limits).MaxTxSize(); l != 0 {
+		t.Fatal(l)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+func newConn(t *testing.T, b *Bus, r *spitest.Record, name string, f physic.Frequency, mode spi.Mode) spi.Conn {
+	var cs gpio.PinOut = &gpiotest.Pin{N: name, L: gpio.High}
+	if r != nil {
+		cs = &spitest.CSPin{Pin: gpiotest.Pin{N: name, L: gpio.High}, R: r}
+	}
+	p, err := b.NewPort(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.Connect(f, mode, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// fakePort records the calls to Connect and Configure.
+type fakePort struct {
+	calls []string
+}
+
+func (f *fakePort) String() string {
+	return "fake"
+}
+
+func (f *fakePort) Connect(freq physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
+	f.calls = append(f.calls, fmtCall("Connect", freq, mode, bits))
+	return &fakeConn{f}, nil
+}
+
+type fakeConn struct {
+	f *fakePort
+}
+
+func (f *fakeConn) String() string {
+	return "fake"
+}
+
+func (f *fakeConn) Tx(w, r []byte) error {
+	return nil
+}
+
+func (f *fakeConn) TxPackets(p []spi.Packet) error {
+	return nil
+}
+
+func (f *fakeConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (f *fakeConn) Configure(freq physic.Frequency, mode spi.Mode, bits int) error {
+	f.f.calls = append(f.f.calls, fmtCall("Configure", freq, mode, bits))
+	return nil
+}
+
+func fmtCall(name string, f physic.Frequency, mode spi.Mode, bits int) string {
+	return name + "(" + f.String() + ", " + mode.String() + ", " + strconv.Itoa(bits) + ")"
+}
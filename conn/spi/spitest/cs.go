@@ -0,0 +1,51 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"fmt"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+// CSEvent is a transition of a chip select line.
+type CSEvent struct {
+	// Pin is the name of the pin.
+	Pin string
+	// L is the new level of the pin.
+	L gpio.Level
+	// Op is the number of operations that were recorded before the transition,
+	// that is the index in Record.Ops of the next operation.
+	Op int
+}
+
+func (c *CSEvent) String() string {
+	return fmt.Sprintf("%s=%s@%d", c.Pin, c.L, c.Op)
+}
+
+// CSPin implements gpio.PinIO and records its transitions in
+// R.CSEvents.
+//
+// It is meant to be used as a chip select line driven in software, so the
+// transitions can be checked relative to the operations recorded in R.Ops.
+type CSPin struct {
+	gpiotest.Pin
+	R *Record
+}
+
+// Out implements gpio.PinOut.
+//
+// Only changes of level are recorded.
+func (c *CSPin) Out(l gpio.Level) error {
+	if c.Pin.Read() != l {
+		c.R.Lock()
+		c.R.CSEvents = append(c.R.CSEvents, CSEvent{Pin: c.N, L: l, Op: len(c.R.Ops)})
+		c.R.Unlock()
+	}
+	return c.Pin.Out(l)
+}
+
+var _ gpio.PinIO = &CSPin{}
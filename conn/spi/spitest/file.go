@@ -0,0 +1,67 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/spi"
+)
+
+// Save writes the recorded operations to w in the format described at
+// conntest.FileHeader.
+//
+// The connection parameters passed to Connect() are saved as metadata.
+//
+// The file can be loaded back with Playback.Load().
+func (r *Record) Save(w io.Writer) error {
+	r.Lock()
+	defer r.Unlock()
+	h := &conntest.FileHeader{Kind: "spi", Meta: map[string]string{}}
+	if r.Port != nil {
+		h.Bus = r.Port.String()
+	}
+	if r.Initialized {
+		h.Meta["freq"] = r.f.String()
+		h.Meta["mode"] = r.mode.String()
+		h.Meta["bits"] = strconv.Itoa(r.bits)
+		if r.Port != nil {
+			d := conn.Full
+			if r.mode&spi.HalfDuplex != 0 {
+				d = conn.Half
+			}
+			h.Meta["duplex"] = d.String()
+		}
+	}
+	return conntest.SaveIO(w, h, r.Ops)
+}
+
+// SaveFile writes the recorded operations to the file path.
+func (r *Record) SaveFile(path string) error {
+	return conntest.SaveFile(path, r.Save)
+}
+
+// Load reads the operations saved by Record.Save() from r.
+//
+// Ops and D are replaced and Count is reset.
+func (p *Playback) Load(r io.Reader) error {
+	h, ops, err := conntest.LoadIO(r)
+	if err != nil {
+		return err
+	}
+	if h.Kind != "spi" {
+		return fmt.Errorf("spitest: expected a spi recording, got %q", h.Kind)
+	}
+	p.Lock()
+	defer p.Unlock()
+	p.Ops = ops
+	p.D = conntest.ParseDuplex(h.Meta["duplex"])
+	p.Count = 0
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+func TestRecord_Save_Load(t *testing.T) {
+	r := Record{Port: &Playback{Playback: conntest.Playback{Ops: []conntest.IO{{W: []byte{10}, R: []byte{12}}}}}}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{10}, make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	buf := bytes.Buffer{}
+	if err := r.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("%q", lines)
+	}
+	if !strings.HasPrefix(lines[0], `{"version":1,"kind":"spi","bus":"playback","time":"`) {
+		t.Fatal(lines[0])
+	}
+	if !strings.HasSuffix(lines[0], `"meta":{"bits":"8","duplex":"Full","freq":"1MHz","mode":"Mode3"}}`) {
+		t.Fatal(lines[0])
+	}
+	if lines[1] != `{"w":"0a","r":"0c"}` {
+		t.Fatal(lines[1])
+	}
+
+	p := Playback{}
+	if err := p.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Ops, r.Ops) {
+		t.Fatalf("%#v != %#v", p.Ops, r.Ops)
+	}
+	if p.D != conn.Full {
+		t.Fatal(p.D)
+	}
+	if err := p.Load(strings.NewReader(`{"version":1,"kind":"conn"}`)); err == nil {
+		t.Fatal("expected kind error")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+// Loopback implements spi.PortCloser and spi.TargetPort by connecting the
+// controller side of the port directly to a target emulated in memory.
+//
+// When no target is listening, the data read on MISO is the data sent on
+// MOSI, as if the two lines were wired together.
+type Loopback struct {
+	sync.Mutex
+	Initialized bool
+
+	h    spi.TargetHandler
+	mode spi.Mode
+	bits int
+}
+
+func (l *Loopback) String() string {
+	return "loopback"
+}
+
+// Close implements spi.PortCloser.
+func (l *Loopback) Close() error {
+	return nil
+}
+
+// LimitSpeed implements spi.PortCloser.
+func (l *Loopback) LimitSpeed(f physic.Frequency) error {
+	return nil
+}
+
+// Connect implements spi.PortCloser.
+func (l *Loopback) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
+	l.Lock()
+	defer l.Unlock()
+	if l.Initialized {
+		return nil, conntest.Errorf("spitest: Connect cannot be called twice")
+	}
+	l.Initialized = true
+	return &loopbackConn{l: l, mode: mode, bits: bits}, nil
+}
+
+// ListenTarget implements spi.TargetPort.
+func (l *Loopback) ListenTarget(mode spi.Mode, bits int, h spi.TargetHandler) (conn.Resource, error) {
+	if h == nil {
+		return nil, conntest.Errorf("spitest: handler must not be nil")
+	}
+	l.Lock()
+	defer l.Unlock()
+	if l.h != nil {
+		return nil, conntest.Errorf("spitest: a target is already listening")
+	}
+	l.h = h
+	l.mode = mode
+	l.bits = bits
+	return &loopbackTarget{l: l, h: h}, nil
+}
+
+//
+
+type loopbackConn struct {
+	l    *Loopback
+	mode spi.Mode
+	bits int
+}
+
+func (c *loopbackConn) String() string {
+	return c.l.String()
+}
+
+func (c *loopbackConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (c *loopbackConn) Tx(w, r []byte) error {
+	if len(w) != 0 && len(r) != 0 && len(w) != len(r) {
+		return conntest.Errorf("spitest: both buffers must have the same size")
+	}
+	n := len(w)
+	if n == 0 {
+		n = len(r)
+	}
+	if n == 0 {
+		return nil
+	}
+	if len(w) == 0 {
+		w = make([]byte, n)
+	}
+	buf := r
+	if len(buf) == 0 {
+		buf = make([]byte, n)
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.l.h == nil {
+		copy(buf, w)
+		return nil
+	}
+	if (c.l.mode^c.mode)&^spi.NoCS != 0 || c.l.bits != c.bits {
+		return conntest.Errorf("spitest: controller and target configuration mismatch")
+	}
+	c.l.h.OnTx(w, buf)
+	return nil
+}
+
+func (c *loopbackConn) TxPackets(p []spi.Packet) error {
+	for _, packet := range p {
+		if err := c.Tx(packet.W, packet.R); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Configure implements spi.Configurer.
+func (c *loopbackConn) Configure(f physic.Frequency, mode spi.Mode, bits int) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.mode = mode
+	c.bits = bits
+	return nil
+}
+
+// loopbackTarget is returned by Loopback.ListenTarget.
+type loopbackTarget struct {
+	l *Loopback
+	h spi.TargetHandler
+}
+
+func (t *loopbackTarget) String() string {
+	return t.l.String() + "-target"
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the target from responding.
+func (t *loopbackTarget) Halt() error {
+	t.l.Lock()
+	defer t.l.Unlock()
+	if t.l.h == t.h {
+		t.l.h = nil
+	}
+	return nil
+}
+
+var _ spi.PortCloser = &Loopback{}
+var _ spi.TargetPort = &Loopback{}
+var _ spi.Conn = &loopbackConn{}
+var _ spi.Configurer = &loopbackConn{}
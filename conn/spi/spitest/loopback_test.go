@@ -0,0 +1,123 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spitest
+
+import (
+	"bytes"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+func TestLoopback(t *testing.T) {
+	l := Loopback{}
+	if s := l.String(); s != "loopback" {
+		t.Fatal(s)
+	}
+	if err := l.LimitSpeed(physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	c, err := l.Connect(physic.MegaHertz, spi.Mode3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Connect(physic.MegaHertz, spi.Mode3, 8); err == nil {
+		t.Fatal("Connect cannot be called twice")
+	}
+	if s := c.String(); s != "loopback" {
+		t.Fatal(s)
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+
+	// Without target, MISO is wired to MOSI.
+	r := make([]byte, 2)
+	if err := c.Tx([]byte{1, 2}, r); err != nil || !bytes.Equal(r, []byte{1, 2}) {
+		t.Fatal(r, err)
+	}
+	if err := c.Tx(nil, r); err != nil || !bytes.Equal(r, []byte{0, 0}) {
+		t.Fatal(r, err)
+	}
+	if err := c.Tx(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{1}, r); err == nil {
+		t.Fatal("different sizes")
+	}
+
+	h := inverter{}
+	tgt, err := l.ListenTarget(spi.Mode3, 8, &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tgt.String(); s != "loopback-target" {
+		t.Fatal(s)
+	}
+	if _, err := l.ListenTarget(spi.Mode3, 8, &h); err == nil {
+		t.Fatal("already listening")
+	}
+	if err := c.Tx([]byte{1, 2}, r); err != nil || !bytes.Equal(r, []byte{0xFE, 0xFD}) {
+		t.Fatal(r, err)
+	}
+	p := []spi.Packet{{W: []byte{0x0F}}, {W: []byte{0xF0}, R: r[:1]}}
+	if err := c.TxPackets(p); err != nil || r[0] != 0x0F {
+		t.Fatal(r, err)
+	}
+	if h.count != 3 {
+		t.Fatal(h.count)
+	}
+	if err := tgt.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{1, 2}, r); err != nil || !bytes.Equal(r, []byte{1, 2}) {
+		t.Fatal(r, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoopback_err(t *testing.T) {
+	l := Loopback{}
+	if _, err := l.ListenTarget(spi.Mode0, 8, nil); err == nil {
+		t.Fatal("nil handler")
+	}
+	if _, err := l.ListenTarget(spi.Mode0, 8, &inverter{}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := l.Connect(physic.MegaHertz, spi.Mode3, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Tx([]byte{1}, nil) == nil {
+		t.Fatal("mode mismatch")
+	}
+	if c.TxPackets([]spi.Packet{{W: []byte{1}}}) == nil {
+		t.Fatal("mode mismatch")
+	}
+	if err := c.(spi.Configurer).Configure(physic.MegaHertz, spi.Mode0|spi.NoCS, 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Tx([]byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+
+// inverter replies with the inverted data of the transaction.
+type inverter struct {
+	count int
+}
+
+func (i *inverter) OnTx(w, r []byte) {
+	i.count++
+	for j := range w {
+		r[j] = ^w[j]
+	}
+}
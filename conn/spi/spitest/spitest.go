@@ -83,6 +83,16 @@ type Record struct {
 	Port        spi.PortCloser // Port can be nil if only writes are being recorded.
 	Ops         []conntest.IO
 	Initialized bool
+	// CSEvents is the chip select transitions recorded by CSPin.
+	CSEvents []CSEvent
+	// MaxTxSize is returned by conn.Limits.MaxTxSize() on the connection if
+	// not zero. Otherwise, the value of the Port's connection is returned.
+	MaxTxSize int
+
+	// Connection parameters, saved by Save().
+	f    physic.Frequency
+	mode spi.Mode
+	bits int
 }
 
 func (r *Record) String() string {
@@ -113,6 +123,9 @@ func (r *Record) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn,
 		return nil, conntest.Errorf("spitest: Connect cannot be called twice")
 	}
 	r.Initialized = true
+	r.f = f
+	r.mode = mode
+	r.bits = bits
 	if r.Port != nil {
 		c, err := r.Port.Connect(f, mode, bits)
 		if err != nil {
@@ -180,6 +193,40 @@ func (r *Record) txInternal(c spi.Conn, w, read []byte) error {
 	return nil
 }
 
+func (r *Record) txPacketsInternal(c spi.Conn, p []spi.Packet) error {
+	if len(p) == 0 {
+		return conntest.Errorf("spitest: empty packets")
+	}
+	ops := make([]conntest.IO, len(p))
+	for i := range p {
+		if len(p[i].W) != 0 {
+			ops[i].W = make([]byte, len(p[i].W))
+			copy(ops[i].W, p[i].W)
+		}
+	}
+	r.Lock()
+	defer r.Unlock()
+	if r.Port == nil {
+		for i := range p {
+			if len(p[i].R) != 0 {
+				return conntest.Errorf("spitest: read unsupported when no port is connected")
+			}
+		}
+	} else {
+		if err := c.TxPackets(p); err != nil {
+			return err
+		}
+	}
+	for i := range p {
+		if len(p[i].R) != 0 {
+			ops[i].R = make([]byte, len(p[i].R))
+			copy(ops[i].R, p[i].R)
+		}
+	}
+	r.Ops = append(r.Ops, ops...)
+	return nil
+}
+
 //
 
 type recordConn struct {
@@ -202,9 +249,22 @@ func (r *recordConn) Tx(w, read []byte) error {
 	return r.r.txInternal(r.c, w, read)
 }
 
-// TxPackets is not yet implemented.
+// MaxTxSize implements conn.Limits.
+func (r *recordConn) MaxTxSize() int {
+	r.r.Lock()
+	defer r.r.Unlock()
+	if r.r.MaxTxSize != 0 {
+		return r.r.MaxTxSize
+	}
+	if l, ok := r.c.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+// TxPackets records each packet as a separate operation.
 func (r *recordConn) TxPackets(p []spi.Packet) error {
-	return conntest.Errorf("spitest: TxPackets is not implemented")
+	return r.r.txPacketsInternal(r.c, p)
 }
 
 // CLK implements spi.Pins.
@@ -376,3 +436,4 @@ var _ spi.PortCloser = &Playback{}
 var _ spi.PortCloser = &Log{}
 var _ spi.Pins = &Record{}
 var _ spi.Pins = &Playback{}
+var _ conn.Limits = &recordConn{}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"log"
+	"reflect"
 	"testing"
 
 	"periph.io/x/periph/conn"
@@ -337,3 +338,54 @@ func TestLog_Playback(t *testing.T) {
 func init() {
 	log.SetOutput(ioutil.Discard)
 }
+
+func TestRecord_TxPackets(t *testing.T) {
+	r := Record{Port: &Loopback{}}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := &CSPin{Pin: gpiotest.Pin{N: "CS", L: gpio.High}, R: &r}
+	if err := cs.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	p := []spi.Packet{{W: []byte{1}, R: make([]byte, 1)}, {W: []byte{2}}}
+	if err := c.TxPackets(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	// Not a transition.
+	if err := cs.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	expected := []conntest.IO{{W: []byte{1}, R: []byte{1}}, {W: []byte{2}}}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+	events := []CSEvent{{Pin: "CS", L: gpio.Low, Op: 0}, {Pin: "CS", L: gpio.High, Op: 2}}
+	if !reflect.DeepEqual(r.CSEvents, events) {
+		t.Fatal(r.CSEvents)
+	}
+	if s := r.CSEvents[1].String(); s != "CS=High@2" {
+		t.Fatal(s)
+	}
+}
+
+func TestRecord_TxPackets_noPort(t *testing.T) {
+	r := Record{}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TxPackets([]spi.Packet{{R: []byte{0}}}) == nil {
+		t.Fatal("Port is nil")
+	}
+	if err := c.TxPackets([]spi.Packet{{W: []byte{1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Ops) != 1 {
+		t.Fatal(r.Ops)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import (
+	"errors"
+	"io"
+
+	"periph.io/x/periph/conn"
+)
+
+// Streamer is implemented by a Conn that can do a transaction larger than
+// its conn.Limits.MaxTxSize() with CS asserted for the whole transaction.
+type Streamer interface {
+	// TxStream does a single transaction of arbitrary length.
+	//
+	// The arguments have the same meaning than for conn.Conn.Tx().
+	TxStream(w, r []byte) error
+}
+
+// TxStream does a transaction of arbitrary length over c.
+//
+// If c implements Streamer, it is used directly. Otherwise, when the
+// transaction is larger than c's conn.Limits.MaxTxSize(), it is split into
+// chunks of at most MaxTxSize() bytes, each sent with TxPackets(). All the
+// chunks except the last one are sent with KeepCS:true so the CS line stays
+// asserted between the chunks when the driver supports it.
+//
+// When c is half duplex, w is sent first then r is read.
+func TxStream(c Conn, w, r []byte) error {
+	if s, ok := c.(Streamer); ok {
+		return s.TxStream(w, r)
+	}
+	half := c.Duplex() == conn.Half
+	if !half && len(w) != 0 && len(r) != 0 && len(w) != len(r) {
+		return errors.New("spi: TxStream(): when both w and r are used, they must be the same size")
+	}
+	max := 0
+	if l, ok := c.(conn.Limits); ok {
+		max = l.MaxTxSize()
+	}
+	l := len(w)
+	if half {
+		l += len(r)
+	} else if l == 0 {
+		l = len(r)
+	}
+	if max <= 0 || l <= max {
+		return c.Tx(w, r)
+	}
+	var p []Packet
+	if half {
+		p = appendChunks(appendChunks(nil, w, nil, max), nil, r, max)
+	} else {
+		p = appendChunks(nil, w, r, max)
+	}
+	for i := range p {
+		p[i].KeepCS = i != len(p)-1
+		if err := c.TxPackets(p[i : i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stream is an io.Reader and io.Writer over a Conn.
+//
+// Each call to Read() or Write() is a single transaction done with TxStream(),
+// so the buffer can be larger than the Conn's conn.Limits.MaxTxSize().
+type Stream struct {
+	Conn Conn
+}
+
+// Read implements io.Reader.
+//
+// The data written while reading is undefined.
+func (s *Stream) Read(b []byte) (int, error) {
+	if err := TxStream(s.Conn, nil, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Write implements io.Writer.
+//
+// The data read is discarded.
+func (s *Stream) Write(b []byte) (int, error) {
+	if err := TxStream(s.Conn, b, nil); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+//
+
+// appendChunks appends w and r split in packets of at most max bytes.
+//
+// w and r must be the same size if both are specified.
+func appendChunks(p []Packet, w, r []byte, max int) []Packet {
+	l := len(w)
+	if l == 0 {
+		l = len(r)
+	}
+	for i := 0; i < l; i += max {
+		j := i + max
+		if j > l {
+			j = l
+		}
+		var pkt Packet
+		if len(w) != 0 {
+			pkt.W = w[i:j]
+		}
+		if len(r) != 0 {
+			pkt.R = r[i:j]
+		}
+		p = append(p, pkt)
+	}
+	return p
+}
+
+var _ io.ReadWriter = &Stream{}
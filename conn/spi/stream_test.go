@@ -0,0 +1,158 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestTxStream(t *testing.T) {
+	r := &spitest.Record{Port: &spitest.Loopback{}, MaxTxSize: 4}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	read := make([]byte, len(w))
+	if err := spi.TxStream(c, w, read); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, w) {
+		t.Fatal(read)
+	}
+	expected := []conntest.IO{
+		{W: []byte{1, 2, 3, 4}, R: []byte{1, 2, 3, 4}},
+		{W: []byte{5, 6, 7, 8}, R: []byte{5, 6, 7, 8}},
+		{W: []byte{9, 10}, R: []byte{9, 10}},
+	}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestTxStream_small(t *testing.T) {
+	r := &spitest.Record{MaxTxSize: 4}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Small enough to be sent with Tx.
+	if err := spi.TxStream(c, []byte{1, 2, 3, 4}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if spi.TxStream(c, []byte{1, 2}, make([]byte, 3)) == nil {
+		t.Fatal("buffers must have the same size")
+	}
+	if !reflect.DeepEqual(r.Ops, []conntest.IO{{W: []byte{1, 2, 3, 4}}}) {
+		t.Fatal(r.Ops)
+	}
+}
+
+func TestTxStream_keepCS(t *testing.T) {
+	c := &packetConn{max: 3, d: conn.Half}
+	if err := spi.TxStream(c, []byte{1, 2, 3, 4}, make([]byte, 2)); err != nil {
+		t.Fatal(err)
+	}
+	expected := []spi.Packet{
+		{W: []byte{1, 2, 3}, KeepCS: true},
+		{W: []byte{4}, KeepCS: true},
+		{R: []byte{0, 0}},
+	}
+	if !reflect.DeepEqual(c.p, expected) {
+		t.Fatal(c.p)
+	}
+}
+
+func TestTxStream_streamer(t *testing.T) {
+	c := &streamConn{}
+	if err := spi.TxStream(c, []byte{1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !c.called {
+		t.Fatal("TxStream wasn't called")
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := &spitest.Record{Port: &spitest.Loopback{}, MaxTxSize: 2}
+	c, err := r.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &spi.Stream{Conn: c}
+	if n, err := io.Copy(s, bytes.NewReader([]byte{1, 2, 3})); n != 3 || err != nil {
+		t.Fatal(n, err)
+	}
+	b := make([]byte, 3)
+	if n, err := s.Read(b); n != 3 || err != nil {
+		t.Fatal(n, err)
+	}
+	expected := []conntest.IO{
+		{W: []byte{1, 2}},
+		{W: []byte{3}},
+		{R: []byte{0, 0}},
+		{R: []byte{0}},
+	}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatal(r.Ops)
+	}
+	r.Port = nil
+	if n, err := s.Read(b); n != 0 || err == nil {
+		t.Fatal("Port is nil", n, err)
+	}
+	if n, err := s.Write(b); n != 3 || err != nil {
+		t.Fatal(n, err)
+	}
+}
+
+//
+
+// packetConn records the packets sent one at a time.
+type packetConn struct {
+	max int
+	d   conn.Duplex
+	p   []spi.Packet
+}
+
+func (p *packetConn) String() string {
+	return "packet"
+}
+
+func (p *packetConn) Tx(w, r []byte) error {
+	p.p = append(p.p, spi.Packet{W: w, R: r})
+	return nil
+}
+
+func (p *packetConn) TxPackets(pkts []spi.Packet) error {
+	p.p = append(p.p, pkts...)
+	return nil
+}
+
+func (p *packetConn) Duplex() conn.Duplex {
+	return p.d
+}
+
+func (p *packetConn) MaxTxSize() int {
+	return p.max
+}
+
+type streamConn struct {
+	packetConn
+	called bool
+}
+
+func (s *streamConn) TxStream(w, r []byte) error {
+	s.called = true
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package spi
+
+import "periph.io/x/periph/conn"
+
+// TargetHandler is implemented by the application to emulate a SPI target
+// device. In this mode, the host answers to a controller on the bus instead
+// of driving it.
+//
+// The callback is called synchronously from the port driver and must not
+// block.
+type TargetHandler interface {
+	// OnTx is called for each transaction, that is while CS is asserted by the
+	// controller.
+	//
+	// w is the data received from the controller on MOSI and r must be filled
+	// with the data sent to the controller on MISO. Both have the same length.
+	OnTx(w, r []byte)
+}
+
+// TargetPort is implemented by a Port that can emulate a target device.
+type TargetPort interface {
+	// ListenTarget starts responding to the controller with the specified mode
+	// and number of bits per word.
+	//
+	// Halt() on the returned resource stops responding.
+	ListenTarget(mode Mode, bits int, h TargetHandler) (conn.Resource, error)
+}
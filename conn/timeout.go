@@ -0,0 +1,34 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package conn
+
+// TimeoutError is returned when an operation didn't complete before its
+// deadline, for example by the context aware TxContext() functions.
+//
+// Use IsTimeout() to detect it.
+type TimeoutError struct {
+	// Op describes the operation that timed out, e.g. "I2C1.Tx".
+	Op string
+}
+
+func (t *TimeoutError) Error() string {
+	return t.Op + ": timed out"
+}
+
+// Timeout returns true.
+//
+// It has the same signature as net.Error.Timeout().
+func (t *TimeoutError) Timeout() bool {
+	return true
+}
+
+// IsTimeout returns true if err is a timeout error, that is an error
+// implementing a Timeout() method that returns true like TimeoutError does.
+func IsTimeout(err error) bool {
+	t, ok := err.(interface {
+		Timeout() bool
+	})
+	return ok && t.Timeout()
+}
@@ -0,0 +1,23 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package conn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTimeoutError(t *testing.T) {
+	var err error = &TimeoutError{Op: "I2C1.Tx"}
+	if s := err.Error(); s != "I2C1.Tx: timed out" {
+		t.Fatal(s)
+	}
+	if !IsTimeout(err) {
+		t.Fatal("expected timeout")
+	}
+	if IsTimeout(errors.New("foo")) || IsTimeout(nil) {
+		t.Fatal("unexpected timeout")
+	}
+}
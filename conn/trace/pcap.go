@@ -0,0 +1,200 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"encoding/binary"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LinkType is a pcap link-layer header type, as listed at
+// http://www.tcpdump.org/linktypes.html.
+type LinkType uint32
+
+const (
+	// LinkI2C is LINKTYPE_I2C_LINUX, which Wireshark decodes natively.
+	//
+	// Each I²C message is saved as a packet starting with a 5 bytes
+	// pseudo-header: the bus number then 32 bits of flags in big endian, where
+	// bit 0 is set for a read. The first byte of the message is the address
+	// shifted left by one, with bit 0 set for a read, followed by the data.
+	LinkI2C LinkType = 209
+	// LinkSPI is LINKTYPE_USER0 since there's no standard link type for SPI.
+	//
+	// Each SPI transfer is saved as a packet containing the MOSI data then the
+	// MISO data, each prefixed with its length as a 32 bits big endian
+	// integer.
+	LinkSPI LinkType = 147
+)
+
+// PCAP is a Sink that saves the events in the pcap file format.
+//
+// Only the events matching the link type are saved: I²C events for LinkI2C
+// and SPI events for LinkSPI. The file can be opened with Wireshark or
+// converted with sigrok.
+type PCAP struct {
+	mu   sync.Mutex
+	w    io.Writer
+	link LinkType
+	err  error
+}
+
+// NewPCAP writes the pcap file header to w and returns a Sink writing the
+// events to w.
+func NewPCAP(w io.Writer, link LinkType) (*PCAP, error) {
+	var hdr [24]byte
+	binary.LittleEndian.PutUint32(hdr[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(hdr[20:], uint32(link))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return nil, err
+	}
+	return &PCAP{w: w, link: link}, nil
+}
+
+// OpenFile returns a Sink saving the events to the pcap file path, or
+// printing them as text to stderr if path is "-".
+//
+// done must be called to close the file; it returns the first write error.
+func OpenFile(path string, link LinkType) (s Sink, done func() error, err error) {
+	if path == "-" {
+		return &Log{Logger: log.New(os.Stderr, "", log.Lmicroseconds)}, func() error { return nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := NewPCAP(f, link)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	done = func() error {
+		if err := f.Close(); err != nil {
+			return err
+		}
+		return p.Err()
+	}
+	return p, done, nil
+}
+
+// Emit implements Sink.
+//
+// Write errors are saved and returned by Err().
+func (p *PCAP) Emit(e *Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return
+	}
+	switch {
+	case p.link == LinkI2C && e.Type == I2CType:
+		if e.Msgs == nil {
+			if len(e.W) != 0 || len(e.R) == 0 {
+				p.writeI2C(e, e.Addr, false, e.W)
+			}
+			if len(e.R) != 0 {
+				p.writeI2C(e, e.Addr, true, e.R)
+			}
+			return
+		}
+		for _, m := range e.Msgs {
+			if len(m.R) != 0 {
+				p.writeI2C(e, m.Addr, true, m.R)
+			} else {
+				p.writeI2C(e, m.Addr, false, m.W)
+			}
+		}
+	case p.link == LinkSPI && e.Type == SPIType:
+		if e.Packets == nil {
+			p.writeSPI(e, e.W, e.R)
+			return
+		}
+		for _, pkt := range e.Packets {
+			p.writeSPI(e, pkt.W, pkt.R)
+		}
+	}
+}
+
+// Err returns the first error that occurred while writing.
+func (p *PCAP) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+//
+
+// pcapSnapLen is the maximum size of a packet.
+const pcapSnapLen = 65535
+
+// writeI2C writes a I²C message.
+//
+// lock must be held.
+func (p *PCAP) writeI2C(e *Event, addr uint16, read bool, data []byte) {
+	b := make([]byte, 6, 6+len(data))
+	b[0] = busNumber(e.Bus)
+	b[5] = byte(addr << 1)
+	if read {
+		b[4] = 1
+		b[5] |= 1
+	}
+	p.write(e.Start, append(b, data...))
+}
+
+// writeSPI writes a SPI transfer.
+//
+// lock must be held.
+func (p *PCAP) writeSPI(e *Event, w, r []byte) {
+	b := make([]byte, 8+len(w)+len(r))
+	binary.BigEndian.PutUint32(b, uint32(len(w)))
+	copy(b[4:], w)
+	binary.BigEndian.PutUint32(b[4+len(w):], uint32(len(r)))
+	copy(b[8+len(w):], r)
+	p.write(e.Start, b)
+}
+
+// write writes a packet.
+//
+// lock must be held.
+func (p *PCAP) write(t time.Time, b []byte) {
+	l := len(b)
+	if l > pcapSnapLen {
+		b = b[:pcapSnapLen]
+	}
+	var hdr [16]byte
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(t.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(t.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(b)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(l))
+	if _, p.err = p.w.Write(hdr[:]); p.err == nil {
+		_, p.err = p.w.Write(b)
+	}
+}
+
+// busNumber returns the number at the end of the bus name, e.g. 1 for
+// "I2C1", or 0.
+func busNumber(name string) byte {
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+	n := 0
+	for _, c := range name[i:] {
+		n = n*10 + int(c-'0')
+		if n > 255 {
+			return 0
+		}
+	}
+	return byte(n)
+}
+
+var _ Sink = &PCAP{}
@@ -0,0 +1,155 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/spi"
+)
+
+func TestPCAP_I2C(t *testing.T) {
+	buf := bytes.Buffer{}
+	p, err := NewPCAP(&buf, LinkI2C)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1520000000, 123456789)
+	p.Emit(&Event{Bus: "I2C1", Type: I2CType, Op: "Tx", Addr: 0x76, W: []byte{0xd0}, R: []byte{0x60}, Start: start})
+	p.Emit(&Event{Bus: "I2C1", Type: I2CType, Op: "TxMsgs", Msgs: []i2c.Msg{{Addr: 0x50, R: []byte{1, 2}}}, Start: start})
+	// Ignored.
+	p.Emit(&Event{Bus: "SPI0.0", Type: SPIType, Op: "Tx", W: []byte{1}, Start: start})
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		// File header.
+		0xd4, 0xc3, 0xb2, 0xa1, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0, 0, 209, 0, 0, 0,
+		// Write 0xd0 to 0x76.
+		0x00, 0x5c, 0x99, 0x5a, 0x40, 0xe2, 0x01, 0, 7, 0, 0, 0, 7, 0, 0, 0,
+		1, 0, 0, 0, 0, 0xec, 0xd0,
+		// Read 0x60 from 0x76.
+		0x00, 0x5c, 0x99, 0x5a, 0x40, 0xe2, 0x01, 0, 7, 0, 0, 0, 7, 0, 0, 0,
+		1, 0, 0, 0, 1, 0xed, 0x60,
+		// Read 1, 2 from 0x50.
+		0x00, 0x5c, 0x99, 0x5a, 0x40, 0xe2, 0x01, 0, 8, 0, 0, 0, 8, 0, 0, 0,
+		1, 0, 0, 0, 1, 0xa1, 1, 2,
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatalf("%#v", buf.Bytes())
+	}
+}
+
+func TestPCAP_SPI(t *testing.T) {
+	buf := bytes.Buffer{}
+	p, err := NewPCAP(&buf, LinkSPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1520000000, 0)
+	p.Emit(&Event{Bus: "SPI0.0", Type: SPIType, Op: "TxPackets", Packets: []spi.Packet{{W: []byte{1}, R: []byte{2}}}, Start: start})
+	expected := []byte{
+		0xd4, 0xc3, 0xb2, 0xa1, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0, 0, 147, 0, 0, 0,
+		0x00, 0x5c, 0x99, 0x5a, 0, 0, 0, 0, 10, 0, 0, 0, 10, 0, 0, 0,
+		0, 0, 0, 1, 1, 0, 0, 0, 1, 2,
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatalf("%#v", buf.Bytes())
+	}
+}
+
+func TestPCAP_err(t *testing.T) {
+	if _, err := NewPCAP(&failWriter{}, LinkI2C); err == nil {
+		t.Fatal("expected error")
+	}
+	w := &failWriter{ok: 1}
+	p, err := NewPCAP(w, LinkSPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Emit(&Event{Type: SPIType, W: []byte{1}})
+	if p.Err() == nil {
+		t.Fatal("expected error")
+	}
+	p.Emit(&Event{Type: SPIType, W: []byte{1}})
+	if w.ok != -1 {
+		t.Fatal("unexpected write after error")
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	d, err := ioutil.TempDir("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	path := filepath.Join(d, "out.pcap")
+	s, done, err := OpenFile(path, LinkSPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Emit(&Event{Bus: "SPI0.0", Type: SPIType, Op: "Tx", W: []byte{1}, R: []byte{2}})
+	if err := done(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// File header, packet header then 4+1+4+1 bytes.
+	if len(b) != 24+16+10 {
+		t.Fatal(len(b))
+	}
+	if s, done, err = OpenFile("-", LinkSPI); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*Log); !ok {
+		t.Fatalf("%T", s)
+	}
+	if err := done(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := OpenFile(filepath.Join(d, "missing", "out.pcap"), LinkSPI); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestBusNumber(t *testing.T) {
+	data := []struct {
+		name     string
+		expected byte
+	}{
+		{"I2C1", 1},
+		{"/dev/i2c-12", 12},
+		{"playback", 0},
+		{"I2C1000", 0},
+		{"", 0},
+	}
+	for i, line := range data {
+		if n := busNumber(line.name); n != line.expected {
+			t.Fatalf("#%d: %d != %d", i, n, line.expected)
+		}
+	}
+}
+
+//
+
+type failWriter struct {
+	ok int // Number of writes that succeed.
+}
+
+func (f *failWriter) Write(b []byte) (int, error) {
+	if f.ok--; f.ok < 0 {
+		return 0, errors.New("failed")
+	}
+	return len(b), nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"log"
+	"sync"
+)
+
+// Log is a Sink that prints the events as text.
+type Log struct {
+	// Logger is the logger to print to. The default value nil means the
+	// standard logger of package log.
+	Logger *log.Logger
+}
+
+// Emit implements Sink.
+func (l *Log) Emit(e *Event) {
+	if l.Logger == nil {
+		log.Print(e)
+	} else {
+		l.Logger.Print(e)
+	}
+}
+
+// Ring is a Sink that keeps the last events in memory.
+//
+// It is useful to dump the traffic that led to a failure.
+//
+// The zero value keeps the last DefaultRingSize events. Use NewRing() to keep
+// a different number of events.
+type Ring struct {
+	mu     sync.Mutex
+	events []Event
+	next   int  // Index in events where the next event is stored.
+	full   bool // events wrapped around at least once.
+}
+
+// DefaultRingSize is the number of events kept by the zero value of Ring.
+const DefaultRingSize = 256
+
+// NewRing returns a Ring that keeps the last n events.
+func NewRing(n int) *Ring {
+	if n < 1 {
+		n = 1
+	}
+	return &Ring{events: make([]Event, n)}
+}
+
+// Emit implements Sink.
+func (r *Ring) Emit(e *Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = make([]Event, DefaultRingSize)
+	}
+	r.events[r.next] = *e
+	if r.next++; r.next == len(r.events) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Events returns the events kept, from the oldest to the newest.
+func (r *Ring) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		out := make([]Event, r.next)
+		copy(out, r.events)
+		return out
+	}
+	out := make([]Event, 0, len(r.events))
+	out = append(out, r.events[r.next:]...)
+	return append(out, r.events[:r.next]...)
+}
+
+// Reset discards the events kept.
+func (r *Ring) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		r.events[i] = Event{}
+	}
+	r.next = 0
+	r.full = false
+}
+
+var _ Sink = &Log{}
+var _ Sink = &Ring{}
+var _ Sink = Multi{}
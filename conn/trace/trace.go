@@ -0,0 +1,157 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package trace wraps buses and connections to trace their traffic.
+//
+// Each transaction done through a wrapper is reported as an Event to a Sink.
+// The sinks provided are Log to print the events as text, Ring to keep the
+// last events in memory and PCAP to save I²C and SPI traffic to a file that
+// can be opened with Wireshark.
+//
+// Unlike conntest.Record, the wrappers are meant to be used in production
+// code and only add the cost of copying the payloads.
+package trace
+
+import (
+	"fmt"
+	"time"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/spi"
+)
+
+// Type is the type of bus an Event happened on.
+type Type int
+
+const (
+	// ConnType is a generic conn.Conn.
+	ConnType Type = 0
+	// I2CType is an i2c.Bus.
+	I2CType Type = 1
+	// SPIType is a spi.Conn.
+	SPIType Type = 2
+	// OneWireType is a onewire.Bus.
+	OneWireType Type = 3
+)
+
+const typeName = "ConnTypeI2CTypeSPITypeOneWireType"
+
+var typeIndex = [...]uint8{0, 8, 15, 22, 33}
+
+func (i Type) String() string {
+	if i < 0 || i >= Type(len(typeIndex)-1) {
+		return fmt.Sprintf("Type(%d)", i)
+	}
+	return typeName[typeIndex[i]:typeIndex[i+1]]
+}
+
+// Event is a transaction that happened on a bus.
+//
+// The payloads are copies owned by the Event.
+type Event struct {
+	// Bus is the name of the bus or connection.
+	Bus  string
+	Type Type
+	// Op is the method called, e.g. "Tx", "TxMsgs", "SMBusTx" or "TxPackets".
+	Op string
+	// Addr is the device address, for Tx and SMBusTx on an I²C bus.
+	Addr uint16
+	// W and R are the data written and read, for Tx.
+	W, R []byte
+	// Msgs is the messages, for TxMsgs on an I²C bus.
+	Msgs []i2c.Msg
+	// Packets is the packets, for TxPackets on a SPI connection.
+	Packets []spi.Packet
+	// Pull is the pull-up requested at the end of a 1-wire transaction.
+	Pull onewire.Pullup
+	// Start is when the transaction started.
+	Start time.Time
+	// Duration is how long the transaction took.
+	Duration time.Duration
+	// Err is the error returned by the bus, if any.
+	Err error
+}
+
+func (e *Event) String() string {
+	var s string
+	switch {
+	case e.Msgs != nil:
+		s = fmt.Sprintf("%s.%s(", e.Bus, e.Op)
+		for i, m := range e.Msgs {
+			if i != 0 {
+				s += ", "
+			}
+			s += fmt.Sprintf("{0x%02x %s w:%x r:%x}", m.Addr, m.Flags, m.W, m.R)
+		}
+		s += ")"
+	case e.Packets != nil:
+		s = fmt.Sprintf("%s.%s(", e.Bus, e.Op)
+		for i, p := range e.Packets {
+			if i != 0 {
+				s += ", "
+			}
+			s += fmt.Sprintf("{w:%x r:%x", p.W, p.R)
+			if p.BitsPerWord != 0 {
+				s += fmt.Sprintf(" bits:%d", p.BitsPerWord)
+			}
+			if p.KeepCS {
+				s += " KeepCS"
+			}
+			s += "}"
+		}
+		s += ")"
+	case e.Type == I2CType:
+		s = fmt.Sprintf("%s.%s(0x%02x, w:%x, r:%x)", e.Bus, e.Op, e.Addr, e.W, e.R)
+	case e.Type == OneWireType:
+		s = fmt.Sprintf("%s.%s(w:%x, r:%x, %s)", e.Bus, e.Op, e.W, e.R, e.Pull)
+	default:
+		s = fmt.Sprintf("%s.%s(w:%x, r:%x)", e.Bus, e.Op, e.W, e.R)
+	}
+	return fmt.Sprintf("%s = %v in %s", s, e.Err, e.Duration)
+}
+
+// Sink receives the events from the wrappers.
+type Sink interface {
+	// Emit is called after each transaction.
+	//
+	// It must be safe for concurrent use. The payloads in e are not reused by
+	// the wrappers so they can be kept, but they must not be modified.
+	Emit(e *Event)
+}
+
+// Multi is a Sink that forwards the events to multiple sinks.
+type Multi []Sink
+
+// Emit implements Sink.
+func (m Multi) Emit(e *Event) {
+	for _, s := range m {
+		s.Emit(e)
+	}
+}
+
+//
+
+// copyBytes returns a copy of b, or nil if b is empty.
+func copyBytes(b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	out := make([]byte, len(b))
+	copy(out, b)
+	return out
+}
+
+// begin returns an Event started now.
+func begin(bus fmt.Stringer, t Type, op string) Event {
+	return Event{Bus: bus.String(), Type: t, Op: op, Start: time.Now()}
+}
+
+// end completes the event and sends it to the sink.
+func end(s Sink, e *Event, err error) error {
+	e.Duration = time.Since(e.Start)
+	e.Err = err
+	s.Emit(e)
+	return err
+}
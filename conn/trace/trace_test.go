@@ -0,0 +1,404 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/conntest"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewiretest"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+	"periph.io/x/periph/conn/spi/spitest"
+)
+
+func TestType_String(t *testing.T) {
+	if s := I2CType.String(); s != "I2CType" {
+		t.Fatal(s)
+	}
+	if s := Type(10).String(); s != "Type(10)" {
+		t.Fatal(s)
+	}
+}
+
+func TestConn(t *testing.T) {
+	r := NewRing(10)
+	c := &Conn{Conn: &conntest.Playback{Ops: []conntest.IO{{W: []byte{1}, R: []byte{2}}}, D: conn.Half, DontPanic: true}, Sink: r}
+	if s := c.String(); s != "playback" {
+		t.Fatal(s)
+	}
+	if d := c.Duplex(); d != conn.Half {
+		t.Fatal(d)
+	}
+	if l := c.MaxTxSize(); l != 0 {
+		t.Fatal(l)
+	}
+	b := make([]byte, 1)
+	if err := c.Tx([]byte{1}, b); err != nil {
+		t.Fatal(err)
+	}
+	if c.Tx([]byte{1}, b) == nil {
+		t.Fatal("playback is empty")
+	}
+	e := r.Events()
+	if len(e) != 2 {
+		t.Fatal(e)
+	}
+	if e[0].Bus != "playback" || e[0].Type != ConnType || e[0].Op != "Tx" || !bytes.Equal(e[0].W, []byte{1}) || !bytes.Equal(e[0].R, []byte{2}) || e[0].Err != nil || e[0].Start.IsZero() {
+		t.Fatalf("%#v", e[0])
+	}
+	if e[1].Err == nil {
+		t.Fatal("expected error")
+	}
+	if s := e[0].String(); !strings.HasPrefix(s, "playback.Tx(w:01, r:02) = <nil> in ") {
+		t.Fatal(s)
+	}
+}
+
+func TestI2C(t *testing.T) {
+	r := NewRing(10)
+	p := &i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x76, W: []byte{0xd0}, R: []byte{0x60}},
+			{Msgs: []i2c.Msg{{Addr: 0x76, W: []byte{0xf4}}, {Addr: 0x76, Flags: i2c.NoStart, W: []byte{3}}}},
+		},
+	}
+	b := NewI2C(p, r)
+	if s := b.String(); s != "playback" {
+		t.Fatal(s)
+	}
+	d := i2c.Dev{Bus: b, Addr: 0x76}
+	buf := make([]byte, 1)
+	if err := d.Tx([]byte{0xd0}, buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.(i2c.MsgBus).TxMsgs([]i2c.Msg{{Addr: 0x76, W: []byte{0xf4}}, {Addr: 0x76, Flags: i2c.NoStart, W: []byte{3}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetSpeed(physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	e := r.Events()
+	if len(e) != 2 {
+		t.Fatal(e)
+	}
+	if s := e[0].String(); !strings.HasPrefix(s, "playback.Tx(0x76, w:d0, r:60) = <nil> in ") {
+		t.Fatal(s)
+	}
+	if s := e[1].String(); !strings.HasPrefix(s, "playback.TxMsgs({0x76 0x0 w:f4 r:}, {0x76 NoStart w:03 r:}) = <nil> in ") {
+		t.Fatal(s)
+	}
+	if pins := b.(i2c.Pins); pins.SCL() != nil || pins.SDA() != nil {
+		t.Fatal("unexpected pins")
+	}
+	if _, ok := b.(smbus.Bus); ok {
+		t.Fatal("playback doesn't implement smbus.Bus")
+	}
+}
+
+func TestNewI2C(t *testing.T) {
+	r := NewRing(10)
+	b := NewI2C(&nonMsgBus{}, r)
+	if _, ok := b.(i2c.MsgBus); ok {
+		t.Fatal("nonMsgBus doesn't implement i2c.MsgBus")
+	}
+	if _, ok := b.(i2c.Pins); ok {
+		t.Fatal("nonMsgBus doesn't implement i2c.Pins")
+	}
+	if _, ok := b.(smbus.Bus); ok {
+		t.Fatal("nonMsgBus doesn't implement smbus.Bus")
+	}
+	// Each combination of optional interfaces is forwarded.
+	for i := 0; i < 8; i++ {
+		var inner i2c.Bus
+		switch i {
+		case 0:
+			inner = &nonMsgBus{}
+		case 1:
+			inner = &struct {
+				nonMsgBus
+				msgPart
+			}{}
+		case 2:
+			inner = &struct {
+				nonMsgBus
+				smbusPart
+			}{}
+		case 3:
+			inner = &struct {
+				nonMsgBus
+				pinsPart
+			}{}
+		case 4:
+			inner = &struct {
+				nonMsgBus
+				msgPart
+				smbusPart
+			}{}
+		case 5:
+			inner = &struct {
+				nonMsgBus
+				msgPart
+				pinsPart
+			}{}
+		case 6:
+			inner = &struct {
+				nonMsgBus
+				smbusPart
+				pinsPart
+			}{}
+		case 7:
+			inner = &struct {
+				nonMsgBus
+				msgPart
+				smbusPart
+				pinsPart
+			}{}
+		}
+		b := NewI2C(inner, r)
+		_, isMsg := inner.(i2c.MsgBus)
+		_, isSMBus := inner.(smbus.Bus)
+		_, isPins := inner.(i2c.Pins)
+		_, hasMsg := b.(i2c.MsgBus)
+		_, hasSMBus := b.(smbus.Bus)
+		_, hasPins := b.(i2c.Pins)
+		if isMsg != hasMsg || isSMBus != hasSMBus || isPins != hasPins {
+			t.Fatalf("#%d: %t %t %t != %t %t %t", i, isMsg, isSMBus, isPins, hasMsg, hasSMBus, hasPins)
+		}
+	}
+}
+
+func TestNewI2C_scan(t *testing.T) {
+	// A bus with only Tx(); smbus.Scan() must fall back to a read.
+	b := NewI2C(&nonMsgBus{ack: 0x40}, NewRing(10))
+	a, err := smbus.Scan(b, 0x03, 0x77, smbus.ProbeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, []uint16{0x40}) {
+		t.Fatal(a)
+	}
+}
+
+func TestNewI2C_SMBusTx(t *testing.T) {
+	r := NewRing(10)
+	s := &struct {
+		nonMsgBus
+		smbusPart
+	}{}
+	b := NewI2C(s, r).(smbus.Bus)
+	if !b.SMBusSupports(smbus.WordData, true, true) {
+		t.Fatal("expected support")
+	}
+	d := smbus.Dev{Bus: b, Addr: 0x0b, PEC: true}
+	if v, err := d.ReadWord(0x09); err != nil || v != 0x0201 {
+		t.Fatal(v, err)
+	}
+	if err := d.WriteByteData(0x10, 0xaa); err != nil {
+		t.Fatal(err)
+	}
+	if !s.pec {
+		t.Fatal("PEC must be forwarded")
+	}
+	e := r.Events()
+	if len(e) != 2 {
+		t.Fatal(e)
+	}
+	if str := e[0].String(); !strings.HasPrefix(str, "nonmsgbus.SMBusTx(0x0b, w:09, r:0102) = <nil> in ") {
+		t.Fatal(str)
+	}
+	if str := e[1].String(); !strings.HasPrefix(str, "nonmsgbus.SMBusTx(0x0b, w:10aa, r:) = <nil> in ") {
+		t.Fatal(str)
+	}
+}
+
+func TestSPI(t *testing.T) {
+	r := NewRing(10)
+	p := &spitest.Playback{Playback: conntest.Playback{Ops: []conntest.IO{{W: []byte{1}, R: []byte{2}}}}}
+	s := &SPI{Port: p, Sink: r}
+	if str := s.String(); str != "playback" {
+		t.Fatal(str)
+	}
+	if err := s.LimitSpeed(physic.MegaHertz); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Connect(physic.MegaHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Connect(physic.MegaHertz, spi.Mode0, 8); err == nil {
+		t.Fatal("Connect twice")
+	}
+	buf := make([]byte, 1)
+	if err := c.Tx([]byte{1}, buf); err != nil {
+		t.Fatal(err)
+	}
+	if c.TxPackets([]spi.Packet{{W: []byte{3}, KeepCS: true, BitsPerWord: 9}}) == nil {
+		t.Fatal("TxPackets is not implemented by spitest.Playback")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	e := r.Events()
+	if len(e) != 2 {
+		t.Fatal(e)
+	}
+	if str := e[0].String(); !strings.HasPrefix(str, "playback.Tx(w:01, r:02) = <nil> in ") {
+		t.Fatal(str)
+	}
+	if str := e[1].String(); !strings.HasPrefix(str, "playback.TxPackets({w:03 r: bits:9 KeepCS}) = spitest: TxPackets is not implemented in ") {
+		t.Fatal(str)
+	}
+	if c.(conn.Limits).MaxTxSize() != 0 {
+		t.Fatal("unexpected limit")
+	}
+	if s.CLK() != nil || s.MOSI() != nil || s.MISO() != nil || s.CS() != nil {
+		t.Fatal("unexpected pins")
+	}
+	pins := c.(spi.Pins)
+	if pins.CLK() != nil || pins.MOSI() != nil || pins.MISO() != nil || pins.CS() != nil {
+		t.Fatal("unexpected pins")
+	}
+}
+
+func TestOneWire(t *testing.T) {
+	r := NewRing(10)
+	p := &onewiretest.Playback{Ops: []onewiretest.IO{{W: []byte{0xcc, 0x44}, Pull: onewire.StrongPullup}}}
+	o := &OneWire{Bus: p, Sink: r}
+	if s := o.String(); s != "playback" {
+		t.Fatal(s)
+	}
+	if err := o.Tx([]byte{0xcc, 0x44}, nil, onewire.StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	if o.Q() != nil {
+		t.Fatal("unexpected pin")
+	}
+	e := r.Events()
+	if len(e) != 1 {
+		t.Fatal(e)
+	}
+	if s := e[0].String(); !strings.HasPrefix(s, "playback.Tx(w:cc44, r:, Strong) = <nil> in ") {
+		t.Fatal(s)
+	}
+}
+
+func TestLog(t *testing.T) {
+	buf := bytes.Buffer{}
+	l := &Log{Logger: log.New(&buf, "", 0)}
+	l.Emit(&Event{Bus: "foo", Op: "Tx", W: []byte{1}, Err: errors.New("oops")})
+	if s := buf.String(); s != "foo.Tx(w:01, r:) = oops in 0s\n" {
+		t.Fatal(s)
+	}
+}
+
+func TestRing(t *testing.T) {
+	r := NewRing(2)
+	if e := r.Events(); len(e) != 0 {
+		t.Fatal(e)
+	}
+	for _, op := range []string{"a", "b", "c"} {
+		r.Emit(&Event{Op: op})
+	}
+	if e := r.Events(); len(e) != 2 || e[0].Op != "b" || e[1].Op != "c" {
+		t.Fatal(e)
+	}
+	r.Reset()
+	r.Emit(&Event{Op: "d"})
+	if e := r.Events(); !reflect.DeepEqual(e, []Event{{Op: "d"}}) {
+		t.Fatal(e)
+	}
+	if r := NewRing(0); len(r.events) != 1 {
+		t.Fatal(len(r.events))
+	}
+}
+
+func TestRing_zero(t *testing.T) {
+	r := Ring{}
+	if e := r.Events(); len(e) != 0 {
+		t.Fatal(e)
+	}
+	for i := 0; i < DefaultRingSize+1; i++ {
+		r.Emit(&Event{Addr: uint16(i)})
+	}
+	e := r.Events()
+	if len(e) != DefaultRingSize || e[0].Addr != 1 || e[DefaultRingSize-1].Addr != DefaultRingSize {
+		t.Fatal(len(e))
+	}
+	r.Reset()
+	if e := r.Events(); len(e) != 0 {
+		t.Fatal(e)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	r1 := NewRing(1)
+	r2 := NewRing(1)
+	Multi{r1, r2}.Emit(&Event{Op: "a"})
+	if len(r1.Events()) != 1 || len(r2.Events()) != 1 {
+		t.Fatal("event not forwarded")
+	}
+}
+
+//
+
+// nonMsgBus only implements i2c.Bus. If ack is not 0, only this address
+// acknowledges.
+type nonMsgBus struct {
+	ack uint16
+}
+
+func (n *nonMsgBus) String() string { return "nonmsgbus" }
+
+func (n *nonMsgBus) Tx(addr uint16, w, r []byte) error {
+	if n.ack != 0 && addr != n.ack {
+		return errors.New("nonmsgbus: NACK")
+	}
+	return nil
+}
+
+func (n *nonMsgBus) SetSpeed(f physic.Frequency) error { return nil }
+
+// msgPart, smbusPart and pinsPart add an optional interface to a nonMsgBus.
+type msgPart struct{}
+
+func (m *msgPart) TxMsgs(msgs []i2c.Msg) error { return nil }
+
+// smbusPart natively supports all the SMBus protocols. Reads return 1, 2, ...
+type smbusPart struct {
+	pec bool
+}
+
+func (s *smbusPart) SMBusSupports(p smbus.Protocol, read, pec bool) bool { return true }
+
+func (s *smbusPart) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	s.pec = pec
+	if !read {
+		return 0, nil
+	}
+	for i := range data {
+		data[i] = byte(i + 1)
+	}
+	return len(data), nil
+}
+
+type pinsPart struct{}
+
+func (p *pinsPart) SCL() gpio.PinIO { return gpio.INVALID }
+func (p *pinsPart) SDA() gpio.PinIO { return gpio.INVALID }
@@ -0,0 +1,415 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"io"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/spi"
+)
+
+// Conn traces the transactions done on a conn.Conn.
+type Conn struct {
+	Conn conn.Conn
+	Sink Sink
+}
+
+func (c *Conn) String() string {
+	return c.Conn.String()
+}
+
+// Tx implements conn.Conn.
+func (c *Conn) Tx(w, r []byte) error {
+	e := begin(c.Conn, ConnType, "Tx")
+	e.W = copyBytes(w)
+	err := c.Conn.Tx(w, r)
+	e.R = copyBytes(r)
+	return end(c.Sink, &e, err)
+}
+
+// Duplex implements conn.Conn.
+func (c *Conn) Duplex() conn.Duplex {
+	return c.Conn.Duplex()
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// Returns 0 if Conn doesn't implement conn.Limits.
+func (c *Conn) MaxTxSize() int {
+	if l, ok := c.Conn.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+//
+
+// I2C traces the transactions done on an i2c.Bus.
+//
+// Use NewI2C() to also trace and forward the optional interfaces i2c.MsgBus
+// and smbus.Bus, and i2c.Pins, when Bus implements them.
+type I2C struct {
+	Bus  i2c.Bus
+	Sink Sink
+}
+
+// NewI2C returns a wrapper tracing the transactions done on b.
+//
+// The wrapper implements i2c.MsgBus, smbus.Bus and i2c.Pins only if b
+// does, so callers like smbus.Scan() still detect what the bus supports.
+func NewI2C(b i2c.Bus, s Sink) i2c.BusCloser {
+	i := &I2C{Bus: b, Sink: s}
+	m, sm, p := i2cMsgBus{i}, i2cSMBus{i}, i2cPins{i}
+	_, isMsg := b.(i2c.MsgBus)
+	_, isSMBus := b.(smbus.Bus)
+	_, isPins := b.(i2c.Pins)
+	switch {
+	case isMsg && isSMBus && isPins:
+		return &struct {
+			*I2C
+			i2cMsgBus
+			i2cSMBus
+			i2cPins
+		}{i, m, sm, p}
+	case isMsg && isSMBus:
+		return &struct {
+			*I2C
+			i2cMsgBus
+			i2cSMBus
+		}{i, m, sm}
+	case isMsg && isPins:
+		return &struct {
+			*I2C
+			i2cMsgBus
+			i2cPins
+		}{i, m, p}
+	case isSMBus && isPins:
+		return &struct {
+			*I2C
+			i2cSMBus
+			i2cPins
+		}{i, sm, p}
+	case isMsg:
+		return &struct {
+			*I2C
+			i2cMsgBus
+		}{i, m}
+	case isSMBus:
+		return &struct {
+			*I2C
+			i2cSMBus
+		}{i, sm}
+	case isPins:
+		return &struct {
+			*I2C
+			i2cPins
+		}{i, p}
+	default:
+		return i
+	}
+}
+
+func (i *I2C) String() string {
+	return i.Bus.String()
+}
+
+// Close implements i2c.BusCloser.
+//
+// It closes Bus if it implements io.Closer.
+func (i *I2C) Close() error {
+	if c, ok := i.Bus.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Tx implements i2c.Bus.
+func (i *I2C) Tx(addr uint16, w, r []byte) error {
+	e := begin(i.Bus, I2CType, "Tx")
+	e.Addr = addr
+	e.W = copyBytes(w)
+	err := i.Bus.Tx(addr, w, r)
+	e.R = copyBytes(r)
+	return end(i.Sink, &e, err)
+}
+
+// SetSpeed implements i2c.Bus.
+func (i *I2C) SetSpeed(f physic.Frequency) error {
+	return i.Bus.SetSpeed(f)
+}
+
+// i2cMsgBus adds i2c.MsgBus to an I2C whose Bus implements it.
+type i2cMsgBus struct {
+	i *I2C
+}
+
+// TxMsgs implements i2c.MsgBus.
+func (m i2cMsgBus) TxMsgs(msgs []i2c.Msg) error {
+	e := begin(m.i.Bus, I2CType, "TxMsgs")
+	e.Msgs = make([]i2c.Msg, len(msgs))
+	for j := range msgs {
+		e.Msgs[j] = i2c.Msg{Addr: msgs[j].Addr, Flags: msgs[j].Flags, W: copyBytes(msgs[j].W)}
+	}
+	err := m.i.Bus.(i2c.MsgBus).TxMsgs(msgs)
+	for j := range msgs {
+		e.Msgs[j].R = copyBytes(msgs[j].R)
+	}
+	return end(m.i.Sink, &e, err)
+}
+
+// i2cSMBus adds smbus.Bus to an I2C whose Bus implements it.
+type i2cSMBus struct {
+	i *I2C
+}
+
+// SMBusSupports implements smbus.Bus.
+func (s i2cSMBus) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	return s.i.Bus.(smbus.Bus).SMBusSupports(p, read, pec)
+}
+
+// SMBusTx implements smbus.Bus.
+//
+// The event contains the command code and the data as sent on the wire,
+// without the byte count of block transfers nor the PEC.
+func (s i2cSMBus) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	e := begin(s.i.Bus, I2CType, "SMBusTx")
+	e.Addr = addr
+	if p != smbus.Quick && p != smbus.Byte {
+		e.W = []byte{cmd}
+	}
+	if p != smbus.Quick && (!read || p == smbus.ProcCall) {
+		e.W = append(e.W, data...)
+	}
+	n, err := s.i.Bus.(smbus.Bus).SMBusTx(addr, p, read, cmd, data, pec)
+	switch {
+	case p == smbus.ProcCall:
+		e.R = copyBytes(data)
+	case read && p != smbus.Quick && n <= len(data):
+		e.R = copyBytes(data[:n])
+	}
+	return n, end(s.i.Sink, &e, err)
+}
+
+// i2cPins adds i2c.Pins to an I2C whose Bus implements it.
+type i2cPins struct {
+	i *I2C
+}
+
+// SCL implements i2c.Pins.
+func (p i2cPins) SCL() gpio.PinIO {
+	return p.i.Bus.(i2c.Pins).SCL()
+}
+
+// SDA implements i2c.Pins.
+func (p i2cPins) SDA() gpio.PinIO {
+	return p.i.Bus.(i2c.Pins).SDA()
+}
+
+//
+
+// SPI traces the transactions done on the connection returned by a
+// spi.PortCloser.
+type SPI struct {
+	Port spi.PortCloser
+	Sink Sink
+}
+
+func (s *SPI) String() string {
+	return s.Port.String()
+}
+
+// Close implements spi.PortCloser.
+func (s *SPI) Close() error {
+	return s.Port.Close()
+}
+
+// LimitSpeed implements spi.PortCloser.
+func (s *SPI) LimitSpeed(f physic.Frequency) error {
+	return s.Port.LimitSpeed(f)
+}
+
+// Connect implements spi.PortCloser.
+func (s *SPI) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
+	c, err := s.Port.Connect(f, mode, bits)
+	if err != nil {
+		return nil, err
+	}
+	return &SPIConn{Conn: c, Sink: s.Sink}, nil
+}
+
+// CLK implements spi.Pins.
+func (s *SPI) CLK() gpio.PinOut {
+	if p, ok := s.Port.(spi.Pins); ok {
+		return p.CLK()
+	}
+	return gpio.INVALID
+}
+
+// MOSI implements spi.Pins.
+func (s *SPI) MOSI() gpio.PinOut {
+	if p, ok := s.Port.(spi.Pins); ok {
+		return p.MOSI()
+	}
+	return gpio.INVALID
+}
+
+// MISO implements spi.Pins.
+func (s *SPI) MISO() gpio.PinIn {
+	if p, ok := s.Port.(spi.Pins); ok {
+		return p.MISO()
+	}
+	return gpio.INVALID
+}
+
+// CS implements spi.Pins.
+func (s *SPI) CS() gpio.PinOut {
+	if p, ok := s.Port.(spi.Pins); ok {
+		return p.CS()
+	}
+	return gpio.INVALID
+}
+
+// SPIConn traces the transactions done on a spi.Conn.
+type SPIConn struct {
+	Conn spi.Conn
+	Sink Sink
+}
+
+func (s *SPIConn) String() string {
+	return s.Conn.String()
+}
+
+// Tx implements spi.Conn.
+func (s *SPIConn) Tx(w, r []byte) error {
+	e := begin(s.Conn, SPIType, "Tx")
+	e.W = copyBytes(w)
+	err := s.Conn.Tx(w, r)
+	e.R = copyBytes(r)
+	return end(s.Sink, &e, err)
+}
+
+// TxPackets implements spi.Conn.
+func (s *SPIConn) TxPackets(p []spi.Packet) error {
+	e := begin(s.Conn, SPIType, "TxPackets")
+	e.Packets = make([]spi.Packet, len(p))
+	for i := range p {
+		e.Packets[i] = spi.Packet{W: copyBytes(p[i].W), BitsPerWord: p[i].BitsPerWord, KeepCS: p[i].KeepCS}
+	}
+	err := s.Conn.TxPackets(p)
+	for i := range p {
+		e.Packets[i].R = copyBytes(p[i].R)
+	}
+	return end(s.Sink, &e, err)
+}
+
+// Duplex implements spi.Conn.
+func (s *SPIConn) Duplex() conn.Duplex {
+	return s.Conn.Duplex()
+}
+
+// MaxTxSize implements conn.Limits.
+//
+// Returns 0 if Conn doesn't implement conn.Limits.
+func (s *SPIConn) MaxTxSize() int {
+	if l, ok := s.Conn.(conn.Limits); ok {
+		return l.MaxTxSize()
+	}
+	return 0
+}
+
+// CLK implements spi.Pins.
+func (s *SPIConn) CLK() gpio.PinOut {
+	if p, ok := s.Conn.(spi.Pins); ok {
+		return p.CLK()
+	}
+	return gpio.INVALID
+}
+
+// MOSI implements spi.Pins.
+func (s *SPIConn) MOSI() gpio.PinOut {
+	if p, ok := s.Conn.(spi.Pins); ok {
+		return p.MOSI()
+	}
+	return gpio.INVALID
+}
+
+// MISO implements spi.Pins.
+func (s *SPIConn) MISO() gpio.PinIn {
+	if p, ok := s.Conn.(spi.Pins); ok {
+		return p.MISO()
+	}
+	return gpio.INVALID
+}
+
+// CS implements spi.Pins.
+func (s *SPIConn) CS() gpio.PinOut {
+	if p, ok := s.Conn.(spi.Pins); ok {
+		return p.CS()
+	}
+	return gpio.INVALID
+}
+
+//
+
+// OneWire traces the transactions done on a onewire.Bus.
+//
+// Search() is forwarded to Bus and is not traced.
+type OneWire struct {
+	Bus  onewire.Bus
+	Sink Sink
+}
+
+func (o *OneWire) String() string {
+	return o.Bus.String()
+}
+
+// Tx implements onewire.Bus.
+func (o *OneWire) Tx(w, r []byte, power onewire.Pullup) error {
+	e := begin(o.Bus, OneWireType, "Tx")
+	e.W = copyBytes(w)
+	e.Pull = power
+	err := o.Bus.Tx(w, r, power)
+	e.R = copyBytes(r)
+	return end(o.Sink, &e, err)
+}
+
+// Search implements onewire.Bus.
+func (o *OneWire) Search(alarmOnly bool) ([]onewire.Address, error) {
+	return o.Bus.Search(alarmOnly)
+}
+
+// Q implements onewire.Pins.
+func (o *OneWire) Q() gpio.PinIO {
+	if p, ok := o.Bus.(onewire.Pins); ok {
+		return p.Q()
+	}
+	return gpio.INVALID
+}
+
+var _ conn.Conn = &Conn{}
+var _ conn.Limits = &Conn{}
+var _ i2c.BusCloser = &I2C{}
+var _ i2c.MsgBus = &struct {
+	*I2C
+	i2cMsgBus
+}{}
+var _ smbus.Bus = &struct {
+	*I2C
+	i2cSMBus
+}{}
+var _ i2c.Pins = i2cPins{}
+var _ spi.PortCloser = &SPI{}
+var _ spi.Pins = &SPI{}
+var _ spi.Conn = &SPIConn{}
+var _ conn.Limits = &SPIConn{}
+var _ spi.Pins = &SPIConn{}
+var _ onewire.Bus = &OneWire{}
+var _ onewire.Pins = &OneWire{}
@@ -81,6 +81,12 @@ type Opts struct {
 	// to 8 bits, this also disables the dynamic perceptual mapping of intensity
 	// since there is not enough bits of resolution to do it effectively.
 	DisableGlobalPWM bool
+	// Async makes Draw() and Write() return as soon as the frame is queued,
+	// so the next frame can be rendered while the previous one is being sent.
+	//
+	// It uses twice the memory for the raw buffer. An error sending a frame is
+	// returned by the next call. Halt() waits for the frame to be sent.
+	Async bool
 }
 
 // New returns a strip that communicates over SPI to APA102 LEDs.
@@ -105,7 +111,7 @@ func New(p spi.Port, o *Opts) (*Dev, error) {
 	for i := range tail {
 		tail[i] = 0xFF
 	}
-	return &Dev{
+	d := &Dev{
 		Intensity:        o.Intensity,
 		Temperature:      o.Temperature,
 		DisableGlobalPWM: o.DisableGlobalPWM,
@@ -114,7 +120,13 @@ func New(p spi.Port, o *Opts) (*Dev, error) {
 		rawBuf:           buf,
 		pixels:           buf[4 : 4+4*o.NumPixels],
 		rect:             image.Rect(0, 0, o.NumPixels, 1),
-	}, nil
+	}
+	if o.Async {
+		d.q = spi.NewAsync(c)
+		d.s = d.q
+		d.sendBuf = make([]byte, len(buf))
+	}
+	return d, nil
 }
 
 // Dev represents a strip of APA-102 LEDs as a strip connected over a SPI port.
@@ -148,6 +160,9 @@ type Dev struct {
 	rawBuf    []byte          // Raw buffer sent over SPI. Cached to reduce heap fragmentation.
 	pixels    []byte          // Double buffer of pixels, to enable partial painting via Draw(). Effectively points inside rawBuf.
 	rect      image.Rectangle // Device bounds
+	q         spi.AsyncConn   // Set when Opts.Async is true.
+	sendBuf   []byte          // Copy of rawBuf being sent asynchronously.
+	pending   <-chan error    // Result of the transfer of sendBuf in progress.
 }
 
 func (d *Dev) String() string {
@@ -185,7 +200,7 @@ func (d *Dev) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
 		return nil
 	}
 	d.rasterImg(d.pixels, r, src, srcR)
-	return d.s.Tx(d.rawBuf, nil)
+	return d.send()
 }
 
 // Write accepts a stream of raw RGB pixels and sends it as APA102 encoded
@@ -196,7 +211,7 @@ func (d *Dev) Write(pixels []byte) (int, error) {
 	}
 	// Do not touch header and footer.
 	d.raster(d.pixels, pixels, false)
-	err := d.s.Tx(d.rawBuf, nil)
+	err := d.send()
 	return len(pixels), err
 }
 
@@ -211,7 +226,40 @@ func (d *Dev) Halt() error {
 			d.pixels[i] = 0
 		}
 	}
-	return d.s.Tx(d.rawBuf, nil)
+	// Always send synchronously, even if the previous frame failed.
+	err := d.wait()
+	if err1 := spi.TxStream(d.s, d.rawBuf, nil); err == nil {
+		err = err1
+	}
+	return err
+}
+
+//
+
+// send sends rawBuf.
+//
+// When asynchronous, it waits for the previous frame to be sent, then queues
+// a copy of rawBuf and returns immediately.
+func (d *Dev) send() error {
+	if d.q == nil {
+		return spi.TxStream(d.s, d.rawBuf, nil)
+	}
+	if err := d.wait(); err != nil {
+		return err
+	}
+	copy(d.sendBuf, d.rawBuf)
+	d.pending = d.q.TxAsync(d.sendBuf, nil)
+	return nil
+}
+
+// wait waits for the frame being sent asynchronously, if any.
+func (d *Dev) wait() error {
+	if d.pending == nil {
+		return nil
+	}
+	err := <-d.pending
+	d.pending = nil
+	return err
 }
 
 // raster serializes a buffer of RGB bytes to the APA102 SPI format.
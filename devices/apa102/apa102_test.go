@@ -12,6 +12,7 @@ import (
 	"image/color"
 	"image/draw"
 	"io/ioutil"
+	"reflect"
 	"testing"
 
 	"periph.io/x/periph/conn/conntest"
@@ -901,6 +902,52 @@ func TestHalt(t *testing.T) {
 	}
 }
 
+func TestAsync(t *testing.T) {
+	r := spitest.Record{}
+	o := DefaultOpts
+	o.NumPixels = 1
+	o.Temperature = NeutralTemp
+	o.Async = true
+	d, err := New(&r, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := d.Write([]byte{0xFF, 0xFF, 0xFF}); n != 3 || err != nil {
+		t.Fatal(n, err)
+	}
+	// The rendering buffer can be reused while the frame is being sent.
+	img := image.NewNRGBA(d.Bounds())
+	if err := d.Draw(d.Bounds(), img, image.Point{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []conntest.IO{
+		{W: []byte{0x0, 0x0, 0x0, 0x0, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{W: []byte{0x0, 0x0, 0x0, 0x0, 0xe1, 0x0, 0x0, 0x0, 0xff}},
+		{W: []byte{0x0, 0x0, 0x0, 0x0, 0xe1, 0x0, 0x0, 0x0, 0xff}},
+	}
+	if !reflect.DeepEqual(r.Ops, expected) {
+		t.Fatalf("\ngot:  %#v\nwant: %#v\n", r.Ops, expected)
+	}
+}
+
+func TestAsync_err(t *testing.T) {
+	s := spitest.Playback{Playback: conntest.Playback{DontPanic: true}}
+	o := DefaultOpts
+	o.NumPixels = 1
+	o.Async = true
+	d, _ := New(&s, &o)
+	// The error is returned by the next call.
+	if _, err := d.Write([]byte{0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Halt(); err == nil {
+		t.Fatal("expected playback error")
+	}
+}
+
 func TestInit(t *testing.T) {
 	// Catch the "maxB == maxG" line.
 	l := lut{}
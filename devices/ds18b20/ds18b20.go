@@ -79,6 +79,7 @@ func New(o onewire.Bus, addr onewire.Address, resolutionBits int) (*Dev, error)
 type Dev struct {
 	onewire    onewire.Dev // device on 1-wire bus
 	resolution int         // resolution in bits (9..12)
+	poller     physic.Poller
 }
 
 func (d *Dev) String() string {
@@ -86,7 +87,10 @@ func (d *Dev) String() string {
 }
 
 // Halt implements conn.Resource.
+//
+// It stops the continuous sensing, if any.
 func (d *Dev) Halt() error {
+	d.poller.Halt()
 	return nil
 }
 
@@ -105,9 +109,13 @@ func (d *Dev) Sense(e *physic.Env) error {
 }
 
 // SenseContinuous implements physic.SenseEnv.
-func (d *Dev) SenseContinuous(time.Duration) (<-chan physic.Env, error) {
-	// TODO(maruel): Manually poll in a loop via time.NewTicker.
-	return nil, errors.New("ds18b20: not implemented")
+//
+// A conversion is done at every interval. The interval should be larger than
+// the conversion time, see New().
+//
+// To read multiple DS18B20 on the same bus, use a Group instead.
+func (d *Dev) SenseContinuous(interval time.Duration) (<-chan physic.Env, error) {
+	return d.poller.SenseContinuous(interval, d.Sense)
 }
 
 // Precision implements physic.SenseEnv.
@@ -143,6 +151,96 @@ func (d *Dev) LastTemp() (physic.Temperature, error) {
 	return c, nil
 }
 
+// Group is a set of DS18B20 on the same 1-wire bus that are read together.
+//
+// A single conversion is done on all the devices on the bus via ConvertAll,
+// so reading N devices takes one conversion time instead of N.
+type Group struct {
+	bus        onewire.Bus
+	devs       []*Dev
+	resolution int // maximum resolution of all devs
+	poller     physic.Poller
+}
+
+// NewGroup returns a Group to read all the devices devs on bus o.
+//
+// All the devices must be on bus o.
+func NewGroup(o onewire.Bus, devs ...*Dev) (*Group, error) {
+	if len(devs) == 0 {
+		return nil, errors.New("ds18b20: specify at least one device")
+	}
+	g := &Group{bus: o, devs: devs}
+	for _, d := range devs {
+		if d.onewire.Bus != o {
+			return nil, errors.New("ds18b20: " + d.String() + " is not on bus " + o.String())
+		}
+		if d.resolution > g.resolution {
+			g.resolution = d.resolution
+		}
+	}
+	return g, nil
+}
+
+func (g *Group) String() string {
+	return "DS18B20Group{" + g.bus.String() + "}"
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the continuous sensing, if any.
+func (g *Group) Halt() error {
+	g.poller.Halt()
+	return nil
+}
+
+// Sense does a single conversion on all the devices on the bus and reads the
+// temperature of each device of the group into the corresponding item of e.
+//
+// e must have the same length as the number of devices in the group.
+func (g *Group) Sense(e []physic.Env) error {
+	if len(e) != len(g.devs) {
+		return errors.New("ds18b20: e must have one item per device")
+	}
+	if err := ConvertAll(g.bus, g.resolution); err != nil {
+		return err
+	}
+	for i, d := range g.devs {
+		t, err := d.LastTemp()
+		if err != nil {
+			return err
+		}
+		e[i].Temperature = t
+	}
+	return nil
+}
+
+// SenseContinuous senses all the devices of the group at every interval.
+//
+// Each measurement has one item per device, in the order passed to NewGroup.
+// If a measurement fails, the sensing stops and the channel is closed.
+//
+// The application must call Halt() to stop the sensing when done and close
+// the channel.
+func (g *Group) SenseContinuous(interval time.Duration) (<-chan []physic.Env, error) {
+	c := make(chan []physic.Env)
+	poll := func(stop <-chan struct{}) bool {
+		e := make([]physic.Env, len(g.devs))
+		if err := g.Sense(e); err != nil {
+			return false
+		}
+		select {
+		case c <- e:
+			return true
+		case <-stop:
+			return false
+		}
+	}
+	if err := g.poller.Start(interval, poll, func() { close(c) }); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
 //
 
 // busError implements error and onewire.BusError.
@@ -183,4 +281,5 @@ func (d *Dev) readScratchpad() ([]byte, error) {
 var sleep = time.Sleep
 
 var _ conn.Resource = &Dev{}
+var _ conn.Resource = &Group{}
 var _ physic.SenseEnv = &Dev{}
@@ -109,6 +109,177 @@ func TestConvertAll(t *testing.T) {
 	}
 }
 
+func TestSenseContinuous(t *testing.T) {
+	ops := []onewiretest.IO{
+		// Match ROM + Read Scratchpad (init)
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x0, 0x0, 0x3f, 0xff, 0x10, 0x10, 0x3f},
+		},
+		// Match ROM + Convert
+		{
+			W:    []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0x44},
+			Pull: true,
+		},
+		// Match ROM + Read Scratchpad (read temp)
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x0, 0x0, 0x3f, 0xff, 0x10, 0x10, 0x3f},
+		},
+	}
+	var addr onewire.Address = 0x740000070e41ac28
+	bus := onewiretest.Playback{Ops: ops}
+	dev, err := New(&bus, addr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dev.SenseContinuous(0); err == nil {
+		t.Fatal("invalid interval")
+	}
+	// The first measurement is done right away; the next one would only be done
+	// in an hour.
+	c, err := dev.SenseContinuous(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := <-c; e.Temperature != 30*physic.Celsius+physic.ZeroCelsius {
+		t.Fatal(e)
+	}
+	if err := dev.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSenseContinuous_fail(t *testing.T) {
+	ops := []onewiretest.IO{
+		// Match ROM + Read Scratchpad (init)
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x0, 0x0, 0x3f, 0xff, 0x10, 0x10, 0x3f},
+		},
+	}
+	var addr onewire.Address = 0x740000070e41ac28
+	bus := onewiretest.Playback{Ops: ops, DontPanic: true}
+	dev, err := New(&bus, addr, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := dev.SenseContinuous(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The conversion fails, which closes the channel.
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	if err := dev.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	ops := []onewiretest.IO{
+		// Match ROM + Read Scratchpad (init of first device)
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x0, 0x0, 0x3f, 0xff, 0x10, 0x10, 0x3f},
+		},
+		// Match ROM + Read Scratchpad (init of second device)
+		{
+			W: []uint8{0x55, 0x28, 0x12, 0x34, 0x56, 0x7, 0x0, 0x0, 0x9a, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x0, 0x0, 0x7f, 0xff, 0x10, 0x10, 0xdf},
+		},
+		// Skip ROM + Convert, for the highest resolution
+		{W: []uint8{0xcc, 0x44}, Pull: true},
+		// Match ROM + Read Scratchpad (read temp of first device)
+		{
+			W: []uint8{0x55, 0x28, 0xac, 0x41, 0xe, 0x7, 0x0, 0x0, 0x74, 0xbe},
+			R: []uint8{0xe0, 0x1, 0x0, 0x0, 0x3f, 0xff, 0x10, 0x10, 0x3f},
+		},
+		// Match ROM + Read Scratchpad (read temp of second device)
+		{
+			W: []uint8{0x55, 0x28, 0x12, 0x34, 0x56, 0x7, 0x0, 0x0, 0x9a, 0xbe},
+			R: []uint8{0x50, 0x1, 0x0, 0x0, 0x7f, 0xff, 0x10, 0x10, 0xb1},
+		},
+	}
+	bus := onewiretest.Playback{Ops: ops}
+	dev1, err := New(&bus, 0x740000070e41ac28, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev2, err := New(&bus, 0x9a00000756341228, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGroup(&bus, dev1, dev2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.String(); s != "DS18B20Group{playback}" {
+		t.Fatal(s)
+	}
+	var sleeps []time.Duration
+	sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	defer func() { sleep = func(time.Duration) {} }()
+	c, err := g.SenseContinuous(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := <-c
+	expected := []physic.Env{
+		{Temperature: 30*physic.Celsius + physic.ZeroCelsius},
+		{Temperature: 21*physic.Celsius + physic.ZeroCelsius},
+	}
+	if !reflect.DeepEqual(e, expected) {
+		t.Fatal(e)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+	// A single conversion at the highest resolution was done.
+	if !reflect.DeepEqual(sleeps, []time.Duration{752 * time.Millisecond}) {
+		t.Fatal(sleeps)
+	}
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroup_fail(t *testing.T) {
+	bus := onewiretest.Playback{DontPanic: true}
+	if _, err := NewGroup(&bus); err == nil {
+		t.Fatal("no device")
+	}
+	other := onewiretest.Playback{}
+	d := &Dev{onewire: onewire.Dev{Bus: &other, Addr: 0x740000070e41ac28}, resolution: 9}
+	if _, err := NewGroup(&bus, d); err == nil {
+		t.Fatal("device is on another bus")
+	}
+	d.onewire.Bus = &bus
+	g, err := NewGroup(&bus, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Sense(make([]physic.Env, 2)); err == nil {
+		t.Fatal("e is too long")
+	}
+	if err := g.Sense(make([]physic.Env, 1)); err == nil {
+		t.Fatal("ConvertAll failed")
+	}
+	if _, err := g.SenseContinuous(0); err == nil {
+		t.Fatal("invalid interval")
+	}
+}
+
 func TestConvertAll_fail_resolution(t *testing.T) {
 	bus := &onewiretest.Playback{}
 	if err := ConvertAll(bus, 1); err == nil {
@@ -0,0 +1,30 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package ds248x
+
+import (
+	"context"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/onewire"
+)
+
+// TxContext implements onewire.BusContext.
+//
+// The transaction is aborted between two bytes or while polling for the
+// 1-wire bus to be idle, so the bus is never left in the middle of a byte.
+func (d *Dev) TxContext(ctx context.Context, w, r []byte, power onewire.Pullup) error {
+	if err := conn.ContextErr(ctx, d.String()+".Tx"); err != nil {
+		return err
+	}
+	if err := d.tx(ctx.Done(), w, r, power); err != errAborted {
+		return err
+	}
+	return conn.ContextErr(ctx, d.String()+".Tx")
+}
+
+var _ onewire.BusContext = &Dev{}
@@ -0,0 +1,113 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package ds248x
+
+import (
+	"context"
+	"testing"
+
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/onewire"
+)
+
+func TestTxContext_canceled(t *testing.T) {
+	bus := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x18, W: []byte{0xf0}},
+			{Addr: 0x18, W: []byte{0xe1, 0xf0}, R: []byte{0x18}},
+			{Addr: 0x18, W: []byte{0xd2, 0xe1}, R: []byte{0x1}},
+			{Addr: 0x18, W: []byte{0xe1, 0xb4}},
+			{Addr: 0x18, W: []byte{0xc3, 0x6, 0x26, 0x46, 0x66, 0x86}},
+		},
+	}
+	d, err := New(&bus, 0x18, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.TxContext(ctx, []byte{0xcc}, nil, onewire.WeakPullup); err != context.Canceled {
+		t.Fatal(err)
+	}
+	// No I²C transaction must have happened.
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTxContext_canceledLastRead(t *testing.T) {
+	bus := cancelBus{
+		Playback: i2ctest.Playback{
+			Ops: []i2ctest.IO{
+				{Addr: 0x18, W: []byte{0xf0}},
+				{Addr: 0x18, W: []byte{0xe1, 0xf0}, R: []byte{0x18}},
+				{Addr: 0x18, W: []byte{0xd2, 0xe1}, R: []byte{0x1}},
+				{Addr: 0x18, W: []byte{0xe1, 0xb4}},
+				{Addr: 0x18, W: []byte{0xc3, 0x6, 0x26, 0x46, 0x66, 0x86}},
+				// Reset, a device is present.
+				{Addr: 0x18, W: []byte{0xb4}},
+				{Addr: 0x18, R: []byte{0x2}},
+				// Write Skip ROM.
+				{Addr: 0x18, W: []byte{0xa5, 0xcc}},
+				{Addr: 0x18, R: []byte{0x0}},
+				// Read one byte; the context is canceled while the bus is busy.
+				{Addr: 0x18, W: []byte{0x96}, R: []byte{0x0}},
+				{Addr: 0x18, R: []byte{0x1}},
+			},
+		},
+	}
+	d, err := New(&bus, 0x18, &DefaultOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus.cancelAt = len(bus.Ops)
+	bus.cancel = cancel
+	if err := d.TxContext(ctx, []byte{0xcc}, make([]byte, 1), onewire.WeakPullup); err != context.Canceled {
+		t.Fatal(err)
+	}
+	// The read-data register must not be read.
+	if err := bus.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAborted(t *testing.T) {
+	d := &Dev{}
+	if d.aborted() {
+		t.Fatal("nil done channel must never abort")
+	}
+	done := make(chan struct{})
+	d.done = done
+	if d.aborted() {
+		t.Fatal("not yet aborted")
+	}
+	close(done)
+	if !d.aborted() {
+		t.Fatal("expected aborted")
+	}
+}
+
+//
+
+// cancelBus calls cancel once cancelAt transactions were done.
+type cancelBus struct {
+	i2ctest.Playback
+	cancelAt int
+	cancel   func()
+}
+
+func (c *cancelBus) Tx(addr uint16, w, r []byte) error {
+	if err := c.Playback.Tx(addr, w, r); err != nil {
+		return err
+	}
+	if c.Count == c.cancelAt && c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
@@ -82,13 +82,14 @@ func New(i i2c.Bus, addr uint16, opts *Opts) (*Dev, error) {
 // do not cause persistent errors and implement the onewire.BusError interface
 // to indicate this fact.
 type Dev struct {
-	sync.Mutex               // lock for the bus while a transaction is in progress
-	i2c        conn.Conn     // i2c device handle for the ds248x
-	isDS2483   bool          // true: ds2483, false: ds2482-100
-	confReg    byte          // value written to configuration register
-	tReset     time.Duration // time to perform a 1-wire reset
-	tSlot      time.Duration // time to perform a 1-bit 1-wire read/write
-	err        error         // persistent error, device will no longer operate
+	sync.Mutex                 // lock for the bus while a transaction is in progress
+	i2c        conn.Conn       // i2c device handle for the ds248x
+	isDS2483   bool            // true: ds2483, false: ds2482-100
+	confReg    byte            // value written to configuration register
+	tReset     time.Duration   // time to perform a 1-wire reset
+	tSlot      time.Duration   // time to perform a 1-bit 1-wire read/write
+	err        error           // persistent error, device will no longer operate
+	done       <-chan struct{} // closed to abort the transaction in progress
 }
 
 func (d *Dev) String() string {
@@ -110,18 +111,38 @@ func (d *Dev) Halt() error {
 // A strong pull-up is typically required to power temperature conversion or
 // EEPROM writes.
 func (d *Dev) Tx(w, r []byte, power onewire.Pullup) error {
+	return d.tx(nil, w, r, power)
+}
+
+// tx implements Tx.
+//
+// It returns errAborted between two bytes or while waiting for the bus to be
+// idle if done is closed. done can be nil.
+func (d *Dev) tx(done <-chan struct{}, w, r []byte, power onewire.Pullup) error {
 	d.Lock()
 	defer d.Unlock()
+	d.done = done
+	defer func() {
+		d.done = nil
+	}()
 
 	// Issue 1-wire bus reset.
-	if present, err := d.reset(); err != nil {
+	present, err := d.reset()
+	if err != nil {
 		return err
-	} else if !present {
+	}
+	if d.aborted() {
+		return errAborted
+	}
+	if !present {
 		return busError("ds248x: no device present")
 	}
 
 	// Send bytes onto 1-wire bus.
 	for i, b := range w {
+		if d.aborted() {
+			return errAborted
+		}
 		if power == onewire.StrongPullup && i == len(w)-1 && len(r) == 0 {
 			// This is the last byte, need to activate strong pull-up.
 			d.i2cTx([]byte{cmdWriteConfig, d.confReg&0xbf | 0x4}, nil)
@@ -132,15 +153,26 @@ func (d *Dev) Tx(w, r []byte, power onewire.Pullup) error {
 
 	// Read bytes from one-wire bus.
 	for i := range r {
+		if d.aborted() {
+			return errAborted
+		}
 		if power == onewire.StrongPullup && i == len(r)-1 {
 			// This is the last byte, need to activate strong-pull-up
 			d.i2cTx([]byte{cmdWriteConfig, d.confReg&0xbf | 0x4}, nil)
 		}
 		d.i2cTx([]byte{cmd1WRead}, r[i:i+1])
 		d.waitIdle(7 * d.tSlot)
+		// waitIdle returns early when aborted; the read-data register would be
+		// stale.
+		if d.aborted() {
+			return errAborted
+		}
 		d.i2cTx([]byte{cmdSetReadPtr, regRDR}, r[i:i+1])
 	}
 
+	if d.aborted() {
+		return errAborted
+	}
 	return d.err
 }
 
@@ -234,11 +266,25 @@ func (d *Dev) waitIdle(delay time.Duration) byte {
 			d.err = fmt.Errorf("ds248x: timeout waiting for bus cycle to finish")
 			return 0
 		}
+		// The caller gave up; this is not an error with the ds248x.
+		if d.aborted() {
+			return 0
+		}
 		// Try not to hog the kernel thread.
 		sleep(delay / 10)
 	}
 }
 
+// aborted returns true if the transaction in progress was aborted.
+func (d *Dev) aborted() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func (d *Dev) makeDev(opts *Opts) error {
 	d.tReset = 2 * opts.ResetLow
 	d.tSlot = opts.Write0Low + opts.Write0Recovery
@@ -310,6 +356,9 @@ type busError string
 func (e busError) Error() string  { return string(e) }
 func (e busError) BusError() bool { return true }
 
+// errAborted is returned by tx when the transaction was aborted.
+var errAborted = errors.New("ds248x: transaction aborted")
+
 var sleep = time.Sleep
 
 var _ conn.Resource = &Dev{}
@@ -65,6 +65,12 @@ type Opts struct {
 	H int
 	// Rotated determines if the display is rotated by 180°.
 	Rotated bool
+	// Async makes Draw() and Write() return as soon as the frame is queued,
+	// so the next frame can be rendered while the previous one is being sent.
+	//
+	// An error sending a frame is returned by the next call. Only supported
+	// over SPI; it is ignored by NewI2C().
+	Async bool
 }
 
 // NewSPI returns a Dev object that communicates over SPI to a SSD1306 display
@@ -98,6 +104,9 @@ func NewSPI(p spi.Port, dc gpio.PinOut, opts *Opts) (*Dev, error) {
 	if err != nil {
 		return nil, err
 	}
+	if opts.Async {
+		c = spi.NewAsync(c)
+	}
 	return newDev(c, opts, true, dc)
 }
 
@@ -114,6 +123,10 @@ type Dev struct {
 	c   conn.Conn
 	dc  gpio.PinOut
 	spi bool
+	// q is set when Opts.Async is true. pending is the result of the data
+	// transfer in progress, which reads from buffer.
+	q       spi.AsyncConn
+	pending <-chan error
 
 	// Display size controlled by the SSD1306.
 	rect image.Rectangle
@@ -287,6 +300,9 @@ func newDev(c conn.Conn, opts *Opts, usingSPI bool, dc gpio.PinOut) (*Dev, error
 		// Signal that the screen must be redrawn on first draw().
 		scrolled: true,
 	}
+	if a, ok := c.(spi.AsyncConn); ok && usingSPI {
+		d.q = a
+	}
 	if err := d.sendCommand(getInitCmd(opts.W, opts.H, opts.Rotated)); err != nil {
 		return nil, err
 	}
@@ -403,6 +419,10 @@ func (d *Dev) drawInternal(next []byte) error {
 	if skip {
 		return nil
 	}
+	// buffer is being sent when asynchronous.
+	if err := d.wait(); err != nil {
+		return err
+	}
 	copy(d.buffer, next)
 
 	if d.startPage != startPage || d.endPage != endPage || d.startCol != startCol || d.endCol != endCol {
@@ -433,9 +453,16 @@ func (d *Dev) sendData(c []byte) error {
 	}
 	if d.spi {
 		// 4-wire SPI.
+		if err := d.wait(); err != nil {
+			return err
+		}
 		if err := d.dc.Out(gpio.High); err != nil {
 			return err
 		}
+		if d.q != nil {
+			d.pending = d.q.TxAsync(c, nil)
+			return nil
+		}
 		return d.c.Tx(c, nil)
 	}
 	return d.c.Tx(append([]byte{i2cData}, c...), nil)
@@ -453,6 +480,9 @@ func (d *Dev) sendCommand(c []byte) error {
 			return errors.New("ssd1306: 3-wire SPI mode is not yet implemented")
 		}
 		// 4-wire SPI.
+		if err := d.wait(); err != nil {
+			return err
+		}
 		if err := d.dc.Out(gpio.Low); err != nil {
 			return err
 		}
@@ -461,6 +491,18 @@ func (d *Dev) sendCommand(c []byte) error {
 	return d.c.Tx(append([]byte{i2cCmd}, c...), nil)
 }
 
+// wait waits for the data being sent asynchronously, if any.
+//
+// dc must not be changed until then.
+func (d *Dev) wait() error {
+	if d.pending == nil {
+		return nil
+	}
+	err := <-d.pending
+	d.pending = nil
+	return err
+}
+
 const (
 	i2cCmd  = 0x00 // I²C transaction has stream of command bytes
 	i2cData = 0x40 // I²C transaction has stream of data bytes
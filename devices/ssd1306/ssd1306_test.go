@@ -465,6 +465,76 @@ func TestSPI_4wire_Write_differential_fail(t *testing.T) {
 	}
 }
 
+func TestSPI_4wire_Async(t *testing.T) {
+	buf1 := make([]byte, 1024)
+	buf1[130] = 1
+	buf2 := make([]byte, 128)
+	buf2[130-128] = 1
+	buf2[131-128] = 2
+	port := spitest.Playback{
+		Playback: conntest.Playback{
+			Ops: []conntest.IO{
+				{W: getInitCmd(128, 64, false)},
+				{W: buf1},
+				// Reset to write only to the first page.
+				{W: []byte{0x21, 0x0, 0x7f, 0x22, 0x1, 0x1}},
+				{W: buf2},
+				{W: []byte{0xAE}},
+			},
+		},
+	}
+	opts := DefaultOpts
+	opts.Async = true
+	pin := &gpiotest.Pin{N: "pin1", Num: 42}
+	dev, err := NewSPI(&port, pin, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix := make([]byte, 1024)
+	pix[130] = 1
+	if n, err := dev.Write(pix); n != len(pix) || err != nil {
+		t.Fatal(n, err)
+	}
+	// pix can be modified while the frame is being sent.
+	pix[131] = 2
+	if n, err := dev.Write(pix); n != len(pix) || err != nil {
+		t.Fatal(n, err)
+	}
+	if err := dev.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if pin.L != gpio.Low {
+		t.Fatal("dc must be low for a command")
+	}
+	if err := port.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSPI_4wire_Async_fail(t *testing.T) {
+	port := spitest.Playback{
+		Playback: conntest.Playback{
+			Ops:       []conntest.IO{{W: getInitCmd(128, 64, false)}},
+			DontPanic: true,
+		},
+	}
+	opts := DefaultOpts
+	opts.Async = true
+	dev, err := NewSPI(&port, &gpiotest.Pin{N: "pin1", Num: 42}, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix := make([]byte, 1024)
+	pix[130] = 1
+	if n, err := dev.Write(pix); n != len(pix) || err != nil {
+		t.Fatal(n, err)
+	}
+	// The error of the previous frame is returned by the next call.
+	if err := dev.Halt(); !conntest.IsErr(err) {
+		t.Fatalf("expected conntest error: %v", err)
+	}
+}
+
 func TestSPI_4wire_gpio_fail(t *testing.T) {
 	port := spitest.Playback{
 		Playback: conntest.Playback{
@@ -7,35 +7,76 @@ package analog
 
 import (
 	"errors"
+	"strconv"
 
+	"periph.io/x/periph/conn/physic"
 	"periph.io/x/periph/conn/pin"
 )
 
+// Sample is one analog sample.
+//
+// It carries both the raw value as used by the ADC or the DAC and the
+// corresponding electric potential, as calculated by the driver.
+type Sample struct {
+	// V is the electric potential.
+	V physic.ElectricPotential
+	// Raw is the raw value, in counts.
+	Raw int32
+}
+
+func (s Sample) String() string {
+	return s.V.String() + " (" + strconv.Itoa(int(s.Raw)) + ")"
+}
+
 // ADC is an analog-to-digital-conversion input.
 type ADC interface {
 	pin.Pin
 	// Range returns the maximum supported range [min, max] of the values.
-	Range() (int32, int32)
+	Range() (Sample, Sample)
 	// Read returns the current pin level.
-	Read() int32
+	Read() (Sample, error)
 }
 
 // DAC is an digital-to-analog-conversion output.
 type DAC interface {
 	pin.Pin
 	// Range returns the maximum supported range [min, max] of the values.
-	Range() (int32, int32)
+	Range() (Sample, Sample)
 	// Out sets an analog output value.
-	DAC(v int32)
+	//
+	// v.V is used; the driver converts it to the closest raw value supported
+	// and ignores v.Raw.
+	Out(v Sample) error
 }
 
-// INVALID implements both ADC and DAC and fails on all access.
-var INVALID invalidPin
+// PinIO is an analog pin that supports both input and output. It matches both
+// interfaces ADC and DAC.
+//
+// An analog pin implementing PinIO may fail at either input or output or both.
+type PinIO interface {
+	pin.Pin
+	// ADC
+	Range() (Sample, Sample)
+	Read() (Sample, error)
+	// DAC
+	Out(v Sample) error
+}
+
+// RealPin is implemented by aliased pin and allows the retrieval of the real
+// pin underlying an alias.
+//
+// Aliases are created by analogreg.RegisterAlias.
+type RealPin interface {
+	Real() PinIO // Real returns the real pin behind an Alias
+}
+
+// INVALID implements PinIO and fails on all access.
+var INVALID PinIO = invalidPin{}
 
 //
 
 // errInvalidPin is returned when trying to use INVALID.
-var errInvalidPin = errors.New("invalid pin")
+var errInvalidPin = errors.New("analog: invalid pin")
 
 // invalidPin implements PinIO for compatibility but fails on all access.
 type invalidPin struct {
@@ -53,17 +94,25 @@ func (invalidPin) String() string {
 	return "INVALID"
 }
 
+func (invalidPin) Halt() error {
+	return nil
+}
+
 func (invalidPin) Function() string {
 	return ""
 }
 
-func (invalidPin) Range() (int32, int32) {
-	return 0, 0
+func (invalidPin) Range() (Sample, Sample) {
+	return Sample{}, Sample{}
 }
 
-func (invalidPin) Read() int32 {
-	return 0
+func (invalidPin) Read() (Sample, error) {
+	return Sample{}, errInvalidPin
 }
 
-func (invalidPin) DAC(v int32) {
+func (invalidPin) Out(v Sample) error {
+	return errInvalidPin
 }
+
+var _ ADC = INVALID
+var _ DAC = INVALID
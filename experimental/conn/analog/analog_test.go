@@ -0,0 +1,35 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analog
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestSample_String(t *testing.T) {
+	if s := (Sample{V: 1500 * physic.MilliVolt, Raw: 1861}).String(); s != "1.500V (1861)" {
+		t.Fatal(s)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	if s := INVALID.String(); s != "INVALID" {
+		t.Fatal(s)
+	}
+	if n := INVALID.Number(); n != -1 {
+		t.Fatal(n)
+	}
+	if min, max := INVALID.Range(); min != (Sample{}) || max != (Sample{}) {
+		t.Fatal(min, max)
+	}
+	if _, err := INVALID.Read(); err == nil {
+		t.Fatal("expected failure")
+	}
+	if INVALID.Out(Sample{}) == nil {
+		t.Fatal("expected failure")
+	}
+}
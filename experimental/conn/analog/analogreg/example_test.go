@@ -0,0 +1,41 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analogreg_test
+
+import (
+	"fmt"
+	"log"
+
+	"periph.io/x/periph/experimental/conn/analog/analogreg"
+	_ "periph.io/x/periph/experimental/host/iio"
+	"periph.io/x/periph/host"
+)
+
+func Example() {
+	// Make sure periph is initialized. The iio driver is registered by the
+	// blank import above.
+	if _, err := host.Init(); err != nil {
+		log.Fatal(err)
+	}
+
+	// A command line tool may use the pin name as an argument.
+	p := analogreg.ByName("IIO0_IN0")
+	if p == nil {
+		log.Fatal("Failed to find IIO0_IN0")
+	}
+	s, err := p.Read()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %s\n", p, s)
+}
+
+func ExampleAll() {
+	fmt.Print("Analog pins available:\n")
+	for _, p := range analogreg.All() {
+		min, max := p.Range()
+		fmt.Printf("- %s: %s to %s\n", p, min.V, max.V)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package analogtest is meant to be used to test drivers using fake analog
+// pins.
+package analogtest
+
+import (
+	"fmt"
+	"sync"
+
+	"periph.io/x/periph/experimental/conn/analog"
+)
+
+// Pin implements analog.PinIO.
+//
+// Modify its members to simulate hardware events.
+type Pin struct {
+	// These should be immutable.
+	N   string
+	Num int
+	Fn  string
+	Min analog.Sample // Returned by Range()
+	Max analog.Sample // Returned by Range()
+
+	// Grab the Mutex before accessing the following members.
+	sync.Mutex
+	S   analog.Sample // Used for both input and output
+	Err error         // Returned by Read() and Out() when set
+}
+
+// String implements conn.Resource.
+func (p *Pin) String() string {
+	return fmt.Sprintf("%s(%d)", p.N, p.Num)
+}
+
+// Halt implements conn.Resource.
+//
+// It has no effect.
+func (p *Pin) Halt() error {
+	return nil
+}
+
+// Name implements pin.Pin.
+func (p *Pin) Name() string {
+	return p.N
+}
+
+// Number implements pin.Pin.
+func (p *Pin) Number() int {
+	return p.Num
+}
+
+// Function implements pin.Pin.
+func (p *Pin) Function() string {
+	return p.Fn
+}
+
+// Range implements analog.ADC and analog.DAC.
+func (p *Pin) Range() (analog.Sample, analog.Sample) {
+	return p.Min, p.Max
+}
+
+// Read implements analog.ADC.
+func (p *Pin) Read() (analog.Sample, error) {
+	p.Lock()
+	defer p.Unlock()
+	return p.S, p.Err
+}
+
+// Out implements analog.DAC.
+//
+// The raw value is calculated from v.V with a linear interpolation between Min
+// and Max when they differ, otherwise v is stored as is.
+func (p *Pin) Out(v analog.Sample) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.Err != nil {
+		return p.Err
+	}
+	if dv := p.Max.V - p.Min.V; dv != 0 {
+		v.Raw = p.Min.Raw + int32(int64(v.V-p.Min.V)*int64(p.Max.Raw-p.Min.Raw)/int64(dv))
+	}
+	p.S = v
+	return nil
+}
+
+var _ analog.PinIO = &Pin{}
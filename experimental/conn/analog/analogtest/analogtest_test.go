@@ -0,0 +1,68 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package analogtest
+
+import (
+	"errors"
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/conn/analog"
+)
+
+func TestPin(t *testing.T) {
+	p := &Pin{
+		N:   "ADC1",
+		Num: 1,
+		Fn:  "ADC",
+		Max: analog.Sample{V: 3300 * physic.MilliVolt, Raw: 4095},
+		S:   analog.Sample{V: physic.Volt, Raw: 1241},
+	}
+	if s := p.String(); s != "ADC1(1)" {
+		t.Fatal(s)
+	}
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Name(); s != "ADC1" {
+		t.Fatal(s)
+	}
+	if n := p.Number(); n != 1 {
+		t.Fatal(n)
+	}
+	if s := p.Function(); s != "ADC" {
+		t.Fatal(s)
+	}
+	if min, max := p.Range(); min.Raw != 0 || max.Raw != 4095 {
+		t.Fatal(min, max)
+	}
+	if s, err := p.Read(); err != nil || s.Raw != 1241 {
+		t.Fatal(s, err)
+	}
+	if err := p.Out(analog.Sample{V: 1650 * physic.MilliVolt}); err != nil {
+		t.Fatal(err)
+	}
+	if p.S.Raw != 2047 {
+		t.Fatal(p.S)
+	}
+	p.Err = errors.New("oops")
+	if _, err := p.Read(); err == nil {
+		t.Fatal("expected failure")
+	}
+	if p.Out(analog.Sample{}) == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestPin_no_range(t *testing.T) {
+	p := &Pin{N: "DAC0"}
+	v := analog.Sample{V: physic.Volt, Raw: 42}
+	if err := p.Out(v); err != nil {
+		t.Fatal(err)
+	}
+	if p.S != v {
+		t.Fatal(p.S)
+	}
+}
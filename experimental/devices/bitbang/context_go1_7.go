@@ -0,0 +1,30 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package bitbang
+
+import (
+	"context"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/i2c"
+)
+
+// TxContext implements i2c.BusContext.
+//
+// The transaction is aborted between two bytes or while the device stretches
+// the clock. A STOP condition is always sent.
+func (i *I2C) TxContext(ctx context.Context, addr uint16, w, r []byte) error {
+	if err := conn.ContextErr(ctx, i.String()+".Tx"); err != nil {
+		return err
+	}
+	if err := i.tx(ctx.Done(), addr, w, r); err != errAborted {
+		return err
+	}
+	return conn.ContextErr(ctx, i.String()+".Tx")
+}
+
+var _ i2c.BusContext = &I2C{}
@@ -63,6 +63,7 @@ type I2C struct {
 	scl       gpio.PinIO // Clock line
 	sda       gpio.PinIO // Data line
 	halfCycle time.Duration
+	done      <-chan struct{} // Closed to abort the transaction in progress.
 }
 
 func (i *I2C) String() string {
@@ -76,8 +77,20 @@ func (i *I2C) Close() error {
 
 // Tx implements i2c.Bus.
 func (i *I2C) Tx(addr uint16, w, r []byte) error {
+	return i.tx(nil, addr, w, r)
+}
+
+// tx implements Tx.
+//
+// It returns errAborted between two bytes or while the device stretches the
+// clock if done is closed. done can be nil.
+func (i *I2C) tx(done <-chan struct{}, addr uint16, w, r []byte) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
+	i.done = done
+	defer func() {
+		i.done = nil
+	}()
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 	//syscall.Setpriority(which, who, prio)
@@ -104,6 +117,9 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 		}
 	}
 	for _, b := range w {
+		if i.aborted() {
+			return errAborted
+		}
 		ack, err := i.writeByte(b)
 		if err != nil {
 			return err
@@ -113,8 +129,11 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 		}
 	}
 	for x := range r {
+		if i.aborted() {
+			return errAborted
+		}
 		var err error
-		r[x], err = i.readByte()
+		r[x], err = i.readByte(x != len(r)-1)
 		if err != nil {
 			return err
 		}
@@ -122,6 +141,55 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 	return nil
 }
 
+// TxMsgs implements i2c.MsgBus.
+//
+// SkipAddr is not supported as a message address.
+func (i *I2C) TxMsgs(m []i2c.Msg) error {
+	for j := range m {
+		if len(m[j].W) != 0 && len(m[j].R) != 0 {
+			return errors.New("bitbang-i2c: a message can't both read and write")
+		}
+		if m[j].Addr > 0x7F && (m[j].Flags&i2c.Ten == 0 || m[j].Addr > 0x3FF) {
+			return errors.New("bitbang-i2c: invalid address")
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	i.start()
+	defer i.stop()
+	for j := range m {
+		ignoreNAK := m[j].Flags&i2c.IgnoreNAK != 0
+		if j == 0 || m[j].Flags&i2c.NoStart == 0 {
+			if j != 0 {
+				i.restart()
+			}
+			if err := i.writeAddr(m[j].Addr, m[j].Flags&i2c.Ten != 0, len(m[j].R) != 0, ignoreNAK); err != nil {
+				return err
+			}
+		}
+		for _, b := range m[j].W {
+			if err := i.send(b, ignoreNAK); err != nil {
+				return err
+			}
+		}
+		// The last byte of a read message is NACKed so the device releases SDA
+		// before the next START or the STOP condition.
+		for x := range m[j].R {
+			var err error
+			if m[j].R[x], err = i.readByte(x != len(m[j].R)-1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // SetSpeed implements i2c.Bus.
 func (i *I2C) SetSpeed(f physic.Frequency) error {
 	i.mu.Lock()
@@ -159,10 +227,14 @@ func (i *I2C) start() {
 // "When CLK is a high level and DIO changes from low level to high level, data
 // input ends."
 //
+// Ends with SDA and SCL high.
+//
 // Lasts 3/2 cycle.
 func (i *I2C) stop() {
 	// Page 9, section 3.1.4 START and STOP conditions
 	_ = i.scl.Out(gpio.Low)
+	// SDA may be high after a NACK; it must be low for SCL to rise before it.
+	_ = i.sda.Out(gpio.Low)
 	i.sleepHalfCycle()
 	_ = i.scl.Out(gpio.High)
 	i.sleepHalfCycle()
@@ -172,11 +244,67 @@ func (i *I2C) stop() {
 	i.sleepHalfCycle()
 }
 
+// restart sends a repeated START condition.
+//
+// Expects SCL low.
+//
+// Ends with SDA and SCL low.
+//
+// Lasts 3/2 cycle.
+func (i *I2C) restart() {
+	// Page 9, section 3.1.4 START and STOP conditions
+	_ = i.sda.Out(gpio.High)
+	i.sleepHalfCycle()
+	_ = i.scl.Out(gpio.High)
+	i.sleepHalfCycle()
+	i.start()
+}
+
+// writeAddr sends the address with the R/W bit.
+func (i *I2C) writeAddr(addr uint16, ten, read, ignoreNAK bool) error {
+	var rw byte
+	if read {
+		rw = 1
+	}
+	if !ten {
+		// Page 13, section 3.1.10 The slave address and R/W bit
+		return i.send(byte(addr<<1)|rw, ignoreNAK)
+	}
+	// Page 15, section 3.1.11 10-bit addressing
+	// The first byte is 0b11110xx0 with the 2 MSB of the address, then the 8 LSB.
+	hi := byte(0xF0 | (addr>>7)&0x06)
+	if err := i.send(hi, ignoreNAK); err != nil {
+		return err
+	}
+	if err := i.send(byte(addr), ignoreNAK); err != nil {
+		return err
+	}
+	if read {
+		// A read requires a repeated START followed by the first byte with R/W
+		// set.
+		i.restart()
+		return i.send(hi|1, ignoreNAK)
+	}
+	return nil
+}
+
+// send writes a byte and returns an error on NACK unless ignoreNAK is set.
+func (i *I2C) send(b byte, ignoreNAK bool) error {
+	ack, err := i.writeByte(b)
+	if err != nil {
+		return err
+	}
+	if !ack && !ignoreNAK {
+		return errors.New("bitbang-i2c: got NACK")
+	}
+	return nil
+}
+
 // writeByte writes 8 bits then waits for ACK.
 //
-// Expects SDA and SCL low.
+// Expects SCL low.
 //
-// Ends with SDA low and SCL high.
+// Ends with SDA and SCL low.
 //
 // Lasts 9 cycles.
 func (i *I2C) writeByte(b byte) (bool, error) {
@@ -195,17 +323,22 @@ func (i *I2C) writeByte(b byte) (bool, error) {
 	}
 	// Page 10, section 3.1.6 ACK and NACK
 	// 9th clock is ACK.
+	// SDA must be released while SCL is low, otherwise a NACK would be seen as
+	// a STOP condition.
+	// SDA was already set as pull-up.
+	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		return false, err
+	}
 	i.sleepHalfCycle()
 	// SCL was already set as pull-up. PullNoChange
 	if err := i.scl.In(gpio.PullUp, gpio.NoEdge); err != nil {
 		return false, err
 	}
-	// SDA was already set as pull-up.
-	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
-		return false, err
-	}
 	// Implement clock stretching, the device may keep the line low.
 	for i.scl.Read() == gpio.Low {
+		if i.aborted() {
+			return false, errAborted
+		}
 		i.sleepHalfCycle()
 	}
 	// ACK == Low.
@@ -219,14 +352,14 @@ func (i *I2C) writeByte(b byte) (bool, error) {
 	return ack, nil
 }
 
-// readByte reads 8 bits and an ACK.
+// readByte reads 8 bits then sends an ACK, or a NACK if ack is false.
 //
-// Expects SDA and SCL low.
+// Expects SCL low.
 //
-// Ends with SDA low and SCL high.
+// Ends with SCL low and SDA low on ACK, high on NACK.
 //
 // Lasts 9 cycles.
-func (i *I2C) readByte() (byte, error) {
+func (i *I2C) readByte(ack bool) (byte, error) {
 	var b byte
 	if err := i.sda.In(gpio.PullUp, gpio.NoEdge); err != nil {
 		return b, err
@@ -241,18 +374,35 @@ func (i *I2C) readByte() (byte, error) {
 		}
 		_ = i.scl.Out(gpio.Low)
 	}
-	if err := i.sda.Out(gpio.Low); err != nil {
+	// Page 10, section 3.1.6 ACK and NACK
+	// ACK == Low.
+	if err := i.sda.Out(gpio.Level(!ack)); err != nil {
 		return 0, err
 	}
 	i.sleepHalfCycle()
 	_ = i.scl.Out(gpio.High)
 	i.sleepHalfCycle()
+	_ = i.scl.Out(gpio.Low)
 	return b, nil
 }
 
+// aborted returns true if the transaction in progress was aborted.
+func (i *I2C) aborted() bool {
+	select {
+	case <-i.done:
+		return true
+	default:
+		return false
+	}
+}
+
 // sleep does a busy loop to act as fast as possible.
 func (i *I2C) sleepHalfCycle() {
 	cpu.Nanospin(i.halfCycle)
 }
 
+// errAborted is returned by tx when the transaction was aborted.
+var errAborted = errors.New("bitbang-i2c: transaction aborted")
+
 var _ i2c.Bus = &I2C{}
+var _ i2c.MsgBus = &I2C{}
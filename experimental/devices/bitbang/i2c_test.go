@@ -0,0 +1,168 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bitbang
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+	"periph.io/x/periph/conn/i2c"
+)
+
+func TestI2C_TxMsgs(t *testing.T) {
+	b, l := newRecorded(t)
+	// Nothing pulls the lines low so every ACK from the device reads as a NACK
+	// and every byte read is 0xFF.
+	r := make([]byte, 2)
+	m := []i2c.Msg{
+		{Addr: 0x23, W: []byte{0x10}, Flags: i2c.IgnoreNAK},
+		{Addr: 0x23, R: r, Flags: i2c.IgnoreNAK},
+		{Addr: 0x23, W: []byte{0x20}, Flags: i2c.IgnoreNAK},
+	}
+	if err := b.TxMsgs(m); err != nil {
+		t.Fatal(err)
+	}
+	// Each message starts with a (repeated) START and the bus ends with a single
+	// STOP. The last byte read is NACKed by the master.
+	expected := []string{"S", "46N", "10N", "S", "47N", "FFA", "FFN", "S", "46N", "20N", "P"}
+	if got := l.decode(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("%v != %v", expected, got)
+	}
+	if r[0] != 0xFF || r[1] != 0xFF {
+		t.Fatal(r)
+	}
+}
+
+func TestI2C_Tx_read(t *testing.T) {
+	b, l := newRecorded(t)
+	r := make([]byte, 3)
+	if err := b.Tx(SkipAddr, nil, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"S", "FFA", "FFA", "FFN", "P"}
+	if got := l.decode(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("%v != %v", expected, got)
+	}
+}
+
+func TestI2C_TxMsgs_invalid(t *testing.T) {
+	b, _ := newRecorded(t)
+	if err := b.TxMsgs([]i2c.Msg{{Addr: 0x23, W: []byte{1}, R: []byte{1}}}); err == nil {
+		t.Fatal("a message can't both read and write")
+	}
+	if err := b.TxMsgs([]i2c.Msg{{Addr: 0x80}}); err == nil {
+		t.Fatal("invalid 7 bits address")
+	}
+	if err := b.TxMsgs([]i2c.Msg{{Addr: 0x23}}); err == nil {
+		t.Fatal("NACK")
+	}
+}
+
+//
+
+// transition is a level change on a pin.
+type transition struct {
+	scl bool
+	l   gpio.Level
+}
+
+// lineLog is the ordered list of transitions on SCL and SDA.
+type lineLog struct {
+	t []transition
+}
+
+// decode returns the conditions and frames seen on the bus: "S" for START, "P"
+// for STOP and the 8 bits followed by "A" or "N" for each frame.
+//
+// A bit is sampled on the rising edge of SCL and kept on the falling edge
+// unless SDA changed in between, which is a START or STOP condition instead.
+func (l *lineLog) decode() []string {
+	var out []string
+	scl, sda := gpio.High, gpio.High
+	var bits []gpio.Level
+	pending := false
+	for _, t := range l.t {
+		if !t.scl {
+			if scl == gpio.High && t.l != sda {
+				pending = false
+				if len(bits) != 0 {
+					out = append(out, fmt.Sprintf("%d bits", len(bits)))
+					bits = nil
+				}
+				if t.l == gpio.Low {
+					out = append(out, "S")
+				} else {
+					out = append(out, "P")
+				}
+			}
+			sda = t.l
+			continue
+		}
+		if t.l == gpio.High && scl == gpio.Low {
+			pending = true
+		} else if t.l == gpio.Low && scl == gpio.High && pending {
+			pending = false
+			if bits = append(bits, sda); len(bits) == 9 {
+				var b byte
+				for _, x := range bits[:8] {
+					b <<= 1
+					if x == gpio.High {
+						b |= 1
+					}
+				}
+				a := "A"
+				if bits[8] == gpio.High {
+					a = "N"
+				}
+				out = append(out, fmt.Sprintf("%02X%s", b, a))
+				bits = nil
+			}
+		}
+		scl = t.l
+	}
+	if len(bits) != 0 {
+		out = append(out, fmt.Sprintf("%d bits", len(bits)))
+	}
+	return out
+}
+
+// recordPin records the levels driven by the master or pulled up.
+type recordPin struct {
+	*gpiotest.Pin
+	scl bool
+	log *lineLog
+}
+
+func (r *recordPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	if err := r.Pin.In(pull, edge); err != nil {
+		return err
+	}
+	r.log.t = append(r.log.t, transition{r.scl, r.Pin.Read()})
+	return nil
+}
+
+func (r *recordPin) Out(l gpio.Level) error {
+	if err := r.Pin.Out(l); err != nil {
+		return err
+	}
+	r.log.t = append(r.log.t, transition{r.scl, l})
+	return nil
+}
+
+func newRecorded(t *testing.T) (*I2C, *lineLog) {
+	l := &lineLog{}
+	scl := &recordPin{Pin: &gpiotest.Pin{N: "SCL"}, scl: true, log: l}
+	sda := &recordPin{Pin: &gpiotest.Pin{N: "SDA"}, log: l}
+	b, err := New(scl, sda, 1000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only record the transactions.
+	l.t = nil
+	return b, l
+}
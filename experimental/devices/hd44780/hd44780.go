@@ -22,8 +22,10 @@ const lineTwo = 0x40
 
 // Dev is the 4-bit addressing device for HD-44780
 type Dev struct {
-	// data pins
-	dataPins []gpio.PinOut
+	// data pins, written at once when data is not nil, otherwise one at a time
+	// via pins
+	data gpio.Group
+	pins []gpio.PinOut
 
 	// register select pin
 	rsPin gpio.PinOut
@@ -36,12 +38,47 @@ type Dev struct {
 //	data - references to data pins
 //	rs - rs pin
 //	e - strobe pin
+//
+// When all the data pins implement gpio.PinIO, they are written as a
+// gpio.Group. Otherwise they are written one at a time.
 func New(data []gpio.PinOut, rs, e gpio.PinOut) (*Dev, error) {
 	if len(data) != 4 {
 		return nil, fmt.Errorf("expected 4 data pins, passed %d", len(data))
 	}
+	pins := make([]gpio.PinIO, 0, len(data))
+	for _, p := range data {
+		if pio, ok := p.(gpio.PinIO); ok {
+			pins = append(pins, pio)
+		}
+	}
+	if len(pins) == len(data) {
+		if g, err := gpio.NewGroup(pins...); err == nil {
+			return NewGroup(g, rs, e)
+		}
+	}
 	dev := &Dev{
-		dataPins:  data,
+		pins:      append([]gpio.PinOut(nil), data...),
+		enablePin: e,
+		rsPin:     rs,
+	}
+	if err := dev.Reset(); err != nil {
+		return nil, err
+	}
+	return dev, nil
+}
+
+// NewGroup creates and initializes the LCD device using a group of pins for
+// the data bus, so that the 4 data bits are written at once when supported
+// by the host.
+//	data - group of 4 data pins, D4 being bit 0
+//	rs - rs pin
+//	e - strobe pin
+func NewGroup(data gpio.Group, rs, e gpio.PinOut) (*Dev, error) {
+	if n := len(data.Pins()); n != 4 {
+		return nil, fmt.Errorf("expected 4 data pins, passed %d", n)
+	}
+	dev := &Dev{
+		data:      data,
 		enablePin: e,
 		rsPin:     rs,
 	}
@@ -160,29 +197,29 @@ func (r *Dev) bulkSendData(seq [][]uint, f func(_data uint8) error) error {
 }
 
 func (r *Dev) clearBits() error {
-	for _, v := range r.dataPins {
-		if err := v.Out(gpio.Low); err != nil {
-			return err
-		}
-	}
-	return nil
+	return r.writeData(0)
 }
 
 func (r *Dev) write4Bits(data uint8) error {
-	for i, v := range r.dataPins {
-		if data&(1<<uint(i)) > 0 {
-			if err := v.Out(gpio.High); err != nil {
-				return err
-			}
-		} else {
-			if err := v.Out(gpio.Low); err != nil {
-				return err
-			}
-		}
+	if err := r.writeData(data); err != nil {
+		return err
 	}
 	return r.strobe()
 }
 
+// writeData sets the data pins to the 4 LSB of data, D4 being bit 0.
+func (r *Dev) writeData(data uint8) error {
+	if r.data != nil {
+		return r.data.Out(0x0F, uint64(data&0x0F))
+	}
+	for i, p := range r.pins {
+		if err := p.Out(gpio.Level(data&(1<<uint(i)) != 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *Dev) sendInstruction() error {
 	if err := r.rsPin.Out(gpio.Low); err != nil {
 		return err
@@ -0,0 +1,150 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package hd44780
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestNewGroup(t *testing.T) {
+	g := &gpiotest.Group{
+		N:       "data",
+		Members: []*gpiotest.Pin{{N: "D4"}, {N: "D5"}, {N: "D6"}, {N: "D7"}},
+	}
+	rs := &gpiotest.Pin{N: "RS"}
+	e := &gpiotest.Pin{N: "E"}
+	d, err := NewGroup(g, rs, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clear, reset sequence then initialization sequence, one nibble at a time.
+	nibbles := []uint64{0, 3, 3, 3, 2, 1, 4, 1, 0, 0, 1, 0, 6, 0, 0xC, 0, 1, 0, 2}
+	if err := verifyNibbles(g, nibbles); err != nil {
+		t.Fatal(err)
+	}
+	g.Ops = nil
+	if err := d.Print("A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyNibbles(g, []uint64{4, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Read() != gpio.High {
+		t.Fatal("RS must be high when sending data")
+	}
+	if e.Read() != gpio.Low {
+		t.Fatal("E must be low after strobe")
+	}
+	g.Ops = nil
+	if err := d.SetCursor(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyNibbles(g, []uint64{0xC, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if rs.Read() != gpio.Low {
+		t.Fatal("RS must be low when sending an instruction")
+	}
+}
+
+func TestNewGroup_error(t *testing.T) {
+	g := &gpiotest.Group{Members: []*gpiotest.Pin{{N: "D4"}}}
+	if _, err := NewGroup(g, &gpiotest.Pin{}, &gpiotest.Pin{}); err == nil {
+		t.Fatal("expected 4 data pins")
+	}
+	if _, err := New(nil, &gpiotest.Pin{}, &gpiotest.Pin{}); err == nil {
+		t.Fatal("expected 4 data pins")
+	}
+}
+
+func TestNew_pinOut(t *testing.T) {
+	pins := []*gpiotest.Pin{{N: "D4"}, {N: "D5"}, {N: "D6"}, {N: "D7"}}
+	// outPin only implements gpio.PinOut so the pins can't be grouped.
+	data := make([]gpio.PinOut, 0, len(pins))
+	for _, p := range pins {
+		data = append(data, outPin{p})
+	}
+	e := &strobePin{Pin: &gpiotest.Pin{N: "E"}, data: pins}
+	d, err := New(data, &gpiotest.Pin{N: "RS"}, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.data != nil {
+		t.Fatal("expected per-pin writes")
+	}
+	e.nibbles = nil
+	if err := d.Print("A"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint64{4, 1}; !reflect.DeepEqual(e.nibbles, expected) {
+		t.Fatalf("expected %v, got %v", expected, e.nibbles)
+	}
+}
+
+func TestNew_group(t *testing.T) {
+	pins := []*gpiotest.Pin{{N: "D4"}, {N: "D5"}, {N: "D6"}, {N: "D7"}}
+	data := make([]gpio.PinOut, 0, len(pins))
+	for _, p := range pins {
+		data = append(data, p)
+	}
+	e := &strobePin{Pin: &gpiotest.Pin{N: "E"}, data: pins}
+	d, err := New(data, &gpiotest.Pin{N: "RS"}, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.data == nil {
+		t.Fatal("expected a gpio.Group")
+	}
+	e.nibbles = nil
+	if err := d.Print("A"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint64{4, 1}; !reflect.DeepEqual(e.nibbles, expected) {
+		t.Fatalf("expected %v, got %v", expected, e.nibbles)
+	}
+}
+
+//
+
+// outPin hides all the methods of the pin except the ones of gpio.PinOut.
+type outPin struct {
+	gpio.PinOut
+}
+
+// strobePin records the level of the data pins on each rising edge.
+type strobePin struct {
+	*gpiotest.Pin
+	data    []*gpiotest.Pin
+	nibbles []uint64
+}
+
+func (s *strobePin) Out(l gpio.Level) error {
+	if l == gpio.High {
+		var n uint64
+		for i, p := range s.data {
+			if p.Read() == gpio.High {
+				n |= 1 << uint(i)
+			}
+		}
+		s.nibbles = append(s.nibbles, n)
+	}
+	return s.Pin.Out(l)
+}
+
+func verifyNibbles(g *gpiotest.Group, nibbles []uint64) error {
+	expected := make([]gpiotest.GroupOp, 0, len(nibbles))
+	for _, n := range nibbles {
+		expected = append(expected, gpiotest.GroupOp{Mask: 0x0F, Values: n})
+	}
+	if !reflect.DeepEqual(g.Ops, expected) {
+		return fmt.Errorf("expected %v, got %v", expected, g.Ops)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package tca9548a controls a TCA9548A or PCA9548A 8 channels I²C
+// multiplexer.
+//
+// Each downstream channel is exposed as a separate i2c.Bus. The multiplexer
+// is switched to the right channel transparently on each transaction, so
+// devices with identical addresses can sit on different channels.
+//
+// Datasheet
+//
+// http://www.ti.com/lit/ds/symlink/tca9548a.pdf
+//
+// https://www.nxp.com/docs/en/data-sheet/PCA9548A.pdf
+package tca9548a
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/conn/physic"
+)
+
+// Channels is the number of downstream channels.
+const Channels = 8
+
+// New returns a handle to a TCA9548A multiplexer on the bus b.
+//
+// Valid I²C addresses are 0x70 to 0x77. All the channels are disabled
+// initially.
+//
+// All the traffic to the devices behind the multiplexer must go through the
+// buses returned by Bus() or registered by Register(), otherwise the
+// multiplexer channel selection can't be tracked.
+func New(b i2c.Bus, addr uint16) (*Dev, error) {
+	if addr < 0x70 || addr > 0x77 {
+		return nil, errors.New("tca9548a: given address not supported by device")
+	}
+	d := &Dev{c: i2c.Dev{Bus: b, Addr: addr}, current: -1}
+	if err := d.Halt(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Dev is a handle to a TCA9548A multiplexer.
+type Dev struct {
+	mu         sync.Mutex
+	c          i2c.Dev
+	current    int      // Currently selected channel; -1 when none.
+	registered []string // Names registered in i2creg.
+}
+
+func (d *Dev) String() string {
+	return fmt.Sprintf("tca9548a{%s}", &d.c)
+}
+
+// Halt implements conn.Resource.
+//
+// It disables all the channels.
+func (d *Dev) Halt() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.current = -1
+	if err := d.c.Tx([]byte{0}, nil); err != nil {
+		return fmt.Errorf("tca9548a: %v", err)
+	}
+	return nil
+}
+
+// Bus returns the I²C bus for the downstream channel.
+//
+// The returned bus implements i2c.MsgBus and smbus.Bus only if the parent bus
+// does. Closing the returned bus doesn't close the parent bus.
+func (d *Dev) Bus(channel int) (i2c.BusCloser, error) {
+	if channel < 0 || channel >= Channels {
+		return nil, errors.New("tca9548a: invalid channel " + strconv.Itoa(channel))
+	}
+	return newBus(d, channel), nil
+}
+
+// Register registers all the downstream channels in i2creg.
+//
+// The buses are named after the parent bus, the multiplexer address in
+// hexadecimal and the channel, e.g. "I2C1-mux70-3". The names are returned.
+func (d *Dev) Register() ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.registered) != 0 {
+		return nil, errors.New("tca9548a: already registered")
+	}
+	for i := 0; i < Channels; i++ {
+		b := &bus{d: d, channel: i}
+		name := b.String()
+		if err := i2creg.Register(name, nil, -1, b.open); err != nil {
+			d.unregister()
+			return nil, err
+		}
+		d.registered = append(d.registered, name)
+	}
+	out := make([]string, len(d.registered))
+	copy(out, d.registered)
+	return out, nil
+}
+
+// Unregister removes the buses registered by Register() from i2creg.
+func (d *Dev) Unregister() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.unregister()
+}
+
+//
+
+// unregister removes the registered buses.
+//
+// lock must be held.
+func (d *Dev) unregister() error {
+	var err error
+	for _, name := range d.registered {
+		if err2 := i2creg.Unregister(name); err2 != nil && err == nil {
+			err = err2
+		}
+	}
+	d.registered = nil
+	return err
+}
+
+// selectChannel enables only the channel specified, if not already done.
+//
+// lock must be held.
+func (d *Dev) selectChannel(channel int) error {
+	if d.current == channel {
+		return nil
+	}
+	if err := d.c.Tx([]byte{1 << uint(channel)}, nil); err != nil {
+		d.current = -1
+		return fmt.Errorf("tca9548a: %v", err)
+	}
+	d.current = channel
+	return nil
+}
+
+// newBus returns the downstream channel, implementing the optional interfaces
+// of the parent bus.
+func newBus(d *Dev, channel int) i2c.BusCloser {
+	b := &bus{d: d, channel: channel}
+	_, isMsg := d.c.Bus.(i2c.MsgBus)
+	_, isSMBus := d.c.Bus.(smbus.Bus)
+	switch {
+	case isMsg && isSMBus:
+		return &msgSMBusBus{b}
+	case isMsg:
+		return &msgBus{b}
+	case isSMBus:
+		return &smbusBus{b}
+	default:
+		return b
+	}
+}
+
+// bus is a downstream channel of the multiplexer.
+type bus struct {
+	d       *Dev
+	channel int
+}
+
+func (b *bus) String() string {
+	return fmt.Sprintf("%s-mux%02x-%d", b.d.c.Bus, b.d.c.Addr, b.channel)
+}
+
+// Close implements i2c.BusCloser.
+//
+// It is a no-op; the parent bus is not closed.
+func (b *bus) Close() error {
+	return nil
+}
+
+// Tx implements i2c.Bus.
+func (b *bus) Tx(addr uint16, w, r []byte) error {
+	if addr == b.d.c.Addr {
+		return errors.New("tca9548a: can't address the multiplexer through its own channel")
+	}
+	b.d.mu.Lock()
+	defer b.d.mu.Unlock()
+	if err := b.d.selectChannel(b.channel); err != nil {
+		return err
+	}
+	return b.d.c.Bus.Tx(addr, w, r)
+}
+
+// txMsgs forwards the messages to the parent bus.
+func (b *bus) txMsgs(m []i2c.Msg) error {
+	p, ok := b.d.c.Bus.(i2c.MsgBus)
+	if !ok {
+		return errors.New("tca9548a: parent bus doesn't implement i2c.MsgBus")
+	}
+	for i := range m {
+		if m[i].Addr == b.d.c.Addr {
+			return errors.New("tca9548a: can't address the multiplexer through its own channel")
+		}
+	}
+	b.d.mu.Lock()
+	defer b.d.mu.Unlock()
+	if err := b.d.selectChannel(b.channel); err != nil {
+		return err
+	}
+	return p.TxMsgs(m)
+}
+
+// smbusSupports forwards to the parent bus.
+func (b *bus) smbusSupports(p smbus.Protocol, read, pec bool) bool {
+	s, ok := b.d.c.Bus.(smbus.Bus)
+	return ok && s.SMBusSupports(p, read, pec)
+}
+
+// smbusTx forwards the SMBus transaction to the parent bus.
+func (b *bus) smbusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	s, ok := b.d.c.Bus.(smbus.Bus)
+	if !ok {
+		return 0, errors.New("tca9548a: parent bus doesn't implement smbus.Bus")
+	}
+	if addr == b.d.c.Addr {
+		return 0, errors.New("tca9548a: can't address the multiplexer through its own channel")
+	}
+	b.d.mu.Lock()
+	defer b.d.mu.Unlock()
+	if err := b.d.selectChannel(b.channel); err != nil {
+		return 0, err
+	}
+	return s.SMBusTx(addr, p, read, cmd, data, pec)
+}
+
+// SetSpeed implements i2c.Bus.
+//
+// It changes the speed of the parent bus, thus of all channels.
+func (b *bus) SetSpeed(f physic.Frequency) error {
+	return b.d.c.Bus.SetSpeed(f)
+}
+
+// open implements i2creg.Opener.
+func (b *bus) open() (i2c.BusCloser, error) {
+	return newBus(b.d, b.channel), nil
+}
+
+// msgBus is a channel of a parent bus implementing i2c.MsgBus.
+type msgBus struct {
+	*bus
+}
+
+// TxMsgs implements i2c.MsgBus.
+func (b *msgBus) TxMsgs(m []i2c.Msg) error {
+	return b.txMsgs(m)
+}
+
+// smbusBus is a channel of a parent bus implementing smbus.Bus.
+type smbusBus struct {
+	*bus
+}
+
+// SMBusSupports implements smbus.Bus.
+func (b *smbusBus) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	return b.smbusSupports(p, read, pec)
+}
+
+// SMBusTx implements smbus.Bus.
+func (b *smbusBus) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	return b.smbusTx(addr, p, read, cmd, data, pec)
+}
+
+// msgSMBusBus is a channel of a parent bus implementing both i2c.MsgBus and
+// smbus.Bus.
+type msgSMBusBus struct {
+	*bus
+}
+
+// TxMsgs implements i2c.MsgBus.
+func (b *msgSMBusBus) TxMsgs(m []i2c.Msg) error {
+	return b.txMsgs(m)
+}
+
+// SMBusSupports implements smbus.Bus.
+func (b *msgSMBusBus) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	return b.smbusSupports(p, read, pec)
+}
+
+// SMBusTx implements smbus.Bus.
+func (b *msgSMBusBus) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	return b.smbusTx(addr, p, read, cmd, data, pec)
+}
+
+var _ conn.Resource = &Dev{}
+var _ i2c.BusCloser = &bus{}
+var _ i2c.MsgBus = &msgBus{}
+var _ smbus.Bus = &smbusBus{}
+var _ i2c.MsgBus = &msgSMBusBus{}
+var _ smbus.Bus = &msgSMBusBus{}
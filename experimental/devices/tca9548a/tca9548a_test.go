@@ -0,0 +1,317 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package tca9548a
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/i2ctest"
+	"periph.io/x/periph/conn/i2c/smbus"
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestNew(t *testing.T) {
+	if _, err := New(&i2ctest.Playback{}, 0x20); err == nil {
+		t.Fatal("invalid address")
+	}
+	p := i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x70, W: []byte{0}}}, DontPanic: true}
+	d, err := New(&p, 0x70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.String(); s != "tca9548a{playback(112)}" {
+		t.Fatal(s)
+	}
+	if _, err := d.Bus(-1); err == nil {
+		t.Fatal("invalid channel")
+	}
+	if _, err := d.Bus(Channels); err == nil {
+		t.Fatal("invalid channel")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The device doesn't reply.
+	if _, err := New(&p, 0x70); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestBus(t *testing.T) {
+	p := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x71, W: []byte{0}},
+			// Channel 3 is selected once.
+			{Addr: 0x71, W: []byte{0x08}},
+			{Addr: 0x40, W: []byte{0x01}, R: []byte{0x42}},
+			{Addr: 0x40, W: []byte{0x02}},
+			// Switch to channel 5.
+			{Addr: 0x71, W: []byte{0x20}},
+			{Addr: 0x40, W: []byte{0x01}, R: []byte{0x43}},
+			// Back to channel 3.
+			{Addr: 0x71, W: []byte{0x08}},
+			{Msgs: []i2c.Msg{{Addr: 0x50, W: []byte{0x00, 0x10}}, {Addr: 0x50, R: []byte{1, 2}}}},
+			// Halt.
+			{Addr: 0x71, W: []byte{0}},
+		},
+	}
+	d, err := New(&p, 0x71)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b3, err := d.Bus(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b5, err := d.Bus(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := b3.String(); s != "playback-mux71-3" {
+		t.Fatal(s)
+	}
+	var r [1]byte
+	if err := b3.Tx(0x40, []byte{0x01}, r[:]); err != nil || r[0] != 0x42 {
+		t.Fatal(r, err)
+	}
+	if err := b3.Tx(0x40, []byte{0x02}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := b5.Tx(0x40, []byte{0x01}, r[:]); err != nil || r[0] != 0x43 {
+		t.Fatal(r, err)
+	}
+	if err := b5.Tx(0x71, []byte{0x01}, nil); err == nil {
+		t.Fatal("can't address the multiplexer")
+	}
+	var e [2]byte
+	m := []i2c.Msg{{Addr: 0x50, W: []byte{0x00, 0x10}}, {Addr: 0x50, R: e[:]}}
+	if err := b3.(i2c.MsgBus).TxMsgs(m); err != nil || e[1] != 2 {
+		t.Fatal(e, err)
+	}
+	if err := b3.(i2c.MsgBus).TxMsgs([]i2c.Msg{{Addr: 0x71}}); err == nil {
+		t.Fatal("can't address the multiplexer")
+	}
+	if err := b3.SetSpeed(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b3.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBus_error(t *testing.T) {
+	p := i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x70, W: []byte{0}}}, DontPanic: true}
+	d, err := New(&p, 0x70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.Bus(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Tx(0x40, nil, nil); err == nil {
+		t.Fatal("channel selection failed")
+	}
+	if d.current != -1 {
+		t.Fatal(d.current)
+	}
+	if err := b.(i2c.MsgBus).TxMsgs(nil); err == nil {
+		t.Fatal("channel selection failed")
+	}
+	d.c.Bus = &nonMsgBus{}
+	if err := b.(i2c.MsgBus).TxMsgs(nil); err == nil {
+		t.Fatal("parent doesn't implement i2c.MsgBus")
+	}
+}
+
+func TestBus_interfaces(t *testing.T) {
+	d, err := New(&nonMsgBus{}, 0x70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.Bus(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(i2c.MsgBus); ok {
+		t.Fatal("parent doesn't implement i2c.MsgBus")
+	}
+	if _, ok := b.(smbus.Bus); ok {
+		t.Fatal("parent doesn't implement smbus.Bus")
+	}
+	d.c.Bus = &smbusParent{}
+	if b, err = d.Bus(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(i2c.MsgBus); ok {
+		t.Fatal("parent doesn't implement i2c.MsgBus")
+	}
+	if _, ok := b.(smbus.Bus); !ok {
+		t.Fatal("parent implements smbus.Bus")
+	}
+	p := i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x70, W: []byte{0}}}}
+	if d, err = New(&p, 0x70); err != nil {
+		t.Fatal(err)
+	}
+	if b, err = d.Bus(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.(i2c.MsgBus); !ok {
+		t.Fatal("parent implements i2c.MsgBus")
+	}
+	if _, ok := b.(smbus.Bus); ok {
+		t.Fatal("parent doesn't implement smbus.Bus")
+	}
+}
+
+func TestBus_SMBusTx(t *testing.T) {
+	p := smbusParent{}
+	d, err := New(&p, 0x70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.Bus(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := b.(smbus.Bus)
+	if !s.SMBusSupports(smbus.WordData, true, false) {
+		t.Fatal("parent supports it")
+	}
+	data := make([]byte, 2)
+	if n, err := s.SMBusTx(0x40, smbus.WordData, true, 1, data, false); err != nil || n != 2 {
+		t.Fatal(n, err)
+	}
+	if _, err := s.SMBusTx(0x40, smbus.ByteData, false, 2, data[:1], false); err != nil {
+		t.Fatal(err)
+	}
+	// The channel is selected once, before the first SMBus transaction.
+	if len(p.tx) != 2 || p.tx[0] != 0 || p.tx[1] != 4 || len(p.ops) != 2 || p.ops[0] != 0x40 {
+		t.Fatal(p.tx, p.ops)
+	}
+	if _, err := s.SMBusTx(0x70, smbus.Quick, false, 0, nil, false); err == nil {
+		t.Fatal("can't address the multiplexer")
+	}
+	d.c.Bus = &nonMsgBus{}
+	if s.SMBusSupports(smbus.WordData, true, false) {
+		t.Fatal("parent doesn't implement smbus.Bus")
+	}
+	if _, err := s.SMBusTx(0x40, smbus.Quick, false, 0, nil, false); err == nil {
+		t.Fatal("parent doesn't implement smbus.Bus")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	p := i2ctest.Playback{
+		Ops: []i2ctest.IO{
+			{Addr: 0x70, W: []byte{0}},
+			{Addr: 0x70, W: []byte{0x80}},
+			{Addr: 0x40, W: []byte{0x01}},
+		},
+	}
+	d, err := New(&p, 0x70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := d.Register()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Unregister()
+	if len(names) != Channels || names[7] != "playback-mux70-7" {
+		t.Fatal(names)
+	}
+	if _, err := d.Register(); err == nil {
+		t.Fatal("already registered")
+	}
+	b, err := i2creg.Open("playback-mux70-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Tx(0x40, []byte{0x01}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Unregister(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i2creg.Open("playback-mux70-7"); err == nil {
+		t.Fatal("expected bus to be unregistered")
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegister_conflict(t *testing.T) {
+	p := i2ctest.Playback{Ops: []i2ctest.IO{{Addr: 0x70, W: []byte{0}}}}
+	d, err := New(&p, 0x70)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := i2creg.Register("playback-mux70-2", nil, -1, func() (i2c.BusCloser, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	defer i2creg.Unregister("playback-mux70-2")
+	if _, err := d.Register(); err == nil {
+		t.Fatal("expected conflict")
+	}
+	// The partially registered buses were removed.
+	if _, err := i2creg.Open("playback-mux70-0"); err == nil {
+		t.Fatal("expected bus to be unregistered")
+	}
+}
+
+//
+
+// nonMsgBus implements i2c.Bus but not i2c.MsgBus.
+type nonMsgBus struct {
+}
+
+func (n *nonMsgBus) String() string {
+	return "nonMsgBus"
+}
+
+func (n *nonMsgBus) Tx(addr uint16, w, r []byte) error {
+	return nil
+}
+
+func (n *nonMsgBus) SetSpeed(f physic.Frequency) error {
+	return nil
+}
+
+// smbusParent implements i2c.Bus and smbus.Bus but not i2c.MsgBus.
+//
+// It records the bytes written to the multiplexer and the addresses of the
+// SMBus transactions.
+type smbusParent struct {
+	nonMsgBus
+	tx  []byte
+	ops []uint16
+}
+
+func (s *smbusParent) Tx(addr uint16, w, r []byte) error {
+	s.tx = append(s.tx, w...)
+	return nil
+}
+
+func (s *smbusParent) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	return true
+}
+
+func (s *smbusParent) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	s.ops = append(s.ops, addr)
+	return len(data), nil
+}
@@ -107,7 +107,7 @@ func (device *Dev) flush() error {
 		device.txBuffer[k+2] = blue
 	}
 
-	return device.connector.Tx(device.txBuffer, nil)
+	return spi.TxStream(device.connector, device.txBuffer, nil)
 }
 
 // Test that driver implements display.Drawer interface.  This is
@@ -0,0 +1,291 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package iio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/conn/analog"
+)
+
+// Capture starts a buffered capture of the analog inputs adcs.
+//
+// If freq is not 0, it is written to the device's sampling_frequency. If
+// length is not 0, it is the size of the kernel buffer in number of scans.
+//
+// The device must either start conversions on its own or have a trigger
+// assigned via its trigger/current_trigger sysfs file beforehand.
+//
+// Only one capture can be in progress per device. Call Buffer.Halt() to stop
+// it.
+func (d *Device) Capture(adcs []*ADC, freq physic.Frequency, length int) (*Buffer, error) {
+	if len(adcs) == 0 {
+		return nil, errors.New("iio: specify at least one channel to capture")
+	}
+	enabled := map[*ADC]bool{}
+	for _, a := range adcs {
+		if a.dev != d {
+			return nil, errors.New("iio: " + a.name + " is not a channel of " + d.String())
+		}
+		if a.scan.index < 0 {
+			return nil, errors.New("iio: " + a.name + " doesn't support buffered capture")
+		}
+		if enabled[a] {
+			return nil, errors.New("iio: " + a.name + " specified twice")
+		}
+		enabled[a] = true
+	}
+	if freq < 0 || length < 0 {
+		return nil, errors.New("iio: invalid frequency or length")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.buf != nil {
+		return nil, errors.New("iio: a capture is already in progress on " + d.String())
+	}
+	// The configuration cannot be changed while the buffer is enabled.
+	if err := writeString(d.root+"buffer/enable", "0"); err != nil {
+		return nil, fmt.Errorf("iio: %v", err)
+	}
+	if freq != 0 {
+		if err := writeString(d.root+"sampling_frequency", strconv.FormatInt(int64(freq/physic.Hertz), 10)); err != nil {
+			return nil, fmt.Errorf("iio: %v", err)
+		}
+	}
+	// The timestamp channel is not supported, ignore the error since it is
+	// optional.
+	_ = writeString(d.root+"scan_elements/in_timestamp_en", "0")
+	for _, a := range d.ADCs {
+		if a.scan.index < 0 {
+			continue
+		}
+		v := "0"
+		if enabled[a] {
+			v = "1"
+		}
+		if err := writeString(d.root+"scan_elements/in_voltage"+strconv.Itoa(a.index)+"_en", v); err != nil {
+			return nil, fmt.Errorf("iio: %v", err)
+		}
+	}
+	if length != 0 {
+		if err := writeString(d.root+"buffer/length", strconv.Itoa(length)); err != nil {
+			return nil, fmt.Errorf("iio: %v", err)
+		}
+	}
+	if err := writeString(d.root+"buffer/enable", "1"); err != nil {
+		return nil, fmt.Errorf("iio: %v", err)
+	}
+	f, err := fileIOOpen("/dev/iio:device"+strconv.Itoa(d.number), os.O_RDONLY)
+	if err != nil {
+		_ = writeString(d.root+"buffer/enable", "0")
+		return nil, fmt.Errorf("iio: %v", err)
+	}
+	b := &Buffer{dev: d, adcs: append([]*ADC(nil), adcs...), f: f}
+	b.offsets, b.size = scanLayout(b.adcs)
+	d.buf = b
+	return b, nil
+}
+
+// Buffer is a buffered capture in progress, as returned by Device.Capture().
+type Buffer struct {
+	dev     *Device
+	adcs    []*ADC
+	offsets []int // Offset in bytes of each channel in a scan
+	size    int   // Size in bytes of a scan
+	f       io.ReadCloser
+	buf     []byte
+}
+
+func (b *Buffer) String() string {
+	names := make([]string, 0, len(b.adcs))
+	for _, a := range b.adcs {
+		names = append(names, a.name)
+	}
+	return b.dev.String() + "(" + strings.Join(names, ",") + ")"
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the capture.
+func (b *Buffer) Halt() error {
+	b.dev.mu.Lock()
+	defer b.dev.mu.Unlock()
+	if b.dev.buf != b {
+		return nil
+	}
+	b.dev.buf = nil
+	err := b.f.Close()
+	if err2 := writeString(b.dev.root+"buffer/enable", "0"); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fmt.Errorf("iio: %v", err)
+	}
+	return nil
+}
+
+// Read reads the samples of the captured channels into samples.
+//
+// Each scan is stored as len(adcs) samples, in the order of the adcs passed to
+// Device.Capture(). Read blocks until at least one scan is available and
+// returns the number of complete scans read, at most len(samples)/len(adcs).
+func (b *Buffer) Read(samples []analog.Sample) (int, error) {
+	n := len(samples) / len(b.adcs)
+	if n == 0 {
+		return 0, errors.New("iio: samples is too short for one scan")
+	}
+	if l := n * b.size; len(b.buf) < l {
+		b.buf = make([]byte, l)
+	}
+	l, err := b.f.Read(b.buf[:n*b.size])
+	if err != nil {
+		return 0, fmt.Errorf("iio: %v", err)
+	}
+	n = l / b.size
+	for i := 0; i < n; i++ {
+		scan := b.buf[i*b.size:]
+		for j, a := range b.adcs {
+			samples[i*len(b.adcs)+j] = a.sample(a.scan.decode(scan[b.offsets[j]:]))
+		}
+	}
+	return n, nil
+}
+
+//
+
+// scanElement is the description of a channel in the buffer, as found in
+// the scan_elements directory.
+type scanElement struct {
+	index     int  // Position in a scan; -1 if the channel can't be captured
+	bigEndian bool // Storage is big endian
+	signed    bool
+	bits      uint // Number of valid bits
+	storage   uint // Number of bits used in the scan
+	shift     uint // Number of bits to shift right to get the value
+}
+
+// readScanElement returns the description of the channel in a scan, if any.
+func (d *Device) readScanElement(index int) scanElement {
+	s := scanElement{index: -1}
+	i, err := readString(d.root + "scan_elements/in_voltage" + strconv.Itoa(index) + "_index")
+	if err != nil {
+		return s
+	}
+	t, err := d.readAttr("scan_elements/", "in", index, "type")
+	if err != nil {
+		return s
+	}
+	if s, err = parseScanType(t); err != nil {
+		return scanElement{index: -1}
+	}
+	if s.index, err = strconv.Atoi(i); err != nil || s.index < 0 {
+		return scanElement{index: -1}
+	}
+	return s
+}
+
+// parseScanType parses a type as found in scan_elements/*_type, e.g.
+// "le:s12/16>>4".
+//
+// The format is [be|le]:[s|u]bits/storagebits[Xrepeat]>>shift. Repeated
+// channels are not supported.
+func parseScanType(t string) (scanElement, error) {
+	s := scanElement{}
+	var endian string
+	var sign byte
+	if _, err := fmt.Sscanf(t, "%2s:%c%d/%d>>%d", &endian, &sign, &s.bits, &s.storage, &s.shift); err != nil {
+		return s, fmt.Errorf("iio: unsupported scan type %q", t)
+	}
+	switch endian {
+	case "be":
+		s.bigEndian = true
+	case "le":
+	default:
+		return s, fmt.Errorf("iio: unsupported scan type %q", t)
+	}
+	switch sign {
+	case 's':
+		s.signed = true
+	case 'u':
+	default:
+		return s, fmt.Errorf("iio: unsupported scan type %q", t)
+	}
+	if (s.storage != 8 && s.storage != 16 && s.storage != 32 && s.storage != 64) || s.bits == 0 || s.bits > 32 || s.bits+s.shift > s.storage {
+		return s, fmt.Errorf("iio: unsupported scan type %q", t)
+	}
+	return s, nil
+}
+
+// limits returns the range of the values as described by the scan type.
+func (s *scanElement) limits() (int32, int32) {
+	if s.signed {
+		return -1 << (s.bits - 1), 1<<(s.bits-1) - 1
+	}
+	if s.bits == 32 {
+		// Doesn't fit in an int32.
+		return 0, 1<<31 - 1
+	}
+	return 0, 1<<s.bits - 1
+}
+
+// decode decodes the value stored at the beginning of b.
+func (s *scanElement) decode(b []byte) int32 {
+	var v uint64
+	n := s.storage / 8
+	for i := uint(0); i < n; i++ {
+		if s.bigEndian {
+			v = v<<8 | uint64(b[i])
+		} else {
+			v |= uint64(b[i]) << (8 * i)
+		}
+	}
+	v = (v >> s.shift) & (1<<s.bits - 1)
+	if s.signed && v&(1<<(s.bits-1)) != 0 {
+		// Sign extend.
+		v |= ^uint64(0) << s.bits
+	}
+	return int32(v)
+}
+
+// scanLayout returns the offset of each channel in a scan and the size of a
+// scan.
+//
+// The channels are stored in order of their index, each aligned on its own
+// storage size, and the scan is padded to the largest storage size.
+func scanLayout(adcs []*ADC) ([]int, int) {
+	order := make([]int, 0, len(adcs))
+	byIndex := map[int]int{}
+	for i, a := range adcs {
+		order = append(order, a.scan.index)
+		byIndex[a.scan.index] = i
+	}
+	sort.Ints(order)
+	offsets := make([]int, len(adcs))
+	size := 0
+	largest := 1
+	for _, index := range order {
+		i := byIndex[index]
+		l := int(adcs[i].scan.storage / 8)
+		if l > largest {
+			largest = l
+		}
+		size = (size + l - 1) / l * l
+		offsets[i] = size
+		size += l
+	}
+	size = (size + largest - 1) / largest * largest
+	return offsets, size
+}
+
+var _ conn.Resource = &Buffer{}
@@ -0,0 +1,483 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package iio exposes the ADCs and DACs supported by the Linux Industrial I/O
+// subsystem.
+//
+// Each in_voltageN_raw channel is exposed as an ADC and each out_voltageN_raw
+// channel as a DAC, both implementing analog.PinIO. They are registered in
+// analogreg as "IIO<device>_IN<channel>" and "IIO<device>_OUT<channel>", e.g.
+// "IIO0_IN3".
+//
+// Reading a channel via sysfs takes a few hundred microseconds. To sample at
+// higher rate, use Device.Capture() which reads the samples from the kernel
+// buffer via /dev/iio:deviceN.
+//
+// https://www.kernel.org/doc/Documentation/ABI/testing/sysfs-bus-iio
+package iio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/conn/analog"
+	"periph.io/x/periph/experimental/conn/analog/analogreg"
+	"periph.io/x/periph/host/fs"
+)
+
+// Devices is all the IIO devices discovered on this host.
+var Devices []*Device
+
+// Device is an IIO device, for example an ADC chip.
+type Device struct {
+	number int
+	name   string // Name as reported by the kernel driver, e.g. "ads1015"
+	root   string // e.g. "/sys/bus/iio/devices/iio:device0/"
+
+	// ADCs is the analog inputs of the device, ordered by channel number.
+	ADCs []*ADC
+	// DACs is the analog outputs of the device, ordered by channel number.
+	DACs []*DAC
+
+	mu  sync.Mutex
+	buf *Buffer // Capture in progress, if any
+}
+
+func (d *Device) String() string {
+	return fmt.Sprintf("%s(%d)", d.name, d.number)
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the capture in progress, if any.
+func (d *Device) Halt() error {
+	d.mu.Lock()
+	b := d.buf
+	d.mu.Unlock()
+	if b != nil {
+		return b.Halt()
+	}
+	return nil
+}
+
+// Name returns the name of the device as reported by its kernel driver.
+func (d *Device) Name() string {
+	return d.name
+}
+
+// Number returns N as in /sys/bus/iio/devices/iio:deviceN.
+func (d *Device) Number() int {
+	return d.number
+}
+
+// ADC is an analog input channel of an IIO device.
+//
+// ADC implements analog.PinIO. Out() always fails.
+type ADC struct {
+	channel
+	scan scanElement
+}
+
+// Function implements pin.Pin.
+func (a *ADC) Function() string {
+	return "ADC"
+}
+
+// Read implements analog.ADC.
+//
+// The scale and offset as reported by the kernel upon initialization are
+// applied to calculate the voltage.
+func (a *ADC) Read() (analog.Sample, error) {
+	v, err := a.readRaw("in", os.O_RDONLY)
+	if err != nil {
+		return analog.Sample{}, err
+	}
+	return a.sample(v), nil
+}
+
+// Out implements analog.DAC.
+//
+// It always fails, since the channel is an input.
+func (a *ADC) Out(v analog.Sample) error {
+	return errors.New("iio: " + a.name + " is an input")
+}
+
+// DAC is an analog output channel of an IIO device.
+//
+// DAC implements analog.PinIO.
+type DAC struct {
+	channel
+}
+
+// Function implements pin.Pin.
+func (d *DAC) Function() string {
+	return "DAC"
+}
+
+// Read implements analog.ADC.
+//
+// It returns the current output value.
+func (d *DAC) Read() (analog.Sample, error) {
+	v, err := d.readRaw("out", os.O_RDWR)
+	if err != nil {
+		return analog.Sample{}, err
+	}
+	return d.sample(v), nil
+}
+
+// Out implements analog.DAC.
+//
+// The scale and offset as reported by the kernel upon initialization are
+// applied to calculate the raw value.
+func (d *DAC) Out(v analog.Sample) error {
+	return d.writeRaw(fromVoltage(v.V, d.scale, d.offset))
+}
+
+//
+
+var fileIOOpen = fileIOOpenDefault
+
+func fileIOOpenDefault(path string, flag int) (fileIO, error) {
+	f, err := fs.Open(path, flag)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type fileIO interface {
+	io.Closer
+	io.Reader
+	io.Seeker
+	io.Writer
+}
+
+// channel is the common part of ADC and DAC.
+type channel struct {
+	dev    *Device
+	index  int
+	name   string
+	min    int32
+	max    int32
+	scale  float64 // In millivolts per count
+	offset float64 // In counts
+
+	mu sync.Mutex
+	f  fileIO // handle to {in|out}_voltageN_raw; opened on first access
+}
+
+// String implements conn.Resource.
+func (c *channel) String() string {
+	return c.name
+}
+
+// Halt implements conn.Resource.
+func (c *channel) Halt() error {
+	return nil
+}
+
+// Name implements pin.Pin.
+func (c *channel) Name() string {
+	return c.name
+}
+
+// Number implements pin.Pin.
+//
+// It returns the channel number, N as in in_voltageN_raw.
+func (c *channel) Number() int {
+	return c.index
+}
+
+// Range implements analog.ADC and analog.DAC.
+//
+// It returns two zero samples if the range is not known.
+func (c *channel) Range() (analog.Sample, analog.Sample) {
+	if c.min == 0 && c.max == 0 {
+		return analog.Sample{}, analog.Sample{}
+	}
+	return c.sample(c.min), c.sample(c.max)
+}
+
+func (c *channel) sample(raw int32) analog.Sample {
+	return analog.Sample{V: toVoltage(raw, c.scale, c.offset), Raw: raw}
+}
+
+func (c *channel) open(prefix string, flag int) error {
+	if c.f == nil {
+		f, err := fileIOOpen(c.dev.root+prefix+"_voltage"+strconv.Itoa(c.index)+"_raw", flag)
+		if err != nil {
+			return err
+		}
+		c.f = f
+	}
+	return nil
+}
+
+func (c *channel) readRaw(prefix string, flag int) (int32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.open(prefix, flag); err != nil {
+		return 0, fmt.Errorf("iio: %v", err)
+	}
+	var b [24]byte
+	if _, err := c.f.Seek(0, 0); err != nil {
+		return 0, fmt.Errorf("iio: %v", err)
+	}
+	n, err := c.f.Read(b[:])
+	if err != nil {
+		return 0, fmt.Errorf("iio: %v", err)
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(b[:n])), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("iio: %v", err)
+	}
+	return int32(v), nil
+}
+
+func (c *channel) writeRaw(v int32) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.open("out", os.O_RDWR); err != nil {
+		return fmt.Errorf("iio: %v", err)
+	}
+	if _, err := c.f.Seek(0, 0); err != nil {
+		return fmt.Errorf("iio: %v", err)
+	}
+	if _, err := c.f.Write([]byte(strconv.Itoa(int(v)))); err != nil {
+		return fmt.Errorf("iio: %v", err)
+	}
+	return nil
+}
+
+// toVoltage converts a raw value into a voltage.
+//
+// As per the IIO ABI, (raw + offset) * scale is in millivolts.
+func toVoltage(raw int32, scale, offset float64) physic.ElectricPotential {
+	return physic.ElectricPotential(math.Floor((float64(raw)+offset)*scale*float64(physic.MilliVolt) + 0.5))
+}
+
+// fromVoltage is the reverse of toVoltage.
+func fromVoltage(v physic.ElectricPotential, scale, offset float64) int32 {
+	return int32(math.Floor(float64(v)/float64(physic.MilliVolt)/scale - offset + 0.5))
+}
+
+// newDevice returns the device rooted at root, with the channels found in
+// files, the list of the *_raw files in root.
+func newDevice(number int, root string, files []string) *Device {
+	d := &Device{number: number, root: root}
+	d.name, _ = readString(root + "name")
+	var ins, outs []int
+	for _, file := range files {
+		if i, ok := parseChannel(file, "in_voltage"); ok {
+			ins = append(ins, i)
+		} else if i, ok := parseChannel(file, "out_voltage"); ok {
+			outs = append(outs, i)
+		}
+	}
+	sort.Ints(ins)
+	sort.Ints(outs)
+	for _, i := range ins {
+		a := &ADC{channel: channel{dev: d, index: i, name: fmt.Sprintf("IIO%d_IN%d", number, i)}}
+		a.scale, a.offset = d.readScale("in", i)
+		a.scan = d.readScanElement(i)
+		if a.scan.index >= 0 {
+			a.min, a.max = a.scan.limits()
+		}
+		a.min, a.max = d.readAvailable("in", i, a.min, a.max)
+		d.ADCs = append(d.ADCs, a)
+	}
+	for _, i := range outs {
+		c := &DAC{channel: channel{dev: d, index: i, name: fmt.Sprintf("IIO%d_OUT%d", number, i)}}
+		c.scale, c.offset = d.readScale("out", i)
+		c.min, c.max = d.readAvailable("out", i, 0, 0)
+		d.DACs = append(d.DACs, c)
+	}
+	return d
+}
+
+// parseChannel returns N if file is prefix + "N_raw".
+func parseChannel(file, prefix string) (int, bool) {
+	if !strings.HasPrefix(file, prefix) || !strings.HasSuffix(file, "_raw") {
+		return 0, false
+	}
+	i, err := strconv.Atoi(file[len(prefix) : len(file)-len("_raw")])
+	return i, err == nil && i >= 0
+}
+
+// readAttr reads the attribute of a channel, falling back to the attribute
+// shared by all the channels of the same type.
+func (d *Device) readAttr(dir, prefix string, index int, attr string) (string, error) {
+	s, err := readString(d.root + dir + prefix + "_voltage" + strconv.Itoa(index) + "_" + attr)
+	if err != nil {
+		s, err = readString(d.root + dir + prefix + "_voltage_" + attr)
+	}
+	return s, err
+}
+
+// readScale returns the scale and offset of a channel.
+//
+// Both are optional and default to 1 and 0 respectively.
+func (d *Device) readScale(prefix string, index int) (float64, float64) {
+	scale, offset := 1., 0.
+	if s, err := d.readAttr("", prefix, index, "scale"); err == nil {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			scale = f
+		}
+	}
+	if s, err := d.readAttr("", prefix, index, "offset"); err == nil {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			offset = f
+		}
+	}
+	return scale, offset
+}
+
+// readAvailable returns the range of raw values from the raw_available
+// attribute, if present, otherwise min and max.
+//
+// The attribute is either a range "[min step max]" or a list of values.
+func (d *Device) readAvailable(prefix string, index int, min, max int32) (int32, int32) {
+	s, err := d.readAttr("", prefix, index, "raw_available")
+	if err != nil {
+		return min, max
+	}
+	var values []int32
+	for _, f := range strings.Fields(strings.Trim(s, "[]")) {
+		v, err := strconv.ParseInt(f, 10, 32)
+		if err != nil {
+			return min, max
+		}
+		values = append(values, int32(v))
+	}
+	if len(values) == 0 {
+		return min, max
+	}
+	if s[0] == '[' {
+		if len(values) != 3 {
+			return min, max
+		}
+		return values[0], values[2]
+	}
+	min, max = values[0], values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// readString reads a pseudo-file (sysfs) that is known to contain a single
+// line of text and returns it without the trailing new line.
+func readString(path string) (string, error) {
+	f, err := fileIOOpen(path, os.O_RDONLY)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var b [256]byte
+	n, err := f.Read(b[:])
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b[:n]), "\n"), nil
+}
+
+// writeString writes s to a pseudo-file (sysfs).
+func writeString(path, s string) error {
+	f, err := fileIOOpen(path, os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write([]byte(s)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// driverIIO implements periph.Driver.
+type driverIIO struct {
+}
+
+func (d *driverIIO) String() string {
+	return "iio"
+}
+
+func (d *driverIIO) Prerequisites() []string {
+	return nil
+}
+
+func (d *driverIIO) After() []string {
+	return nil
+}
+
+func (d *driverIIO) Init() (bool, error) {
+	const prefix = "/sys/bus/iio/devices/iio:device"
+	items, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("iio: no device found")
+	}
+	numbers := make([]int, 0, len(items))
+	for _, item := range items {
+		if n, err := strconv.Atoi(item[len(prefix):]); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		root := prefix + strconv.Itoa(n) + "/"
+		raws, err := filepath.Glob(root + "*_raw")
+		if err != nil {
+			return true, err
+		}
+		files := make([]string, 0, len(raws))
+		for _, r := range raws {
+			files = append(files, filepath.Base(r))
+		}
+		Devices = append(Devices, newDevice(n, root, files))
+	}
+	for _, d := range Devices {
+		for _, a := range d.ADCs {
+			if err := analogreg.Register(a); err != nil {
+				return true, err
+			}
+		}
+		for _, c := range d.DACs {
+			if err := analogreg.Register(c); err != nil {
+				return true, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvIIO)
+	}
+}
+
+var drvIIO driverIIO
+
+var _ conn.Resource = &Device{}
+var _ analog.PinIO = &ADC{}
+var _ analog.PinIO = &DAC{}
@@ -0,0 +1,341 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package iio
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/experimental/conn/analog"
+	"periph.io/x/periph/experimental/conn/analog/analogreg"
+	"periph.io/x/periph/host/fs"
+)
+
+func TestNewDevice(t *testing.T) {
+	f := newFakeSysfs(t, map[string]string{
+		"name":                              "ads1015\n",
+		"in_voltage0_raw":                   "1234\n",
+		"in_voltage10_raw":                  "-12\n",
+		"in_voltage_scale":                  "3.000000\n",
+		"in_voltage10_scale":                "0.125\n",
+		"in_voltage10_offset":               "2\n",
+		"out_voltage1_raw":                  "0\n",
+		"out_voltage1_scale":                "0.805664062\n",
+		"out_voltage1_raw_available":        "[0 1 4095]\n",
+		"scan_elements/in_voltage0_index":   "0\n",
+		"scan_elements/in_voltage0_type":    "le:s12/16>>4\n",
+		"scan_elements/in_voltage10_index":  "1\n",
+		"scan_elements/in_voltage10_type":   "be:u8/8>>0\n",
+		"in_voltage10_raw_available":        "0 10 20 5\n",
+		"in_voltage0-voltage1_raw":          "0\n",
+		"scan_elements/in_timestamp_index":  "2\n",
+		"scan_elements/in_timestamp_type":   "le:s64/64>>0\n",
+		"scan_elements/in_voltage10_ignore": "",
+	})
+	defer f.reset()
+	d := newDevice(0, "/iio/", []string{"in_voltage0-voltage1_raw", "in_voltage0_raw", "in_voltage10_raw", "out_voltage1_raw"})
+	if s := d.String(); s != "ads1015(0)" {
+		t.Fatal(s)
+	}
+	if len(d.ADCs) != 2 || len(d.DACs) != 1 {
+		t.Fatal(d.ADCs, d.DACs)
+	}
+	a := d.ADCs[0]
+	if s := a.String(); s != "IIO0_IN0" {
+		t.Fatal(s)
+	}
+	if n := a.Number(); n != 0 {
+		t.Fatal(n)
+	}
+	if min, max := a.Range(); min.Raw != -2048 || max.Raw != 2047 || max.V != 6141*physic.MilliVolt {
+		t.Fatal(min, max)
+	}
+	if v, err := a.Read(); err != nil || v.Raw != 1234 || v.V != 3702*physic.MilliVolt {
+		t.Fatal(v, err)
+	}
+	if a.Out(analog.Sample{}) == nil {
+		t.Fatal("ADC is an input")
+	}
+	a = d.ADCs[1]
+	if min, max := a.Range(); min.Raw != 0 || max.Raw != 20 {
+		t.Fatal(min, max)
+	}
+	if v, err := a.Read(); err != nil || v.Raw != -12 || v.V != -1250*physic.MicroVolt {
+		t.Fatal(v, err)
+	}
+	c := d.DACs[0]
+	if s := c.String(); s != "IIO0_OUT1" {
+		t.Fatal(s)
+	}
+	if min, max := c.Range(); min.Raw != 0 || max.Raw != 4095 {
+		t.Fatal(min, max)
+	}
+	if err := c.Out(analog.Sample{V: 3300 * physic.MilliVolt}); err != nil {
+		t.Fatal(err)
+	}
+	if s := f.files["/iio/out_voltage1_raw"]; s != "4096" {
+		t.Fatal(s)
+	}
+	if v, err := c.Read(); err != nil || v.Raw != 4096 {
+		t.Fatal(v, err)
+	}
+}
+
+func TestNewDevice_empty(t *testing.T) {
+	f := newFakeSysfs(t, map[string]string{})
+	defer f.reset()
+	d := newDevice(3, "/iio/", nil)
+	if s := d.String(); s != "(3)" {
+		t.Fatal(s)
+	}
+	a := &ADC{channel: channel{dev: d, name: "IIO3_IN0"}}
+	if min, max := a.Range(); min != (analog.Sample{}) || max != (analog.Sample{}) {
+		t.Fatal(min, max)
+	}
+	if _, err := a.Read(); err == nil {
+		t.Fatal("missing file")
+	}
+	c := &DAC{channel: channel{dev: d, name: "IIO3_OUT0"}}
+	if err := c.Out(analog.Sample{V: physic.Volt}); err == nil {
+		t.Fatal("missing file")
+	}
+}
+
+func TestCapture(t *testing.T) {
+	f := newFakeSysfs(t, map[string]string{
+		"name":                             "ads1015\n",
+		"buffer/enable":                    "0\n",
+		"buffer/length":                    "2\n",
+		"sampling_frequency":               "128\n",
+		"scan_elements/in_voltage0_en":     "0\n",
+		"scan_elements/in_voltage0_index":  "0\n",
+		"scan_elements/in_voltage0_type":   "le:s12/16>>4\n",
+		"scan_elements/in_voltage1_en":     "1\n",
+		"scan_elements/in_voltage1_index":  "1\n",
+		"scan_elements/in_voltage1_type":   "be:u8/8>>0\n",
+		"scan_elements/in_voltage2_en":     "0\n",
+		"scan_elements/in_voltage2_index":  "2\n",
+		"scan_elements/in_voltage2_type":   "le:u24/32>>0\n",
+		"scan_elements/in_timestamp_en":    "1\n",
+		"scan_elements/in_timestamp_index": "3\n",
+		"scan_elements/in_timestamp_type":  "le:s64/64>>0\n",
+	})
+	defer f.reset()
+	// in_voltage0 at offset 0, in_voltage2 at offset 4, padded to 8 bytes.
+	f.dev = []byte{
+		0xF0, 0xFF, 0, 0, 0x01, 0x02, 0x03, 0x00,
+		0x10, 0x00, 0, 0, 0xFF, 0xFF, 0xFF, 0x00,
+	}
+	d := newDevice(0, "/iio/", []string{"in_voltage0_raw", "in_voltage1_raw", "in_voltage2_raw"})
+	if len(d.ADCs) != 3 {
+		t.Fatal(d.ADCs)
+	}
+	b, err := d.Capture([]*ADC{d.ADCs[2], d.ADCs[0]}, 1600*physic.Hertz, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "ads1015(0)(IIO0_IN2,IIO0_IN0)" {
+		t.Fatal(s)
+	}
+	expected := map[string]string{
+		"/iio/buffer/enable":                 "1",
+		"/iio/buffer/length":                 "1024",
+		"/iio/sampling_frequency":            "1600",
+		"/iio/scan_elements/in_voltage0_en":  "1",
+		"/iio/scan_elements/in_voltage1_en":  "0",
+		"/iio/scan_elements/in_voltage2_en":  "1",
+		"/iio/scan_elements/in_timestamp_en": "0",
+	}
+	for k, v := range expected {
+		if f.files[k] != v {
+			t.Fatalf("%s: %q != %q", k, f.files[k], v)
+		}
+	}
+	if _, err := d.Capture([]*ADC{d.ADCs[0]}, 0, 0); err == nil {
+		t.Fatal("capture already in progress")
+	}
+	samples := make([]analog.Sample, 5)
+	n, err := b.Read(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatal(n)
+	}
+	if samples[0].Raw != 0x030201 || samples[1].Raw != -1 || samples[2].Raw != 0xFFFFFF || samples[3].Raw != 1 {
+		t.Fatal(samples)
+	}
+	if samples[1].V != -physic.MilliVolt {
+		t.Fatal(samples[1])
+	}
+	if _, err := b.Read(samples[:1]); err == nil {
+		t.Fatal("too short")
+	}
+	if err := d.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if s := f.files["/iio/buffer/enable"]; s != "0" {
+		t.Fatal(s)
+	}
+	if err := b.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCapture_fail(t *testing.T) {
+	f := newFakeSysfs(t, map[string]string{
+		"scan_elements/in_voltage0_index": "0\n",
+		"scan_elements/in_voltage0_type":  "le:s12/16>>4\n",
+	})
+	defer f.reset()
+	d := newDevice(0, "/iio/", []string{"in_voltage0_raw", "in_voltage1_raw"})
+	other := newDevice(1, "/iio1/", []string{"in_voltage0_raw"})
+	data := [][]*ADC{
+		nil,
+		{d.ADCs[1]},
+		{d.ADCs[0], d.ADCs[0]},
+		{other.ADCs[0]},
+		// buffer/enable is missing.
+		{d.ADCs[0]},
+	}
+	for i, line := range data {
+		if _, err := d.Capture(line, 0, 0); err == nil {
+			t.Fatalf("#%d: expected failure", i)
+		}
+	}
+	if _, err := d.Capture(d.ADCs[:1], -1, 0); err == nil {
+		t.Fatal("invalid frequency")
+	}
+}
+
+func TestParseScanType(t *testing.T) {
+	data := []struct {
+		in       string
+		expected scanElement
+	}{
+		{"le:s12/16>>4", scanElement{signed: true, bits: 12, storage: 16, shift: 4}},
+		{"be:u24/32>>8", scanElement{bigEndian: true, bits: 24, storage: 32, shift: 8}},
+		{"le:u32/32>>0", scanElement{bits: 32, storage: 32}},
+	}
+	for i, line := range data {
+		s, err := parseScanType(line.in)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if s != line.expected {
+			t.Fatalf("#%d: %#v != %#v", i, s, line.expected)
+		}
+	}
+	for _, in := range []string{"", "xe:s12/16>>4", "le:x12/16>>4", "le:s12/12>>0", "le:s12/16>>8", "le:s64/64>>0", "le:s12/16X2>>4"} {
+		if _, err := parseScanType(in); err == nil {
+			t.Fatalf("%q: expected failure", in)
+		}
+	}
+}
+
+func TestScanElement_limits(t *testing.T) {
+	data := []struct {
+		s        scanElement
+		min, max int32
+	}{
+		{scanElement{signed: true, bits: 12}, -2048, 2047},
+		{scanElement{bits: 12}, 0, 4095},
+		{scanElement{signed: true, bits: 32}, -1 << 31, 1<<31 - 1},
+		{scanElement{bits: 32}, 0, 1<<31 - 1},
+	}
+	for i, line := range data {
+		if min, max := line.s.limits(); min != line.min || max != line.max {
+			t.Fatalf("#%d: %d, %d", i, min, max)
+		}
+	}
+}
+
+func TestDriver(t *testing.T) {
+	defer func() {
+		for _, p := range analogreg.All() {
+			_ = analogreg.Unregister(p.Name())
+		}
+		Devices = nil
+	}()
+	// It may pass or fail, as long as it doesn't panic.
+	_, _ = drvIIO.Init()
+}
+
+//
+
+func init() {
+	fs.Inhibit()
+}
+
+// fakeSysfs implements fileIOOpen on top of an in-memory file tree rooted at
+// "/iio/".
+type fakeSysfs struct {
+	t     *testing.T
+	files map[string]string
+	dev   []byte // content of /dev/iio:device0
+}
+
+func newFakeSysfs(t *testing.T, files map[string]string) *fakeSysfs {
+	f := &fakeSysfs{t: t, files: map[string]string{}}
+	for k, v := range files {
+		f.files["/iio/"+k] = v
+	}
+	fileIOOpen = f.open
+	return f
+}
+
+func (f *fakeSysfs) reset() {
+	fileIOOpen = fileIOOpenDefault
+}
+
+func (f *fakeSysfs) open(path string, flag int) (fileIO, error) {
+	if path == "/dev/iio:device0" && f.dev != nil {
+		return &fakeFile{fs: f, path: path}, nil
+	}
+	if _, ok := f.files[path]; !ok {
+		return nil, errors.New("file not found")
+	}
+	if flag != os.O_RDONLY && flag != os.O_WRONLY && flag != os.O_RDWR {
+		f.t.Fatalf("unexpected flag %d", flag)
+	}
+	return &fakeFile{fs: f, path: path}, nil
+}
+
+// fakeFile implements fileIO.
+type fakeFile struct {
+	fs   *fakeSysfs
+	path string
+	read bool
+}
+
+func (f *fakeFile) Close() error {
+	return nil
+}
+
+func (f *fakeFile) Read(b []byte) (int, error) {
+	if f.path == "/dev/iio:device0" {
+		n := copy(b, f.fs.dev)
+		f.fs.dev = f.fs.dev[n:]
+		return n, nil
+	}
+	if f.read {
+		return 0, io.EOF
+	}
+	f.read = true
+	return copy(b, f.fs.files[f.path]), nil
+}
+
+func (f *fakeFile) Seek(offset int64, whence int) (int64, error) {
+	f.read = false
+	return 0, nil
+}
+
+func (f *fakeFile) Write(b []byte) (int, error) {
+	f.fs.files[f.path] = string(b)
+	return len(b), nil
+}
@@ -472,7 +472,7 @@ func (d *driverGPIOPL) Prerequisites() []string {
 }
 
 func (d *driverGPIOPL) After() []string {
-	return []string{"sysfs-gpio"}
+	return []string{"sysfs-gpio", "sysfs-gpiochip"}
 }
 
 func (d *driverGPIOPL) Init() (bool, error) {
@@ -0,0 +1,137 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package allwinner
+
+import (
+	"errors"
+	"strings"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Group implements gpio.PinGrouper.
+//
+// All the pins must be available Allwinner pins in ports PB to PH and the
+// memory mapped registers must be accessible. PL pins are rejected since they
+// are in a separate memory region. The returned gpio.Group writes all the pins
+// of each port with a single read-modify-write of the port data register, and
+// reads all the pins with one read per port.
+//
+// Use gpio.NewGroup() instead of calling this function directly.
+func (p *Pin) Group(pins []gpio.PinIO) (gpio.Group, error) {
+	if drvGPIO.gpioMemory == nil {
+		return nil, errors.New("allwinner-gpio: subsystem gpiomem not initialized")
+	}
+	g := &pinGroup{pins: pins, real: make([]*Pin, 0, len(pins))}
+	for _, pin := range pins {
+		rp := gpio.Real(pin)
+		if _, ok := rp.(*PinPL); ok {
+			return nil, errors.New("allwinner-gpio: " + pin.String() + " is a PL pin; PL pins are not supported in a group")
+		}
+		r, ok := rp.(*Pin)
+		if !ok || !r.available {
+			return nil, errors.New("allwinner-gpio: " + pin.String() + " is not an available Allwinner pin")
+		}
+		g.real = append(g.real, r)
+	}
+	return g, nil
+}
+
+//
+
+// pinGroup implements gpio.Group for Allwinner pins.
+type pinGroup struct {
+	pins []gpio.PinIO
+	real []*Pin
+}
+
+func (g *pinGroup) String() string {
+	names := make([]string, 0, len(g.pins))
+	for _, p := range g.pins {
+		names = append(names, p.Name())
+	}
+	return "Group(" + strings.Join(names, ",") + ")"
+}
+
+// Halt implements conn.Resource.
+func (g *pinGroup) Halt() error {
+	for _, p := range g.real {
+		if err := p.Halt(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pins implements gpio.Group.
+func (g *pinGroup) Pins() []gpio.PinIO {
+	return g.pins
+}
+
+// In implements gpio.Group.
+func (g *pinGroup) In(pull gpio.Pull) error {
+	for _, p := range g.real {
+		if err := p.In(pull, gpio.NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.Group.
+func (g *pinGroup) Read() uint64 {
+	var data [len(gpioMap{}.groups)]uint32
+	var read [len(data)]bool
+	var out uint64
+	for i, p := range g.real {
+		if !read[p.group] {
+			data[p.group] = drvGPIO.gpioMemory.groups[p.group].data
+			read[p.group] = true
+		}
+		if data[p.group]&(1<<p.offset) != 0 {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+// Out implements gpio.Group.
+//
+// The port data register has no set and clear registers so it is updated
+// with a read-modify-write that is not atomic, like Pin.FastOut(). A
+// concurrent write to another pin of the same port, via another Group or a
+// Pin, may be lost.
+func (g *pinGroup) Out(mask, values uint64) error {
+	var set, clear [len(gpioMap{}.groups)]uint32
+	for i, p := range g.real {
+		bit := uint64(1) << uint(i)
+		if mask&bit == 0 {
+			continue
+		}
+		l := gpio.Level(values&bit != 0)
+		if p.function() != out {
+			// Slow path, only taken the first time.
+			if err := p.Out(l); err != nil {
+				return err
+			}
+			continue
+		}
+		b := uint32(1) << p.offset
+		if l {
+			set[p.group] |= b
+		} else {
+			clear[p.group] |= b
+		}
+	}
+	for i := range set {
+		if set[i] != 0 || clear[i] != 0 {
+			drvGPIO.gpioMemory.groups[i].data = drvGPIO.gpioMemory.groups[i].data&^clear[i] | set[i]
+		}
+	}
+	return nil
+}
+
+var _ gpio.Group = &pinGroup{}
+var _ gpio.PinGrouper = &Pin{}
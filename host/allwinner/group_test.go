@@ -0,0 +1,106 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package allwinner
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestPinGroup(t *testing.T) {
+	defer setupGroup()()
+	pins := []gpio.PinIO{PB0, PB5, PC1}
+	g, err := gpio.NewGroup(pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*pinGroup); !ok {
+		t.Fatalf("expected fast path, got %T", g)
+	}
+	if s := g.String(); s != "Group(PB0,PB5,PC1)" {
+		t.Fatal(s)
+	}
+	if len(g.Pins()) != 3 {
+		t.Fatal(g.Pins())
+	}
+	drvGPIO.gpioMemory.groups[1].data = 1 << 5
+	drvGPIO.gpioMemory.groups[2].data = 1 << 1
+	if v := g.Read(); v != 6 {
+		t.Fatal(v)
+	}
+	// The first call sets the pins as output one by one.
+	if err := g.Out(7, 5); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pins {
+		if f := p.(*Pin).function(); f != out {
+			t.Fatal(p, f)
+		}
+	}
+	if d := drvGPIO.gpioMemory.groups[1].data; d != 1 {
+		t.Fatalf("%#x", d)
+	}
+	if d := drvGPIO.gpioMemory.groups[2].data; d != 1<<1 {
+		t.Fatalf("%#x", d)
+	}
+	// The pins not in the group are not modified.
+	drvGPIO.gpioMemory.groups[1].data |= 0x100
+	if err := g.Out(3, 2); err != nil {
+		t.Fatal(err)
+	}
+	if d := drvGPIO.gpioMemory.groups[1].data; d != 0x120 {
+		t.Fatalf("%#x", d)
+	}
+	if d := drvGPIO.gpioMemory.groups[2].data; d != 1<<1 {
+		t.Fatalf("%#x", d)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinGroup_invalid(t *testing.T) {
+	defer setupGroup()()
+	if _, err := PB0.Group([]gpio.PinIO{PB0, PL0}); err == nil {
+		t.Fatal("PL pins are not supported")
+	}
+	if _, err := PB0.Group([]gpio.PinIO{PB0, &gpiotest.Pin{N: "fake"}}); err == nil {
+		t.Fatal("not an Allwinner pin")
+	}
+	g, err := gpio.NewGroup(PB0, PL0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*pinGroup); ok {
+		t.Fatal("expected generic group")
+	}
+	drvGPIO.gpioMemory = nil
+	if _, err := PB0.Group([]gpio.PinIO{PB0}); err == nil {
+		t.Fatal("gpiomem not initialized")
+	}
+}
+
+//
+
+// setupGroup fakes the GPIO memory and makes PB0, PB5 and PC1 available. It
+// returns a function to restore the previous state.
+func setupGroup() func() {
+	mem := drvGPIO.gpioMemory
+	drvGPIO.gpioMemory = &gpioMap{}
+	pins := []*Pin{PB0, PB5, PC1}
+	available := make([]bool, len(pins))
+	for i, p := range pins {
+		available[i] = p.available
+		p.available = true
+	}
+	return func() {
+		drvGPIO.gpioMemory = mem
+		for i, p := range pins {
+			p.available = available[i]
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"errors"
+	"strings"
+
+	"periph.io/x/periph/conn/gpio"
+)
+
+// Group implements gpio.PinGrouper.
+//
+// All the pins must be bcm283x pins and the memory mapped registers must be
+// accessible. The returned gpio.Group writes all the pins of each bank of 32
+// pins with one write to the clear register followed by one write to the set
+// register, and reads all the pins with one read per bank.
+//
+// Use gpio.NewGroup() instead of calling this function directly.
+func (p *Pin) Group(pins []gpio.PinIO) (gpio.Group, error) {
+	if drvGPIO.gpioMemory == nil {
+		return nil, errors.New("bcm283x-gpio: subsystem gpiomem not initialized")
+	}
+	g := &pinGroup{pins: pins, real: make([]*Pin, 0, len(pins))}
+	for _, pin := range pins {
+		r, ok := gpio.Real(pin).(*Pin)
+		if !ok {
+			return nil, errors.New("bcm283x-gpio: " + pin.String() + " is not a bcm283x pin")
+		}
+		g.real = append(g.real, r)
+	}
+	return g, nil
+}
+
+//
+
+// pinGroup implements gpio.Group for bcm283x pins.
+type pinGroup struct {
+	pins []gpio.PinIO
+	real []*Pin
+}
+
+func (g *pinGroup) String() string {
+	names := make([]string, 0, len(g.pins))
+	for _, p := range g.pins {
+		names = append(names, p.Name())
+	}
+	return "Group(" + strings.Join(names, ",") + ")"
+}
+
+// Halt implements conn.Resource.
+func (g *pinGroup) Halt() error {
+	for _, p := range g.real {
+		if err := p.Halt(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pins implements gpio.Group.
+func (g *pinGroup) Pins() []gpio.PinIO {
+	return g.pins
+}
+
+// In implements gpio.Group.
+func (g *pinGroup) In(pull gpio.Pull) error {
+	for _, p := range g.real {
+		if err := p.In(pull, gpio.NoEdge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read implements gpio.Group.
+func (g *pinGroup) Read() uint64 {
+	level := [2]uint32{drvGPIO.gpioMemory.level[0], drvGPIO.gpioMemory.level[1]}
+	var out uint64
+	for i, p := range g.real {
+		if level[p.number/32]&(1<<uint(p.number&31)) != 0 {
+			out |= 1 << uint(i)
+		}
+	}
+	return out
+}
+
+// Out implements gpio.Group.
+//
+// The pins going low are changed a few nanoseconds before the pins going high.
+func (g *pinGroup) Out(mask, values uint64) error {
+	var set, clear [2]uint32
+	for i, p := range g.real {
+		bit := uint64(1) << uint(i)
+		if mask&bit == 0 {
+			continue
+		}
+		l := gpio.Level(values&bit != 0)
+		if p.function() != out {
+			// Slow path, only taken the first time.
+			if err := p.Out(l); err != nil {
+				return err
+			}
+			continue
+		}
+		b := uint32(1) << uint(p.number&31)
+		if l {
+			set[p.number/32] |= b
+		} else {
+			clear[p.number/32] |= b
+		}
+	}
+	for i := range set {
+		if clear[i] != 0 {
+			drvGPIO.gpioMemory.outputClear[i] = clear[i]
+		}
+		if set[i] != 0 {
+			drvGPIO.gpioMemory.outputSet[i] = set[i]
+		}
+	}
+	return nil
+}
+
+var _ gpio.Group = &pinGroup{}
+var _ gpio.PinGrouper = &Pin{}
@@ -0,0 +1,76 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package bcm283x
+
+import (
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpiotest"
+)
+
+func TestPinGroup(t *testing.T) {
+	defer reset()
+	pins := []gpio.PinIO{&cpuPins[4], &cpuPins[12], &cpuPins[40]}
+	g, err := gpio.NewGroup(pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*pinGroup); !ok {
+		t.Fatalf("expected fast path, got %T", g)
+	}
+	if s := g.String(); s != "Group(GPIO4,GPIO12,GPIO40)" {
+		t.Fatal(s)
+	}
+	if len(g.Pins()) != 3 {
+		t.Fatal(g.Pins())
+	}
+	// GPIO4, GPIO12 and GPIO40 are set in setMemory().
+	if v := g.Read(); v != 7 {
+		t.Fatal(v)
+	}
+	// The first call sets the pins as output one by one.
+	if err := g.Out(7, 5); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pins {
+		if f := p.(*Pin).function(); f != out {
+			t.Fatal(p, f)
+		}
+	}
+	drvGPIO.gpioMemory.outputSet = [2]uint32{}
+	drvGPIO.gpioMemory.outputClear = [2]uint32{}
+	if err := g.Out(7, 2); err != nil {
+		t.Fatal(err)
+	}
+	if s := drvGPIO.gpioMemory.outputSet; s != [2]uint32{1 << 12, 0} {
+		t.Fatalf("%#x", s)
+	}
+	if c := drvGPIO.gpioMemory.outputClear; c != [2]uint32{1 << 4, 1 << 8} {
+		t.Fatalf("%#x", c)
+	}
+	if err := g.In(gpio.PullNoChange); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPinGroup_fallback(t *testing.T) {
+	defer reset()
+	pins := []gpio.PinIO{&cpuPins[4], &gpiotest.Pin{N: "fake"}}
+	g, err := gpio.NewGroup(pins...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.(*pinGroup); ok {
+		t.Fatal("expected generic group")
+	}
+	drvGPIO.gpioMemory = nil
+	if _, err := cpuPins[4].Group(pins[:1]); err == nil {
+		t.Fatal("gpiomem not initialized")
+	}
+}
@@ -92,6 +92,16 @@ func (e *Event) MakeEvent(fd uintptr) error {
 	return e.event.makeEvent(fd)
 }
 
+// MakeReadEvent initializes an epoll *level* triggered event on linux that is
+// signaled as long as there is data to read on the file handle.
+//
+// This is the kind of event to use for character devices that queue events
+// in a kernel buffer, like /dev/gpiochipN line requests, where each Wait()
+// call is expected to be followed by a Read() to consume the data.
+func (e *Event) MakeReadEvent(fd uintptr) error {
+	return e.event.makeReadEvent(fd)
+}
+
 // Wait waits for an event or the specified amount of time.
 func (e *Event) Wait(timeoutms int) (int, error) {
 	return e.event.wait(timeoutms)
@@ -0,0 +1,93 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package sysfs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/host/fs"
+)
+
+// WaitForEdgeContext implements gpio.PinInContext.
+func (p *Pin) WaitForEdgeContext(ctx context.Context) error {
+	// Run lockless, like WaitForEdge().
+	if err := waitEventContext(ctx, &p.event, p.String()+".WaitForEdge"); err != nil {
+		if _, ok := err.(waitErr); ok {
+			return p.wrap(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// WaitForEdgeContext implements gpio.PinInContext.
+//
+// Each call consumes one edge event queued by the kernel.
+func (l *Line) WaitForEdgeContext(ctx context.Context) error {
+	l.mu.Lock()
+	hasEvent, f := l.hasEvent, l.f
+	l.mu.Unlock()
+	if !hasEvent {
+		return l.wrap(errNoEdge)
+	}
+	if err := waitEventContext(ctx, &l.event, l.String()+".WaitForEdge"); err != nil {
+		if _, ok := err.(waitErr); ok {
+			return l.wrap(err)
+		}
+		return err
+	}
+	var ev gpioV2LineEvent
+	if err := readEvent(f, &ev); err != nil {
+		return l.wrap(err)
+	}
+	return nil
+}
+
+//
+
+// eventPollMS is the longest time in milliseconds waitEventContext() waits
+// before checking whether the context is done.
+const eventPollMS = 100
+
+// waitEventContext waits for e to be ready until ctx is done.
+//
+// Errors returned by e.Wait() are returned as waitErr.
+func waitEventContext(ctx context.Context, e *fs.Event, op string) error {
+	for {
+		if err := conn.ContextErr(ctx, op); err != nil {
+			return err
+		}
+		ms := eventPollMS
+		if d, ok := ctx.Deadline(); ok {
+			if r := int(d.Sub(time.Now()) / time.Millisecond); r < ms {
+				ms = r
+			}
+		}
+		if ms < 0 {
+			ms = 0
+		}
+		if nr, err := e.Wait(ms); err != nil {
+			return waitErr{err}
+		} else if nr == 1 {
+			return nil
+		}
+	}
+}
+
+var errNoEdge = errors.New("edge detection is not enabled")
+
+// waitErr is an error returned by fs.Event.Wait().
+type waitErr struct {
+	error
+}
+
+var _ gpio.PinInContext = &Pin{}
+var _ gpio.PinInContext = &Line{}
@@ -0,0 +1,28 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// +build go1.7
+
+package sysfs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLine_WaitForEdgeContext_noEdge(t *testing.T) {
+	l := Line{number: 42, name: "GPIO42"}
+	if err := l.WaitForEdgeContext(context.Background()); err == nil {
+		t.Fatal("edge detection is not enabled")
+	}
+}
+
+func TestWaitEventContext_canceled(t *testing.T) {
+	p := Pin{number: 42, name: "foo"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.WaitForEdgeContext(ctx); err != context.Canceled {
+		t.Fatal(err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"periph.io/x/periph"
@@ -48,6 +49,7 @@ type Pin struct {
 	fValue     fileIO    // handle to /sys/class/gpio/gpio*/value; never closed
 	event      fs.Event  // Initialized once
 	buf        [4]byte   // scratch buffer for Function(), Read() and Out()
+	seq        uint32    // Edge event sequence number; accessed atomically
 }
 
 // String implements conn.Resource.
@@ -244,6 +246,20 @@ func (p *Pin) WaitForEdge(timeout time.Duration) bool {
 	}
 }
 
+// WaitForEdgeEvent implements gpio.PinEdgeEvent.
+//
+// GPIO sysfs doesn't provide the time of the interrupt, so the timestamp is
+// when the edge was observed by this process and the level is read right
+// after. Edges that occur in quick succession may be coalesced without a gap
+// in the sequence number.
+func (p *Pin) WaitForEdgeEvent(timeout time.Duration) (gpio.EdgeEvent, bool) {
+	if !p.WaitForEdge(timeout) {
+		return gpio.EdgeEvent{}, false
+	}
+	t := time.Since(edgeOrigin)
+	return gpio.EdgeEvent{Timestamp: t, Level: p.Read(), Seq: atomic.AddUint32(&p.seq, 1)}, true
+}
+
 // Pull implements gpio.PinIn.
 //
 // It returns gpio.PullNoChange since gpio sysfs has no support for input pull
@@ -298,8 +314,12 @@ func (p *Pin) Out(l gpio.Level) error {
 
 // PWM implements gpio.PinOut.
 //
-// This is not supported on sysfs.
-func (p *Pin) PWM(gpio.Duty, physic.Frequency) error {
+// This is only supported when the pin is muxed to a hardware PWM channel
+// exposed in /sys/class/pwm, see PWMs.
+func (p *Pin) PWM(d gpio.Duty, f physic.Frequency) error {
+	if c := drvPWM.gpios[p.number]; c != nil {
+		return c.PWM(d, f)
+	}
 	return p.wrap(errors.New("pwm is not supported via sysfs"))
 }
 
@@ -382,6 +402,10 @@ const (
 	dOut     direction = 2
 )
 
+// edgeOrigin is the origin of the timestamps of the edges observed by this
+// process.
+var edgeOrigin = time.Now()
+
 var (
 	bIn      = []byte("in")
 	bLow     = []byte("low")
@@ -508,4 +532,5 @@ var _ conn.Resource = &Pin{}
 var _ gpio.PinIn = &Pin{}
 var _ gpio.PinOut = &Pin{}
 var _ gpio.PinIO = &Pin{}
+var _ gpio.PinEdgeEvent = &Pin{}
 var _ pin.PinFunc = &Pin{}
@@ -0,0 +1,803 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+	"periph.io/x/periph/host/fs"
+)
+
+// Lines is all the GPIO lines exported by the GPIO character devices
+// /dev/gpiochipN.
+//
+// The key is the global GPIO number. It is the same number as used in Pins
+// when the GPIO sysfs interface is also available, otherwise lines are
+// numbered consecutively in the order of the gpiochip devices.
+//
+// This global variable is initialized once at driver initialization and isn't
+// mutated afterward. Do not modify it.
+var Lines map[int]*Line
+
+// Line represents one GPIO line as found on a GPIO character device.
+//
+// Unlike Pin, it supports internal pull resistors via the line bias flags and
+// the line is only requested to the kernel on first use.
+type Line struct {
+	number int
+	name   string
+	offset uint32
+	label  string // Line name as provided by the kernel, often from the device tree.
+	chip   *gpioChip
+
+	mu        sync.Mutex
+	f         fileIO        // line request handle; nil until the line is requested
+	direction direction     // Cache of the last known direction
+	pull      gpio.Pull     // Cache of the last pull used
+	edge      gpio.Edge     // Cache of the last edge used
+	debounce  time.Duration // Debounce period, 0 when disabled
+	event     fs.Event      // Initialized once
+	hasEvent  bool
+	// Ioctl arguments. They are kept here so they don't escape to the heap.
+	v   gpioV2LineValues
+	cfg gpioV2LineConfig
+}
+
+// String implements conn.Resource.
+func (l *Line) String() string {
+	return l.name
+}
+
+// Halt implements conn.Resource.
+//
+// It stops edge detection if enabled.
+func (l *Line) Halt() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.edge == gpio.NoEdge {
+		return nil
+	}
+	if err := l.configure(l.direction, l.pull, gpio.NoEdge, gpio.Low); err != nil {
+		return l.wrap(err)
+	}
+	return nil
+}
+
+// Name implements pin.Pin.
+func (l *Line) Name() string {
+	return l.name
+}
+
+// Number implements pin.Pin.
+func (l *Line) Number() int {
+	return l.number
+}
+
+// Function implements pin.Pin.
+func (l *Line) Function() string {
+	return string(l.Func())
+}
+
+// Func implements pin.PinFunc.
+//
+// When the line is not requested by this process, only the direction is
+// reported since the level cannot be read.
+func (l *Line) Func() pin.Func {
+	l.mu.Lock()
+	d := l.direction
+	requested := l.f != nil
+	l.mu.Unlock()
+	if requested {
+		switch d {
+		case dIn:
+			if l.Read() {
+				return gpio.IN_HIGH
+			}
+			return gpio.IN_LOW
+		case dOut:
+			if l.Read() {
+				return gpio.OUT_HIGH
+			}
+			return gpio.OUT_LOW
+		}
+	}
+	var info gpioV2LineInfo
+	if err := l.chip.lineInfo(l.offset, &info); err != nil {
+		return pin.Func("ERR")
+	}
+	if info.flags&lineFlagOutput != 0 {
+		return gpio.OUT
+	}
+	if info.flags&lineFlagInput != 0 {
+		return gpio.IN
+	}
+	return pin.Func("ERR")
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (l *Line) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.IN, gpio.OUT}
+}
+
+// SetFunc implements pin.PinFunc.
+func (l *Line) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.IN:
+		return l.In(gpio.PullNoChange, gpio.NoEdge)
+	case gpio.OUT_HIGH:
+		return l.Out(gpio.High)
+	case gpio.OUT, gpio.OUT_LOW:
+		return l.Out(gpio.Low)
+	default:
+		return l.wrap(errors.New("unsupported function"))
+	}
+}
+
+// Consumer returns the label of the consumer currently holding this line, as
+// reported by the kernel.
+//
+// It returns an empty string if the line is not in use.
+func (l *Line) Consumer() string {
+	var info gpioV2LineInfo
+	if err := l.chip.lineInfo(l.offset, &info); err != nil {
+		return ""
+	}
+	return cString(info.consumer[:])
+}
+
+// In implements gpio.PinIn.
+//
+// Pull resistors are supported via the line bias flags. Not all GPIO
+// controllers support bias; in this case the kernel returns an error.
+func (l *Line) In(pull gpio.Pull, edge gpio.Edge) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.configure(dIn, pull, edge, gpio.Low); err != nil {
+		return l.wrap(err)
+	}
+	if edge != gpio.NoEdge && !l.hasEvent {
+		if err := l.event.MakeReadEvent(l.f.Fd()); err != nil {
+			return l.wrap(err)
+		}
+		l.hasEvent = true
+	}
+	// Flush the edges that may have accumulated while reconfiguring.
+	l.flushEdges()
+	return nil
+}
+
+// Read implements gpio.PinIn.
+func (l *Line) Read() gpio.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return gpio.Low
+	}
+	l.v = gpioV2LineValues{mask: 1}
+	if err := l.f.Ioctl(ioctlGPIOV2LineGetValues, uintptr(unsafe.Pointer(&l.v))); err != nil {
+		return gpio.Low
+	}
+	return gpio.Level(l.v.bits&1 != 0)
+}
+
+// WaitForEdge implements gpio.PinIn.
+//
+// Each call consumes one edge event queued by the kernel.
+func (l *Line) WaitForEdge(timeout time.Duration) bool {
+	var ev gpioV2LineEvent
+	return l.waitForEvent(timeout, &ev)
+}
+
+// WaitForEdgeEvent implements gpio.PinEdgeEvent.
+//
+// The timestamp is taken by the kernel upon the interrupt and the sequence
+// number is the kernel's one for this line, so lost edges can be detected.
+func (l *Line) WaitForEdgeEvent(timeout time.Duration) (gpio.EdgeEvent, bool) {
+	var ev gpioV2LineEvent
+	if !l.waitForEvent(timeout, &ev) {
+		return gpio.EdgeEvent{}, false
+	}
+	return gpio.EdgeEvent{
+		Timestamp: time.Duration(ev.timestampNs),
+		Level:     ev.id == gpioV2LineEventRisingEdge,
+		Seq:       ev.lineSeqno,
+	}, true
+}
+
+// Debounce implements gpio.PinDebouncer.
+//
+// The kernel uses the GPIO controller's hardware debouncer when available and
+// emulates it otherwise. The period is rounded down to the microsecond. It
+// takes effect immediately if the line is an input, otherwise on the next
+// call to In().
+func (l *Line) Debounce(period time.Duration) error {
+	if period < 0 || period/time.Microsecond > math.MaxUint32 {
+		return l.wrap(errors.New("invalid debounce period"))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.debounce
+	l.debounce = period
+	if l.f != nil && l.direction == dIn {
+		if err := l.configure(dIn, l.pull, l.edge, gpio.Low); err != nil {
+			l.debounce = old
+			return l.wrap(err)
+		}
+	}
+	return nil
+}
+
+// Pull implements gpio.PinIn.
+func (l *Line) Pull() gpio.Pull {
+	l.mu.Lock()
+	pull := l.pull
+	l.mu.Unlock()
+	if pull != gpio.PullNoChange {
+		return pull
+	}
+	var info gpioV2LineInfo
+	if err := l.chip.lineInfo(l.offset, &info); err != nil {
+		return gpio.PullNoChange
+	}
+	switch {
+	case info.flags&lineFlagBiasPullUp != 0:
+		return gpio.PullUp
+	case info.flags&lineFlagBiasPullDown != 0:
+		return gpio.PullDown
+	case info.flags&lineFlagBiasDisabled != 0:
+		return gpio.Float
+	}
+	return gpio.PullNoChange
+}
+
+// DefaultPull implements gpio.PinIn.
+//
+// It returns gpio.PullNoChange since the GPIO character device doesn't expose
+// the reset value.
+func (l *Line) DefaultPull() gpio.Pull {
+	return gpio.PullNoChange
+}
+
+// Out implements gpio.PinOut.
+func (l *Line) Out(level gpio.Level) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.direction != dOut || l.f == nil {
+		if err := l.configure(dOut, gpio.PullNoChange, gpio.NoEdge, level); err != nil {
+			return l.wrap(err)
+		}
+		return nil
+	}
+	l.v = gpioV2LineValues{mask: 1}
+	if level {
+		l.v.bits = 1
+	}
+	if err := l.f.Ioctl(ioctlGPIOV2LineSetValues, uintptr(unsafe.Pointer(&l.v))); err != nil {
+		return l.wrap(err)
+	}
+	return nil
+}
+
+// PWM implements gpio.PinOut.
+//
+// The GPIO character device doesn't support PWM, so this is only supported
+// when the line is muxed to a hardware PWM channel exposed in /sys/class/pwm,
+// see PWMs.
+func (l *Line) PWM(d gpio.Duty, f physic.Frequency) error {
+	if c := drvPWM.gpios[l.number]; c != nil {
+		return c.PWM(d, f)
+	}
+	return l.wrap(errors.New("pwm is not supported via gpiochip"))
+}
+
+//
+
+// configure requests the line if needed, otherwise reconfigures it.
+//
+// lock must be held.
+func (l *Line) configure(d direction, pull gpio.Pull, edge gpio.Edge, level gpio.Level) error {
+	cfg := &l.cfg
+	*cfg = gpioV2LineConfig{}
+	switch d {
+	case dIn:
+		cfg.flags = lineFlagInput
+		switch pull {
+		case gpio.Float:
+			cfg.flags |= lineFlagBiasDisabled
+		case gpio.PullDown:
+			cfg.flags |= lineFlagBiasPullDown
+		case gpio.PullUp:
+			cfg.flags |= lineFlagBiasPullUp
+		case gpio.PullNoChange:
+		default:
+			return errors.New("invalid pull")
+		}
+		switch edge {
+		case gpio.NoEdge:
+		case gpio.RisingEdge:
+			cfg.flags |= lineFlagEdgeRising
+		case gpio.FallingEdge:
+			cfg.flags |= lineFlagEdgeFalling
+		case gpio.BothEdges:
+			cfg.flags |= lineFlagEdgeRising | lineFlagEdgeFalling
+		default:
+			return errors.New("invalid edge")
+		}
+		if us := uint32(l.debounce / time.Microsecond); us != 0 {
+			cfg.numAttrs = 1
+			cfg.attrs[0].attr.id = lineAttrIDDebounce
+			cfg.attrs[0].mask = 1
+			// debounce_period_us is a __u32 in a union with a __u64.
+			*(*uint32)(unsafe.Pointer(&cfg.attrs[0].attr.value)) = us
+		}
+	case dOut:
+		cfg.flags = lineFlagOutput
+		cfg.numAttrs = 1
+		cfg.attrs[0].attr.id = lineAttrIDOutputValues
+		cfg.attrs[0].mask = 1
+		if level {
+			cfg.attrs[0].attr.value = 1
+		}
+	default:
+		return errors.New("invalid direction")
+	}
+	if l.f == nil {
+		f, err := l.chip.request(l.offset, cfg)
+		if err != nil {
+			return err
+		}
+		l.f = f
+	} else if err := l.f.Ioctl(ioctlGPIOV2LineSetConfig, uintptr(unsafe.Pointer(cfg))); err != nil {
+		return err
+	}
+	l.direction = d
+	l.pull = pull
+	l.edge = edge
+	return nil
+}
+
+// waitForEvent waits for the next edge event and stores it in ev.
+func (l *Line) waitForEvent(timeout time.Duration, ev *gpioV2LineEvent) bool {
+	// Only snapshot the state under the lock, as the wait itself must not block
+	// In().
+	l.mu.Lock()
+	hasEvent, f := l.hasEvent, l.f
+	l.mu.Unlock()
+	if !hasEvent {
+		return false
+	}
+	var ms int
+	if timeout == -1 {
+		ms = -1
+	} else {
+		ms = int(timeout / time.Millisecond)
+	}
+	start := time.Now()
+	for {
+		if nr, err := l.event.Wait(ms); err != nil {
+			return false
+		} else if nr == 1 {
+			return readEvent(f, ev) == nil
+		}
+		// A signal occurred.
+		if timeout != -1 {
+			ms = int((timeout - time.Since(start)) / time.Millisecond)
+		}
+		if ms <= 0 {
+			return false
+		}
+	}
+}
+
+// readEvent reads one edge event from the line request handle f.
+func readEvent(f fileIO, ev *gpioV2LineEvent) error {
+	buf := (*[unsafe.Sizeof(gpioV2LineEvent{})]byte)(unsafe.Pointer(ev))
+	n, err := f.Read(buf[:])
+	if err != nil {
+		return err
+	}
+	if n != len(buf) {
+		return errors.New("short read")
+	}
+	return nil
+}
+
+// flushEdges discards the edge events queued by the kernel.
+//
+// lock must be held.
+func (l *Line) flushEdges() {
+	if !l.hasEvent {
+		return
+	}
+	var ev gpioV2LineEvent
+	for {
+		if nr, err := l.event.Wait(0); err != nil || nr != 1 || readEvent(l.f, &ev) != nil {
+			return
+		}
+	}
+}
+
+func (l *Line) wrap(err error) error {
+	return fmt.Errorf("sysfs-gpiochip (%s): %v", l, err)
+}
+
+//
+
+// gpioChip is an open GPIO character device.
+type gpioChip struct {
+	path  string // Something like /dev/gpiochip0
+	name  string
+	label string
+	lines uint32
+
+	mu sync.Mutex
+	f  ioctlCloser
+	// Ioctl arguments. They are kept here so they don't escape to the heap.
+	info gpioV2LineInfo
+	req  gpioV2LineRequest
+}
+
+func (c *gpioChip) lineInfo(offset uint32, info *gpioV2LineInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.info = gpioV2LineInfo{offset: offset}
+	if err := c.f.Ioctl(ioctlGPIOV2GetLineInfo, uintptr(unsafe.Pointer(&c.info))); err != nil {
+		return err
+	}
+	*info = c.info
+	return nil
+}
+
+// request requests a single line with the initial configuration cfg.
+func (c *gpioChip) request(offset uint32, cfg *gpioV2LineConfig) (fileIO, error) {
+	c.mu.Lock()
+	c.req = gpioV2LineRequest{numLines: 1, config: *cfg}
+	c.req.offsets[0] = offset
+	copy(c.req.consumer[:len(c.req.consumer)-1], consumerLabel)
+	err := c.f.Ioctl(ioctlGPIOV2GetLine, uintptr(unsafe.Pointer(&c.req)))
+	fd := c.req.fd
+	c.mu.Unlock()
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("need more access, try as root or setup udev rules: %v", err)
+		}
+		return nil, err
+	}
+	return lineFileOpen(fd, fmt.Sprintf("%s:%d", c.path, offset))
+}
+
+var lineFileOpen = lineFileOpenDefault
+
+func lineFileOpenDefault(fd int32, name string) (fileIO, error) {
+	if fd < 0 {
+		return nil, errors.New("invalid line handle")
+	}
+	return &fs.File{File: os.NewFile(uintptr(fd), name)}, nil
+}
+
+// cString returns the NUL terminated string in b.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// consumerLabel is the consumer name reported to the kernel when requesting
+// lines.
+const consumerLabel = "periph"
+
+// GPIO character device uAPI v2 IOCTL control codes.
+//
+// Constants and structure definition can be found at
+// /usr/include/linux/gpio.h.
+const (
+	ioctlGPIOGetChipInfo      = 0x8044B401 // GPIO_GET_CHIPINFO_IOCTL
+	ioctlGPIOV2GetLineInfo    = 0xC100B405 // GPIO_V2_GET_LINEINFO_IOCTL
+	ioctlGPIOV2GetLine        = 0xC250B407 // GPIO_V2_GET_LINE_IOCTL
+	ioctlGPIOV2LineSetConfig  = 0xC110B40D // GPIO_V2_LINE_SET_CONFIG_IOCTL
+	ioctlGPIOV2LineGetValues  = 0xC010B40E // GPIO_V2_LINE_GET_VALUES_IOCTL
+	ioctlGPIOV2LineSetValues  = 0xC010B40F // GPIO_V2_LINE_SET_VALUES_IOCTL
+	gpioMaxNameSize           = 32
+	gpioV2LinesMax            = 64
+	gpioV2LineNumAttrsMax     = 10
+	gpioV2LineEventRisingEdge = 1
+)
+
+// enum gpio_v2_line_flag
+const (
+	lineFlagUsed         = 1 << 0
+	lineFlagActiveLow    = 1 << 1
+	lineFlagInput        = 1 << 2
+	lineFlagOutput       = 1 << 3
+	lineFlagEdgeRising   = 1 << 4
+	lineFlagEdgeFalling  = 1 << 5
+	lineFlagOpenDrain    = 1 << 6
+	lineFlagOpenSource   = 1 << 7
+	lineFlagBiasPullUp   = 1 << 8
+	lineFlagBiasPullDown = 1 << 9
+	lineFlagBiasDisabled = 1 << 10
+)
+
+// enum gpio_v2_line_attr_id
+const (
+	lineAttrIDFlags        = 1
+	lineAttrIDOutputValues = 2
+	lineAttrIDDebounce     = 3
+)
+
+// The structures below contain __aligned_u64 members; they are laid out so
+// that the offsets are the same on 32 bits and 64 bits platforms.
+
+type gpioChipInfo struct {
+	name  [gpioMaxNameSize]byte
+	label [gpioMaxNameSize]byte
+	lines uint32
+}
+
+type gpioV2LineValues struct {
+	bits uint64
+	mask uint64
+}
+
+type gpioV2LineAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64 // flags, values or debounce_period_us
+}
+
+type gpioV2LineConfigAttribute struct {
+	attr gpioV2LineAttribute
+	mask uint64
+}
+
+type gpioV2LineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [gpioV2LineNumAttrsMax]gpioV2LineConfigAttribute
+}
+
+type gpioV2LineRequest struct {
+	offsets         [gpioV2LinesMax]uint32
+	consumer        [gpioMaxNameSize]byte
+	config          gpioV2LineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+type gpioV2LineInfo struct {
+	name     [gpioMaxNameSize]byte
+	consumer [gpioMaxNameSize]byte
+	offset   uint32
+	numAttrs uint32
+	flags    uint64
+	attrs    [gpioV2LineNumAttrsMax]gpioV2LineAttribute
+	padding  [4]uint32
+}
+
+type gpioV2LineEvent struct {
+	timestampNs uint64
+	id          uint32
+	offset      uint32
+	seqno       uint32
+	lineSeqno   uint32
+	padding     [6]uint32
+}
+
+// driverGPIOChip implements periph.Driver.
+type driverGPIOChip struct {
+	chips []*gpioChip
+}
+
+func (d *driverGPIOChip) String() string {
+	return "sysfs-gpiochip"
+}
+
+func (d *driverGPIOChip) Prerequisites() []string {
+	return nil
+}
+
+// After returns sysfs-gpio so the lines supersede the sysfs pins with the same
+// number when both are available.
+func (d *driverGPIOChip) After() []string {
+	return []string{"sysfs-gpio"}
+}
+
+// Init initializes GPIO character device handling code.
+//
+// Uses the GPIO character device uAPI v2 as described at
+// https://www.kernel.org/doc/html/latest/userspace-api/gpio/chardev.html
+//
+// Unlike GPIO sysfs, it supports internal pull resistors and the lines are
+// released automatically when the process exits.
+func (d *driverGPIOChip) Init() (bool, error) {
+	prefix := "/dev/gpiochip"
+	items, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("no GPIO character device found")
+	}
+	// Make sure they are processed in numerical order, as this defines the
+	// numbering when GPIO sysfs is not available.
+	numbers := make([]int, 0, len(items))
+	for _, item := range items {
+		if n, err := strconv.Atoi(item[len(prefix):]); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	bases := sysfsGPIOChipBases()
+	Lines = map[int]*Line{}
+	next := 0
+	for _, n := range numbers {
+		c, err := openGPIOChip(prefix + strconv.Itoa(n))
+		if err != nil {
+			return true, err
+		}
+		d.chips = append(d.chips, c)
+		base, ok := bases[c.label]
+		if !ok || base.ngpio != int(c.lines) {
+			base.base = next
+		}
+		if end := base.base + int(c.lines); end > next {
+			next = end
+		}
+		if err := d.registerLines(c, base.base); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (d *driverGPIOChip) registerLines(c *gpioChip, base int) error {
+	for i := uint32(0); i < c.lines; i++ {
+		var info gpioV2LineInfo
+		if err := c.lineInfo(i, &info); err != nil {
+			return fmt.Errorf("sysfs-gpiochip: %s: %v", c.path, err)
+		}
+		n := base + int(i)
+		if _, ok := Lines[n]; ok {
+			return fmt.Errorf("sysfs-gpiochip: found two lines with number %d", n)
+		}
+		l := &Line{
+			number: n,
+			name:   fmt.Sprintf("GPIO%d", n),
+			offset: i,
+			label:  cString(info.name[:]),
+			chip:   c,
+		}
+		Lines[n] = l
+		num := strconv.Itoa(n)
+		// Unregister the pin if already registered. This happens with
+		// sysfs-gpio. Do not error on it, since sysfs-gpio may have failed to
+		// load.
+		_ = gpioreg.Unregister(l.name)
+		_ = gpioreg.Unregister(num)
+		if err := gpioreg.Register(l); err != nil {
+			return err
+		}
+		if err := gpioreg.RegisterAlias(num, l.name); err != nil {
+			return err
+		}
+		// Expose the kernel provided line name, unless it clashes with an
+		// existing pin or alias.
+		if l.label != "" && gpioreg.ByName(l.label) == nil {
+			if err := gpioreg.RegisterAlias(l.label, l.name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func openGPIOChip(path string) (*gpioChip, error) {
+	f, err := ioctlOpen(path, os.O_RDWR)
+	if err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("sysfs-gpiochip: need more access, try as root or setup udev rules: %v", err)
+		}
+		return nil, fmt.Errorf("sysfs-gpiochip: %v", err)
+	}
+	var info gpioChipInfo
+	if err := f.Ioctl(ioctlGPIOGetChipInfo, uintptr(unsafe.Pointer(&info))); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("sysfs-gpiochip: %s: %v", path, err)
+	}
+	return &gpioChip{
+		path:  path,
+		name:  cString(info.name[:]),
+		label: cString(info.label[:]),
+		lines: info.lines,
+		f:     f,
+	}, nil
+}
+
+type sysfsGPIOChipBase struct {
+	base  int
+	ngpio int
+}
+
+// sysfsGPIOChipBases returns the global GPIO numbering as exposed by GPIO
+// sysfs, keyed by chip label.
+//
+// Errors are ignored, since GPIO sysfs may not be available at all.
+func sysfsGPIOChipBases() map[string]sysfsGPIOChipBase {
+	out := map[string]sysfsGPIOChipBase{}
+	items, err := filepath.Glob("/sys/class/gpio/gpiochip*")
+	if err != nil {
+		return out
+	}
+	for _, item := range items {
+		base, err := readInt(item + "/base")
+		if err != nil {
+			continue
+		}
+		ngpio, err := readInt(item + "/ngpio")
+		if err != nil {
+			continue
+		}
+		label, err := readString(item + "/label")
+		if err != nil {
+			continue
+		}
+		out[label] = sysfsGPIOChipBase{base, ngpio}
+	}
+	return out
+}
+
+// readString reads a pseudo-file (sysfs) that is known to contain a single
+// line of text and returns it without the trailing new line.
+func readString(path string) (string, error) {
+	f, err := fileIOOpen(path, os.O_RDONLY)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	var b [256]byte
+	n, err := f.Read(b[:])
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(b[:n]), "\n"), nil
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvGPIOChip)
+	}
+}
+
+var drvGPIOChip driverGPIOChip
+
+var _ conn.Resource = &Line{}
+var _ gpio.PinIn = &Line{}
+var _ gpio.PinOut = &Line{}
+var _ gpio.PinIO = &Line{}
+var _ gpio.PinDebouncer = &Line{}
+var _ gpio.PinEdgeEvent = &Line{}
+var _ pin.PinFunc = &Line{}
@@ -0,0 +1,361 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+)
+
+func TestGPIOChip_structs(t *testing.T) {
+	// Sizes as found in /usr/include/linux/gpio.h.
+	data := []struct {
+		name     string
+		actual   uintptr
+		expected uintptr
+	}{
+		{"gpiochip_info", unsafe.Sizeof(gpioChipInfo{}), 68},
+		{"gpio_v2_line_values", unsafe.Sizeof(gpioV2LineValues{}), 16},
+		{"gpio_v2_line_attribute", unsafe.Sizeof(gpioV2LineAttribute{}), 16},
+		{"gpio_v2_line_config", unsafe.Sizeof(gpioV2LineConfig{}), 272},
+		{"gpio_v2_line_request", unsafe.Sizeof(gpioV2LineRequest{}), 592},
+		{"gpio_v2_line_info", unsafe.Sizeof(gpioV2LineInfo{}), 256},
+		{"gpio_v2_line_event", unsafe.Sizeof(gpioV2LineEvent{}), 48},
+		{"gpio_v2_line_request.config", unsafe.Offsetof(gpioV2LineRequest{}.config), 288},
+		{"gpio_v2_line_request.fd", unsafe.Offsetof(gpioV2LineRequest{}.fd), 588},
+	}
+	for _, line := range data {
+		if line.actual != line.expected {
+			t.Fatalf("%s: expected %d, got %d", line.name, line.expected, line.actual)
+		}
+	}
+}
+
+func TestLine_String(t *testing.T) {
+	l := Line{number: 42, name: "GPIO42"}
+	if s := l.String(); s != "GPIO42" {
+		t.Fatal(s)
+	}
+	if s := l.Name(); s != "GPIO42" {
+		t.Fatal(s)
+	}
+	if n := l.Number(); n != 42 {
+		t.Fatal(n)
+	}
+}
+
+func TestLine_In(t *testing.T) {
+	c := &fakeGPIOChip{}
+	l := Line{number: 42, name: "GPIO42", offset: 3, chip: &gpioChip{f: c}}
+	c.chip = l.chip
+	defer reset()
+	var lf *fakeLineFile
+	lineFileOpen = func(fd int32, name string) (fileIO, error) {
+		lf = &fakeLineFile{line: &l}
+		return lf, nil
+	}
+	if err := l.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if c.req.offsets[0] != 3 || c.req.numLines != 1 {
+		t.Fatal(c.req.offsets[0], c.req.numLines)
+	}
+	if s := cString(c.req.consumer[:]); s != "periph" {
+		t.Fatal(s)
+	}
+	if f := c.req.config.flags; f != lineFlagInput|lineFlagBiasPullUp {
+		t.Fatalf("%#x", f)
+	}
+	if err := l.In(gpio.Float, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if f := lf.cfg.flags; f != lineFlagInput|lineFlagBiasDisabled {
+		t.Fatalf("%#x", f)
+	}
+	if err := l.In(gpio.PullDown, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	if f := lf.cfg.flags; f != lineFlagInput|lineFlagBiasPullDown {
+		t.Fatalf("%#x", f)
+	}
+	if p := l.Pull(); p != gpio.PullDown {
+		t.Fatal(p)
+	}
+	if l.In(gpio.Pull(10), gpio.NoEdge) == nil {
+		t.Fatal("invalid pull")
+	}
+	if l.In(gpio.PullNoChange, gpio.Edge(10)) == nil {
+		t.Fatal("invalid edge")
+	}
+	lf.err = errors.New("oops")
+	if l.In(gpio.PullNoChange, gpio.NoEdge) == nil {
+		t.Fatal("ioctl failed")
+	}
+}
+
+func TestLine_In_request_fail(t *testing.T) {
+	c := &fakeGPIOChip{err: errors.New("oops")}
+	l := Line{number: 42, name: "GPIO42", chip: &gpioChip{f: c}}
+	c.chip = l.chip
+	if l.In(gpio.PullNoChange, gpio.NoEdge) == nil {
+		t.Fatal("request failed")
+	}
+	if l.Read() != gpio.Low {
+		t.Fatal("not requested is always low")
+	}
+	if l.WaitForEdge(0) {
+		t.Fatal("no edge detection")
+	}
+}
+
+func TestLine_Out(t *testing.T) {
+	c := &fakeGPIOChip{}
+	l := Line{number: 42, name: "GPIO42", chip: &gpioChip{f: c}}
+	c.chip = l.chip
+	defer reset()
+	lf := &fakeLineFile{line: &l}
+	lineFileOpen = func(fd int32, name string) (fileIO, error) {
+		lf.values.bits = c.req.config.attrs[0].attr.value
+		return lf, nil
+	}
+	if err := l.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	if f := c.req.config.flags; f != lineFlagOutput {
+		t.Fatalf("%#x", f)
+	}
+	a := c.req.config.attrs[0]
+	if c.req.config.numAttrs != 1 || a.attr.id != lineAttrIDOutputValues || a.mask != 1 || a.attr.value != 1 {
+		t.Fatalf("%#v", c.req.config)
+	}
+	if l.Read() != gpio.High {
+		t.Fatal("expected high")
+	}
+	if err := l.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	if lf.values.bits != 0 || lf.values.mask != 1 {
+		t.Fatalf("%#v", lf.values)
+	}
+	if l.Read() != gpio.Low {
+		t.Fatal("expected low")
+	}
+	if s := l.Function(); s != string(gpio.OUT_LOW) {
+		t.Fatal(s)
+	}
+	lf.err = errors.New("oops")
+	if l.Out(gpio.High) == nil {
+		t.Fatal("ioctl failed")
+	}
+	if l.Read() != gpio.Low {
+		t.Fatal("broken line is always low")
+	}
+	if l.PWM(gpio.DutyHalf, 0) == nil {
+		t.Fatal("gpiochip doesn't support PWM")
+	}
+}
+
+func TestLine_Debounce(t *testing.T) {
+	c := &fakeGPIOChip{}
+	l := Line{number: 42, name: "GPIO42", chip: &gpioChip{f: c}}
+	c.chip = l.chip
+	defer reset()
+	lf := &fakeLineFile{line: &l}
+	lineFileOpen = func(fd int32, name string) (fileIO, error) {
+		return lf, nil
+	}
+	// Not yet requested; applied on In().
+	if err := l.Debounce(5 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.In(gpio.PullUp, gpio.NoEdge); err != nil {
+		t.Fatal(err)
+	}
+	a := c.req.config.attrs[0]
+	if c.req.config.numAttrs != 1 || a.attr.id != lineAttrIDDebounce || a.mask != 1 {
+		t.Fatalf("%#v", c.req.config)
+	}
+	if us := *(*uint32)(unsafe.Pointer(&a.attr.value)); us != 5000 {
+		t.Fatal(us)
+	}
+	// Already an input; applied immediately.
+	if err := l.Debounce(0); err != nil {
+		t.Fatal(err)
+	}
+	if lf.cfg.numAttrs != 0 || lf.cfg.flags != lineFlagInput|lineFlagBiasPullUp {
+		t.Fatalf("%#v", lf.cfg)
+	}
+	if l.Debounce(-time.Second) == nil {
+		t.Fatal("negative period")
+	}
+	if l.Debounce(2*time.Hour) == nil {
+		t.Fatal("period too large")
+	}
+	lf.err = errors.New("oops")
+	if l.Debounce(time.Millisecond) == nil {
+		t.Fatal("ioctl failed")
+	}
+	if l.debounce != 0 {
+		t.Fatal(l.debounce)
+	}
+}
+
+func TestLine_Func(t *testing.T) {
+	c := &fakeGPIOChip{info: gpioV2LineInfo{flags: lineFlagUsed | lineFlagOutput}}
+	copy(c.info.consumer[:], "spi0 CS0")
+	l := Line{number: 42, name: "GPIO42", chip: &gpioChip{f: c}}
+	c.chip = l.chip
+	if f := l.Func(); f != gpio.OUT {
+		t.Fatal(f)
+	}
+	if s := l.Consumer(); s != "spi0 CS0" {
+		t.Fatal(s)
+	}
+	c.info.flags = lineFlagInput | lineFlagBiasPullUp
+	if f := l.Func(); f != gpio.IN {
+		t.Fatal(f)
+	}
+	if p := l.Pull(); p != gpio.PullUp {
+		t.Fatal(p)
+	}
+	if p := l.DefaultPull(); p != gpio.PullNoChange {
+		t.Fatal(p)
+	}
+	c.err = errors.New("oops")
+	if f := l.Func(); f != "ERR" {
+		t.Fatal(f)
+	}
+	if p := l.Pull(); p != gpio.PullNoChange {
+		t.Fatal(p)
+	}
+	if s := l.Consumer(); s != "" {
+		t.Fatal(s)
+	}
+	if l.SetFunc("I2C1_SDA") == nil {
+		t.Fatal("unsupported function")
+	}
+}
+
+func TestDriverGPIOChip_registerLines(t *testing.T) {
+	f := &fakeGPIOChip{}
+	copy(f.info.name[:], "FOO_LINE")
+	c := &gpioChip{path: "/dev/gpiochip9", lines: 2, f: f}
+	f.chip = c
+	d := driverGPIOChip{}
+	Lines = map[int]*Line{}
+	defer func() {
+		Lines = nil
+		for _, n := range []string{"GPIO1000", "GPIO1001", "1000", "1001", "FOO_LINE"} {
+			_ = gpioreg.Unregister(n)
+		}
+	}()
+	if err := d.registerLines(c, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if len(Lines) != 2 || Lines[1001].offset != 1 {
+		t.Fatal(Lines)
+	}
+	if p := gpioreg.ByName("1001"); p == nil || p.(gpio.RealPin).Real() != Lines[1001] {
+		t.Fatal(p)
+	}
+	// The first line wins the kernel name alias.
+	if p := gpioreg.ByName("FOO_LINE"); p == nil || p.(gpio.RealPin).Real() != Lines[1000] {
+		t.Fatal(p)
+	}
+	if d.registerLines(c, 1001) == nil {
+		t.Fatal("duplicate line number")
+	}
+}
+
+func TestCString(t *testing.T) {
+	if s := cString([]byte{'a', 'b', 0, 'c'}); s != "ab" {
+		t.Fatal(s)
+	}
+	if s := cString([]byte{'a', 'b'}); s != "ab" {
+		t.Fatal(s)
+	}
+}
+
+//
+
+// fakeGPIOChip implements ioctlCloser for a /dev/gpiochipN handle.
+//
+// The ioctl arguments are read from and written to chip directly.
+type fakeGPIOChip struct {
+	ioctlClose
+	chip *gpioChip
+	err  error
+	info gpioV2LineInfo
+	req  gpioV2LineRequest
+}
+
+func (f *fakeGPIOChip) Ioctl(op uint, data uintptr) error {
+	if f.err != nil {
+		return f.err
+	}
+	switch op {
+	case ioctlGPIOV2GetLineInfo:
+		if data != uintptr(unsafe.Pointer(&f.chip.info)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		offset := f.chip.info.offset
+		f.chip.info = f.info
+		f.chip.info.offset = offset
+	case ioctlGPIOV2GetLine:
+		if data != uintptr(unsafe.Pointer(&f.chip.req)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		f.req = f.chip.req
+		f.chip.req.fd = 10
+	default:
+		return errors.New("unexpected ioctl")
+	}
+	return nil
+}
+
+// fakeLineFile implements fileIO for a line request handle.
+//
+// The ioctl arguments are read from and written to line directly.
+type fakeLineFile struct {
+	file
+	line   *Line
+	err    error
+	cfg    gpioV2LineConfig
+	values gpioV2LineValues
+}
+
+func (f *fakeLineFile) Ioctl(op uint, data uintptr) error {
+	if f.err != nil {
+		return f.err
+	}
+	switch op {
+	case ioctlGPIOV2LineSetConfig:
+		if data != uintptr(unsafe.Pointer(&f.line.cfg)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		f.cfg = f.line.cfg
+		if f.cfg.numAttrs != 0 {
+			f.values.bits = f.cfg.attrs[0].attr.value
+		}
+	case ioctlGPIOV2LineGetValues:
+		if data != uintptr(unsafe.Pointer(&f.line.v)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		f.line.v.bits = f.values.bits & f.line.v.mask
+	case ioctlGPIOV2LineSetValues:
+		if data != uintptr(unsafe.Pointer(&f.line.v)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		f.values = f.line.v
+	default:
+		return errors.New("unexpected ioctl")
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/physic"
+)
+
+// HwmonSensors is all the hwmon inputs discovered on this host via sysfs.
+//
+// There is one item per input, e.g. a device with the files temp1_input and
+// fan1_input results in two sensors "hwmon0/temp1" and "hwmon0/fan1".
+var HwmonSensors []*HwmonSensor
+
+// HwmonSensorByName returns a *HwmonSensor for the input name, if any.
+//
+// The name is either in the form "hwmon0/temp1" or "<device>/temp1", where
+// device is the name of the hwmon device as reported by the kernel, e.g.
+// "cpu_thermal/temp1". The first match is returned.
+func HwmonSensorByName(name string) (*HwmonSensor, error) {
+	for _, s := range HwmonSensors {
+		if s.name == name || s.device+"/"+s.input == name {
+			if err := s.open(); err != nil {
+				return nil, err
+			}
+			return s, nil
+		}
+	}
+	return nil, errors.New("sysfs-hwmon: invalid sensor name")
+}
+
+// HwmonSensor represents one input of a hwmon device, e.g. temp1_input.
+//
+// Temperature and humidity inputs are reported via physic.SenseEnv. Voltage,
+// current and fan inputs can't be represented in physic.Env so they are read
+// with Voltage(), Current() and Fan() respectively.
+type HwmonSensor struct {
+	name   string // "hwmonN/<input>"
+	device string // Content of the name attribute, e.g. "cpu_thermal"
+	input  string // e.g. "temp1"
+	typ    string // One of "temp", "in", "curr", "fan" or "humidity"
+	root   string // /sys/class/hwmon/hwmonN/ or /sys/class/hwmon/hwmonN/device/
+
+	mu     sync.Mutex
+	label  string
+	f      fileIO
+	poller physic.Poller
+}
+
+// String implements conn.Resource.
+func (s *HwmonSensor) String() string {
+	return s.name + "(" + s.device + ")"
+}
+
+// Halt implements conn.Resource.
+//
+// It stops the continuous sensing, if any.
+func (s *HwmonSensor) Halt() error {
+	s.poller.Halt()
+	return nil
+}
+
+// Device returns the name of the hwmon device the input belongs to.
+func (s *HwmonSensor) Device() string {
+	return s.device
+}
+
+// Type returns the type of input as exported by sysfs: "temp", "in", "curr",
+// "fan" or "humidity".
+func (s *HwmonSensor) Type() string {
+	return s.typ
+}
+
+// Label returns the label of the input as exported by sysfs, e.g. "Core 0".
+//
+// Returns the input name if the device doesn't provide labels.
+func (s *HwmonSensor) Label() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.label == "" {
+		var err error
+		if s.label, err = readString(s.root + s.input + "_label"); err != nil || s.label == "" {
+			s.label = s.input
+		}
+	}
+	return s.label
+}
+
+// Sense implements physic.SenseEnv.
+//
+// Only temperature and humidity inputs set a value in e. Other inputs leave e
+// untouched.
+func (s *HwmonSensor) Sense(e *physic.Env) error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sense(e)
+}
+
+// SenseContinuous implements physic.SenseEnv.
+//
+// It is only supported on temperature and humidity inputs. The application
+// must call Halt() to stop the sensing when done and close the channel.
+func (s *HwmonSensor) SenseContinuous(interval time.Duration) (<-chan physic.Env, error) {
+	if s.typ != "temp" && s.typ != "humidity" {
+		return nil, errors.New("sysfs-hwmon: " + s.typ + " input can't be represented in physic.Env")
+	}
+	return s.poller.SenseContinuous(interval, s.Sense)
+}
+
+// Precision implements physic.SenseEnv.
+//
+// hwmon reports temperatures in m°C and humidity in m%rH.
+func (s *HwmonSensor) Precision(e *physic.Env) {
+	switch s.typ {
+	case "temp":
+		e.Temperature = physic.MilliKelvin
+	case "humidity":
+		e.Humidity = physic.PercentRH / 1000
+	}
+}
+
+// Voltage returns the value of a voltage ("in") input.
+func (s *HwmonSensor) Voltage() (physic.ElectricPotential, error) {
+	v, err := s.readType("in")
+	return physic.ElectricPotential(v) * physic.MilliVolt, err
+}
+
+// Current returns the value of a current ("curr") input.
+func (s *HwmonSensor) Current() (physic.ElectricCurrent, error) {
+	v, err := s.readType("curr")
+	return physic.ElectricCurrent(v) * physic.MilliAmpere, err
+}
+
+// Fan returns the rotation speed of a fan input.
+func (s *HwmonSensor) Fan() (physic.Frequency, error) {
+	// The value is in revolutions per minute.
+	v, err := s.readType("fan")
+	return physic.Frequency(v) * physic.Hertz / 60, err
+}
+
+//
+
+func (s *HwmonSensor) open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f != nil {
+		return nil
+	}
+	f, err := fileIOOpen(s.root+s.input+"_input", os.O_RDONLY)
+	if err != nil {
+		return fmt.Errorf("sysfs-hwmon: %v", err)
+	}
+	s.f = f
+	return nil
+}
+
+// read reads the raw value of the input.
+//
+// lock must be held.
+func (s *HwmonSensor) read() (int64, error) {
+	var buf [24]byte
+	n, err := seekRead(s.f, buf[:])
+	if err != nil {
+		return 0, fmt.Errorf("sysfs-hwmon: %v", err)
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(buf[:n])), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("sysfs-hwmon: %v", err)
+	}
+	return v, nil
+}
+
+// readType reads the raw value of the input after confirming its type.
+func (s *HwmonSensor) readType(typ string) (int64, error) {
+	if s.typ != typ {
+		return 0, errors.New("sysfs-hwmon: " + s.name + " is a " + s.typ + " input")
+	}
+	if err := s.open(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// sense reads the input and stores it in e.
+//
+// lock must be held.
+func (s *HwmonSensor) sense(e *physic.Env) error {
+	v, err := s.read()
+	if err != nil {
+		return err
+	}
+	switch s.typ {
+	case "temp":
+		e.Temperature = physic.Temperature(v)*physic.MilliKelvin + physic.ZeroCelsius
+	case "humidity":
+		e.Humidity = physic.RelativeHumidity(v) * (physic.PercentRH / 1000)
+	}
+	return nil
+}
+
+// hwmonTypes is the supported input types, in the order they are listed.
+var hwmonTypes = []string{"temp", "in", "curr", "fan", "humidity"}
+
+// newHwmonSensors returns the sensors of the hwmon device number n.
+//
+// root is the directory containing the attributes and inputs is the list of
+// the *_input files in it.
+func newHwmonSensors(n int, root, device string, inputs []string) []*HwmonSensor {
+	// Sort per type, then per index, e.g. temp2 before temp10.
+	byType := map[string][]int{}
+	for _, item := range inputs {
+		input := strings.TrimSuffix(filepath.Base(item), "_input")
+		for _, typ := range hwmonTypes {
+			if strings.HasPrefix(input, typ) {
+				if i, err := strconv.Atoi(input[len(typ):]); err == nil {
+					byType[typ] = append(byType[typ], i)
+				}
+				break
+			}
+		}
+	}
+	prefix := "hwmon" + strconv.Itoa(n) + "/"
+	var out []*HwmonSensor
+	for _, typ := range hwmonTypes {
+		indexes := byType[typ]
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			input := typ + strconv.Itoa(i)
+			out = append(out, &HwmonSensor{
+				name:   prefix + input,
+				device: device,
+				input:  input,
+				typ:    typ,
+				root:   root,
+			})
+		}
+	}
+	return out
+}
+
+// driverHwmon implements periph.Driver.
+type driverHwmon struct {
+}
+
+func (d *driverHwmon) String() string {
+	return "sysfs-hwmon"
+}
+
+func (d *driverHwmon) Prerequisites() []string {
+	return nil
+}
+
+func (d *driverHwmon) After() []string {
+	return nil
+}
+
+// Init initializes hwmon sysfs handling code.
+//
+// Uses hwmon sysfs as described at
+// https://www.kernel.org/doc/Documentation/hwmon/sysfs-interface
+func (d *driverHwmon) Init() (bool, error) {
+	items, err := filepath.Glob("/sys/class/hwmon/hwmon*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("sysfs-hwmon: no sensor found")
+	}
+	// Make the sensors in deterministic order.
+	devices := make([]int, 0, len(items))
+	for _, item := range items {
+		if n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(item), "hwmon")); err == nil {
+			devices = append(devices, n)
+		}
+	}
+	sort.Ints(devices)
+	for _, n := range devices {
+		root := "/sys/class/hwmon/hwmon" + strconv.Itoa(n) + "/"
+		name, err := readString(root + "name")
+		if err != nil {
+			// Older drivers put the attributes in the device directory.
+			root += "device/"
+			if name, err = readString(root + "name"); err != nil {
+				continue
+			}
+		}
+		inputs, err := filepath.Glob(root + "*_input")
+		if err != nil {
+			return true, err
+		}
+		HwmonSensors = append(HwmonSensors, newHwmonSensors(n, root, name, inputs)...)
+	}
+	if len(HwmonSensors) == 0 {
+		return false, errors.New("sysfs-hwmon: no sensor found")
+	}
+	return true, nil
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvHwmon)
+	}
+}
+
+var drvHwmon driverHwmon
+
+var _ conn.Resource = &HwmonSensor{}
+var _ physic.SenseEnv = &HwmonSensor{}
@@ -0,0 +1,197 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"periph.io/x/periph/conn/physic"
+)
+
+func TestHwmonSensorByName(t *testing.T) {
+	defer resetHwmon()
+	HwmonSensors = []*HwmonSensor{
+		{name: "hwmon0/temp1", device: "cpu_thermal", input: "temp1", f: &file{}},
+		{name: "hwmon1/fan1", device: "pwmfan", input: "fan1", f: &file{}},
+	}
+	if s, err := HwmonSensorByName("hwmon1/fan1"); err != nil || s != HwmonSensors[1] {
+		t.Fatal(s, err)
+	}
+	if s, err := HwmonSensorByName("cpu_thermal/temp1"); err != nil || s != HwmonSensors[0] {
+		t.Fatal(s, err)
+	}
+	if _, err := HwmonSensorByName("missing"); err == nil || err.Error() != "sysfs-hwmon: invalid sensor name" {
+		t.Fatal(err)
+	}
+}
+
+func TestHwmonSensorByName_cant_open(t *testing.T) {
+	defer resetHwmon()
+	HwmonSensors = []*HwmonSensor{{name: "hwmon0/temp1", root: "//\000/", input: "temp1"}}
+	if _, err := HwmonSensorByName("hwmon0/temp1"); err == nil || err.Error() != "sysfs-hwmon: file I/O is inhibited" {
+		t.Fatal(err)
+	}
+}
+
+func TestNewHwmonSensors(t *testing.T) {
+	inputs := []string{
+		"/hwmon/fan1_input",
+		"/hwmon/temp10_input",
+		"/hwmon/temp2_input",
+		"/hwmon/power1_input",
+		"/hwmon/in0_input",
+		"/hwmon/curr1_input",
+		"/hwmon/humidity1_input",
+	}
+	s := newHwmonSensors(3, "/hwmon/", "foo", inputs)
+	expected := []string{"hwmon3/temp2", "hwmon3/temp10", "hwmon3/in0", "hwmon3/curr1", "hwmon3/fan1", "hwmon3/humidity1"}
+	if len(s) != len(expected) {
+		t.Fatal(s)
+	}
+	for i := range s {
+		if s[i].name != expected[i] {
+			t.Fatalf("#%d: %q != %q", i, s[i].name, expected[i])
+		}
+	}
+	if x := s[1].String(); x != "hwmon3/temp10(foo)" {
+		t.Fatal(x)
+	}
+	if x := s[1].Device(); x != "foo" {
+		t.Fatal(x)
+	}
+	if x := s[2].Type(); x != "in" {
+		t.Fatal(x)
+	}
+}
+
+func TestHwmonSensor_temp(t *testing.T) {
+	defer resetHwmon()
+	fileIOOpen = func(path string, flag int) (fileIO, error) {
+		if flag != os.O_RDONLY {
+			t.Fatal(flag)
+		}
+		switch path {
+		case "/hwmon/temp1_input":
+			return &fileRead{t: t, ops: [][]byte{[]byte("42500\n")}}, nil
+		case "/hwmon/temp1_label":
+			return &fileRead{t: t, ops: [][]byte{[]byte("Core 0\n")}}, nil
+		default:
+			t.Fatalf("unknown %q", path)
+			return nil, errors.New("unknown file")
+		}
+	}
+	s := HwmonSensor{name: "hwmon0/temp1", device: "coretemp", input: "temp1", typ: "temp", root: "/hwmon/"}
+	e := physic.Env{}
+	if err := s.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+	if expected := 42500*physic.MilliKelvin + physic.ZeroCelsius; e.Temperature != expected {
+		t.Fatal(e.Temperature)
+	}
+	if l := s.Label(); l != "Core 0" {
+		t.Fatal(l)
+	}
+	e = physic.Env{}
+	s.Precision(&e)
+	if e.Temperature != physic.MilliKelvin || e.Humidity != 0 {
+		t.Fatal(e)
+	}
+	if _, err := s.Voltage(); err == nil || err.Error() != "sysfs-hwmon: hwmon0/temp1 is a temp input" {
+		t.Fatal(err)
+	}
+	if err := s.Halt(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHwmonSensor_humidity(t *testing.T) {
+	defer resetHwmon()
+	s := HwmonSensor{name: "hwmon0/humidity1", input: "humidity1", typ: "humidity", f: &fileRead{t: t, ops: [][]byte{[]byte("45300\n")}}}
+	e := physic.Env{}
+	if err := s.Sense(&e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Humidity != 453*physic.MilliRH {
+		t.Fatal(e.Humidity)
+	}
+	e = physic.Env{}
+	s.Precision(&e)
+	if e.Humidity != 10*physic.MicroRH {
+		t.Fatal(e)
+	}
+}
+
+func TestHwmonSensor_other(t *testing.T) {
+	defer resetHwmon()
+	in := HwmonSensor{name: "hwmon0/in1", input: "in1", typ: "in", f: &fileRead{t: t, ops: [][]byte{[]byte("1203\n"), []byte("1203\n")}}}
+	if v, err := in.Voltage(); err != nil || v != 1203*physic.MilliVolt {
+		t.Fatal(v, err)
+	}
+	// Sense doesn't modify e.
+	e := physic.Env{}
+	if err := in.Sense(&e); err != nil || e != (physic.Env{}) {
+		t.Fatal(e, err)
+	}
+	if _, err := in.SenseContinuous(time.Second); err == nil {
+		t.Fatal("voltage can't be represented in physic.Env")
+	}
+	curr := HwmonSensor{name: "hwmon0/curr1", input: "curr1", typ: "curr", f: &fileRead{t: t, ops: [][]byte{[]byte("-250\n")}}}
+	if c, err := curr.Current(); err != nil || c != -250*physic.MilliAmpere {
+		t.Fatal(c, err)
+	}
+	fan := HwmonSensor{name: "hwmon0/fan1", input: "fan1", typ: "fan", f: &fileRead{t: t, ops: [][]byte{[]byte("3000\n")}}}
+	if f, err := fan.Fan(); err != nil || f != 50*physic.Hertz {
+		t.Fatal(f, err)
+	}
+	bad := HwmonSensor{name: "hwmon0/fan2", input: "fan2", typ: "fan", f: &fileRead{t: t, ops: [][]byte{[]byte("bad\n")}}}
+	if _, err := bad.Fan(); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestHwmonSensor_SenseContinuous(t *testing.T) {
+	defer resetHwmon()
+	// The sensing goroutine may read a few more times before Halt() is handled.
+	ops := [][]byte{[]byte("20000\n")}
+	for i := 0; i < 100; i++ {
+		ops = append(ops, []byte("21000\n"))
+	}
+	s := HwmonSensor{name: "hwmon0/temp1", input: "temp1", typ: "temp", f: &fileRead{t: t, ops: ops}}
+	if _, err := s.SenseContinuous(0); err == nil {
+		t.Fatal("invalid interval")
+	}
+	c, err := s.SenseContinuous(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := <-c; e.Temperature != 20000*physic.MilliKelvin+physic.ZeroCelsius {
+		t.Fatal(e)
+	}
+	if e := <-c; e.Temperature != 21000*physic.MilliKelvin+physic.ZeroCelsius {
+		t.Fatal(e)
+	}
+	if err := s.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
+}
+
+func TestHwmonDriver(t *testing.T) {
+	if len((&driverHwmon{}).Prerequisites()) != 0 {
+		t.Fatal("unexpected hwmon prerequisites")
+	}
+}
+
+//
+
+func resetHwmon() {
+	reset()
+	HwmonSensors = nil
+}
@@ -20,6 +20,7 @@ import (
 	"periph.io/x/periph/conn/gpio/gpioreg"
 	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/physic"
 )
 
@@ -71,6 +72,15 @@ type I2C struct {
 	fn  functionality
 	scl gpio.PinIO
 	sda gpio.PinIO
+	// force is set by SetForce().
+	force bool
+	// Ioctl arguments, under mu. They are kept here so they don't escape to the
+	// heap.
+	rdwr  rdwrIoctlData
+	msgs  [maxMsgs]i2cMsg
+	smbus smbusIoctlData
+	raw   [(smbus.BlockMax + 2) / 2]uint16 // uint16 to be aligned like the C union
+	w     [smbus.BlockMax + 3]byte         // Bytes written by smbusRdwr()
 }
 
 // Close closes the handle to the I²C driver. It is not a requirement to close
@@ -97,34 +107,167 @@ func (i *I2C) Tx(addr uint16, w, r []byte) error {
 		return nil
 	}
 
+	i.mu.Lock()
+	defer i.mu.Unlock()
 	// Convert the messages to the internal format.
-	var buf [2]i2cMsg
-	msgs := buf[0:0]
+	n := 0
 	if len(w) != 0 {
-		msgs = buf[:1]
-		buf[0].addr = addr
-		buf[0].length = uint16(len(w))
-		buf[0].buf = uintptr(unsafe.Pointer(&w[0]))
+		i.msgs[n] = i2cMsg{addr: addr, length: uint16(len(w)), buf: uintptr(unsafe.Pointer(&w[0]))}
+		n++
 	}
 	if len(r) != 0 {
-		l := len(msgs)
-		msgs = msgs[:l+1] // extend the slice by one
-		buf[l].addr = addr
-		buf[l].flags = flagRD
-		buf[l].length = uint16(len(r))
-		buf[l].buf = uintptr(unsafe.Pointer(&r[0]))
-	}
-	p := rdwrIoctlData{
-		msgs:  uintptr(unsafe.Pointer(&msgs[0])),
-		nmsgs: uint32(len(msgs)),
-	}
-	pp := uintptr(unsafe.Pointer(&p))
+		i.msgs[n] = i2cMsg{addr: addr, flags: flagRD, length: uint16(len(r)), buf: uintptr(unsafe.Pointer(&r[0]))}
+		n++
+	}
+	return i.rdwrIoctl(n)
+}
+
+// TxMsgs implements i2c.MsgBus.
+//
+// The messages are sent with a single I2C_RDWR ioctl. NoStart requires the
+// adapter to support I2C_FUNC_NOSTART and IgnoreNAK requires it to support
+// I2C_FUNC_PROTOCOL_MANGLING.
+func (i *I2C) TxMsgs(m []i2c.Msg) error {
+	if len(m) == 0 {
+		return nil
+	}
+	if len(m) > maxMsgs {
+		return fmt.Errorf("sysfs-i2c: too many messages %d; maximum is %d", len(m), maxMsgs)
+	}
 	i.mu.Lock()
 	defer i.mu.Unlock()
-	if err := i.f.Ioctl(ioctlRdwr, pp); err != nil {
-		return fmt.Errorf("sysfs-i2c: %v", err)
+	for j := range m {
+		i.msgs[j] = i2cMsg{}
+		if err := i.toMsg(&m[j], &i.msgs[j]); err != nil {
+			return err
+		}
 	}
-	return nil
+	return i.rdwrIoctl(len(m))
+}
+
+// SMBusSupports implements smbus.Bus.
+//
+// It returns the functionality reported by the kernel driver. Note that the
+// kernel emulates most SMBus transactions on full I²C adapters.
+func (i *I2C) SMBusSupports(p smbus.Protocol, read, pec bool) bool {
+	if pec && i.fn&funcSMBusPEC == 0 {
+		return false
+	}
+	var f functionality
+	switch p {
+	case smbus.Quick:
+		f = funcSMBusQuick
+	case smbus.Byte:
+		f = funcSMBusWriteByte
+		if read {
+			f = funcSMBusReadByte
+		}
+	case smbus.ByteData:
+		f = funcSMBusWriteByteData
+		if read {
+			f = funcSMBusReadByteData
+		}
+	case smbus.WordData:
+		f = funcSMBusWriteWordData
+		if read {
+			f = funcSMBusReadWordData
+		}
+	case smbus.ProcCall:
+		f = funcSMBusProcCall
+	case smbus.BlockData:
+		f = funcSMBusWriteBlockData
+		if read {
+			f = funcSMBusReadBlockData
+		}
+	}
+	return f != 0 && i.fn&f != 0
+}
+
+// SMBusTx implements smbus.Bus.
+//
+// It uses the I2C_SMBUS ioctl. When the address is bound to a kernel driver,
+// the kernel refuses to select it with EBUSY and an error is returned, unless
+// SetForce(true) was called.
+func (i *I2C) SMBusTx(addr uint16, p smbus.Protocol, read bool, cmd byte, data []byte, pec bool) (int, error) {
+	if addr >= 0x80 {
+		return 0, errors.New("sysfs-i2c: invalid SMBus address")
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	raw := &i.raw
+	buf := (*[smbus.BlockMax + 2]byte)(unsafe.Pointer(&raw[0]))
+	d := &i.smbus
+	*d = smbusIoctlData{command: cmd, data: uintptr(unsafe.Pointer(&raw[0]))}
+	if read && p != smbus.ProcCall {
+		d.readWrite = smbusRead
+	}
+	switch p {
+	case smbus.Quick:
+		d.size = smbusQuick
+		d.data = 0
+	case smbus.Byte:
+		d.size = smbusByte
+		if !read {
+			// The byte is sent as the command.
+			d.command = data[0]
+		}
+	case smbus.ByteData:
+		d.size = smbusByteData
+		buf[0] = data[0]
+	case smbus.WordData, smbus.ProcCall:
+		d.size = smbusWordData
+		if p == smbus.ProcCall {
+			d.size = smbusProcCall
+		}
+		raw[0] = uint16(data[0]) | uint16(data[1])<<8
+	case smbus.BlockData:
+		d.size = smbusBlockData
+		if !read {
+			if len(data) > smbus.BlockMax {
+				return 0, errors.New("sysfs-i2c: SMBus block is too large")
+			}
+			buf[0] = byte(len(data))
+			copy(buf[1:], data)
+		}
+	default:
+		return 0, fmt.Errorf("sysfs-i2c: unsupported SMBus protocol %s", p)
+	}
+	if err := i.smbusIoctl(addr, pec); err != nil {
+		return 0, err
+	}
+	if !read && p != smbus.ProcCall {
+		return 0, nil
+	}
+	switch p {
+	case smbus.Byte, smbus.ByteData:
+		data[0] = buf[0]
+		return 1, nil
+	case smbus.WordData, smbus.ProcCall:
+		data[0] = byte(raw[0])
+		data[1] = byte(raw[0] >> 8)
+		return 2, nil
+	case smbus.BlockData:
+		n := int(buf[0])
+		if n > len(data) || n > smbus.BlockMax {
+			return 0, fmt.Errorf("sysfs-i2c: SMBus block of %d bytes doesn't fit in %d bytes", n, len(data))
+		}
+		return copy(data, buf[1:1+n]), nil
+	}
+	return 0, nil
+}
+
+// SetForce enables SMBus transactions to an address bound to a kernel driver,
+// like i2cget -f does.
+//
+// The kernel refuses to select such an address to protect the driver, so
+// SMBusTx() sends the transaction as I2C_RDWR messages instead. This bypasses
+// the protection: the kernel driver is not aware of these transactions and
+// its device may end up in an unexpected state. It also requires the adapter
+// to support plain I²C transfers, and I2C_M_RECV_LEN for BlockData reads.
+func (i *I2C) SetForce(force bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.force = force
 }
 
 // SetSpeed implements i2c.Bus.
@@ -184,6 +327,191 @@ func newI2C(busNumber int) (*I2C, error) {
 	return i, nil
 }
 
+// toMsg converts a i2c.Msg to the kernel format.
+func (i *I2C) toMsg(m *i2c.Msg, out *i2cMsg) error {
+	if len(m.W) != 0 && len(m.R) != 0 {
+		return errors.New("sysfs-i2c: a message can't both read and write")
+	}
+	if m.Flags&i2c.Ten != 0 {
+		if m.Addr >= 0x400 || i.fn&func10BitAddr == 0 {
+			return errors.New("sysfs-i2c: invalid address")
+		}
+		out.flags |= flagTEN
+	} else if m.Addr >= 0x80 {
+		return errors.New("sysfs-i2c: invalid address")
+	}
+	if m.Flags&i2c.NoStart != 0 {
+		if i.fn&funcNOSTART == 0 {
+			return errors.New("sysfs-i2c: NoStart is not supported by this bus")
+		}
+		out.flags |= flagNOSTART
+	}
+	if m.Flags&i2c.IgnoreNAK != 0 {
+		if i.fn&funcProtocolMangling == 0 {
+			return errors.New("sysfs-i2c: IgnoreNAK is not supported by this bus")
+		}
+		out.flags |= flagIgnoreNAK
+	}
+	if m.Flags&^(i2c.Ten|i2c.NoStart|i2c.IgnoreNAK) != 0 {
+		return fmt.Errorf("sysfs-i2c: unsupported flags %s", m.Flags)
+	}
+	out.addr = m.Addr
+	b := m.W
+	if len(m.R) != 0 {
+		out.flags |= flagRD
+		b = m.R
+	}
+	if len(b) > 0xFFFF {
+		return errors.New("sysfs-i2c: message is too large")
+	}
+	out.length = uint16(len(b))
+	if len(b) != 0 {
+		out.buf = uintptr(unsafe.Pointer(&b[0]))
+	}
+	return nil
+}
+
+// rdwrIoctl sends the first n messages of i.msgs with the I2C_RDWR ioctl.
+//
+// lock must be held.
+func (i *I2C) rdwrIoctl(n int) error {
+	i.rdwr = rdwrIoctlData{msgs: uintptr(unsafe.Pointer(&i.msgs[0])), nmsgs: uint32(n)}
+	if err := i.f.Ioctl(ioctlRdwr, uintptr(unsafe.Pointer(&i.rdwr))); err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+// smbusIoctl selects the device address and PEC mode, then runs the SMBus
+// transaction in i.smbus.
+//
+// lock must be held.
+func (i *I2C) smbusIoctl(addr uint16, pec bool) error {
+	if err := i.f.Ioctl(ioctlSlave, uintptr(addr)); err != nil {
+		if isErrBusy(err) {
+			if i.force {
+				return i.smbusRdwr(addr, pec)
+			}
+			return fmt.Errorf("sysfs-i2c: address 0x%02X is in use by a kernel driver: %v", addr, err)
+		}
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if i.fn&funcSMBusPEC != 0 {
+		var v uintptr
+		if pec {
+			v = 1
+		}
+		if err := i.f.Ioctl(ioctlPEC, v); err != nil {
+			return fmt.Errorf("sysfs-i2c: %v", err)
+		}
+	}
+	if err := i.f.Ioctl(ioctlSMBus, uintptr(unsafe.Pointer(&i.smbus))); err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+// smbusRdwr runs the SMBus transaction in i.smbus as I2C_RDWR messages,
+// calculating the PEC when enabled.
+//
+// The results are stored in i.raw like the I2C_SMBUS ioctl does.
+//
+// lock must be held.
+func (i *I2C) smbusRdwr(addr uint16, pec bool) error {
+	d := &i.smbus
+	buf := (*[smbus.BlockMax + 2]byte)(unsafe.Pointer(&i.raw[0]))
+	read := d.readWrite == smbusRead
+	if d.size == smbusQuick {
+		i.msgs[0] = i2cMsg{addr: addr}
+		if read {
+			i.msgs[0].flags = flagRD
+		}
+		return i.rdwrIoctl(1)
+	}
+	// w is the bytes written and l the number of bytes read, excluding the PEC.
+	w := i.w[:0]
+	if !read || d.size != smbusByte {
+		w = append(w, d.command)
+	}
+	l := 0
+	switch d.size {
+	case smbusByte:
+		if read {
+			l = 1
+		}
+	case smbusByteData:
+		if read {
+			l = 1
+		} else {
+			w = append(w, buf[0])
+		}
+	case smbusWordData, smbusProcCall:
+		if read {
+			l = 2
+		} else {
+			w = append(w, byte(i.raw[0]), byte(i.raw[0]>>8))
+		}
+		if d.size == smbusProcCall {
+			l = 2
+		}
+	case smbusBlockData:
+		if read {
+			// The kernel adds the byte count received to the initial length.
+			l = 1
+		} else {
+			w = append(w, buf[:1+buf[0]]...)
+		}
+	}
+	a := byte(addr << 1)
+	if pec && l == 0 {
+		w = append(w, smbus.CalcPEC(append([]byte{a}, w...)))
+	}
+	n := 0
+	if len(w) != 0 {
+		i.msgs[n] = i2cMsg{addr: addr, length: uint16(len(w)), buf: uintptr(unsafe.Pointer(&i.w[0]))}
+		n++
+	}
+	if l != 0 {
+		i.msgs[n] = i2cMsg{addr: addr, flags: flagRD, length: uint16(l), buf: uintptr(unsafe.Pointer(&buf[0]))}
+		if pec {
+			i.msgs[n].length++
+		}
+		if d.size == smbusBlockData {
+			i.msgs[n].flags |= flagRecvLen
+			i.msgs[n].length += smbus.BlockMax
+			buf[0] = byte(i.msgs[n].length - smbus.BlockMax)
+		}
+		n++
+	}
+	if err := i.rdwrIoctl(n); err != nil {
+		return err
+	}
+	if l == 0 {
+		return nil
+	}
+	if d.size == smbusBlockData {
+		if buf[0] > smbus.BlockMax {
+			return fmt.Errorf("sysfs-i2c: SMBus block of %d bytes is larger than %d bytes", buf[0], smbus.BlockMax)
+		}
+		l += int(buf[0])
+	}
+	if pec {
+		// The PEC covers all the bytes on the wire, including the addresses.
+		var msg []byte
+		if len(w) != 0 {
+			msg = append([]byte{a}, w...)
+		}
+		msg = append(append(msg, a|1), buf[:l]...)
+		if c := smbus.CalcPEC(msg); c != buf[l] {
+			return fmt.Errorf("sysfs-i2c: invalid PEC 0x%02x; expected 0x%02x", buf[l], c)
+		}
+	}
+	if d.size == smbusWordData || d.size == smbusProcCall {
+		i.raw[0] = uint16(buf[0]) | uint16(buf[1])<<8
+	}
+	return nil
+}
+
 func (i *I2C) initPins() {
 	i.mu.Lock()
 	if i.scl == nil {
@@ -208,8 +536,14 @@ const (
 	ioctlTenBits = 0x704 // TODO(maruel): Expose this but the header says it's broken (!?)
 	ioctlFuncs   = 0x705
 	ioctlRdwr    = 0x707
+	ioctlPEC     = 0x708
+	ioctlSMBus   = 0x720
 )
 
+// maxMsgs is the maximum number of messages in a I2C_RDWR ioctl as defined by
+// I2C_RDWR_IOCTL_MAX_MSGS.
+const maxMsgs = 42
+
 // flags
 const (
 	flagTEN        = 0x0010 // this is a ten bit chip address
@@ -305,6 +639,25 @@ func (f functionality) String() string {
 	return strings.Join(out, "|")
 }
 
+// SMBus transaction types and direction as used in smbusIoctlData.
+const (
+	smbusWrite     = 0
+	smbusRead      = 1
+	smbusQuick     = 0
+	smbusByte      = 1
+	smbusByteData  = 2
+	smbusWordData  = 3
+	smbusProcCall  = 4
+	smbusBlockData = 5
+)
+
+type smbusIoctlData struct {
+	readWrite uint8
+	command   uint8
+	size      uint32
+	data      uintptr // Pointer to union i2c_smbus_data
+}
+
 type rdwrIoctlData struct {
 	msgs  uintptr // Pointer to i2cMsg
 	nmsgs uint32
@@ -386,3 +739,5 @@ var drvI2C driverI2C
 
 var _ i2c.Bus = &I2C{}
 var _ i2c.BusCloser = &I2C{}
+var _ i2c.MsgBus = &I2C{}
+var _ smbus.Bus = &I2C{}
@@ -0,0 +1,258 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"periph.io/x/periph/conn/i2c"
+)
+
+// NewTarget implements i2c.TargetBus.
+//
+// It uses the Linux slave-eeprom backend as described at
+// https://www.kernel.org/doc/Documentation/i2c/slave-eeprom-backend. This
+// requires a kernel built with CONFIG_I2C_SLAVE_EEPROM and a bus driver that
+// supports target mode. The only supported size is 256.
+//
+// The kernel handles the reads and writes from the controller, so
+// h.OnRead() is never called. When h is not nil, the memory is polled to
+// detect the writes done by the controller.
+func (i *I2C) NewTarget(addr uint16, size int, h i2c.TargetHandler) (i2c.Target, error) {
+	if addr >= 0x80 {
+		return nil, errors.New("sysfs-i2c: invalid target address")
+	}
+	if size != targetSize {
+		return nil, errors.New("sysfs-i2c: the only supported target size is " + strconv.Itoa(targetSize))
+	}
+	t := &I2CTarget{
+		root: fmt.Sprintf("/sys/bus/i2c/devices/i2c-%d/", i.busNumber),
+		name: fmt.Sprintf("%s-target(%d)", i, addr),
+		addr: addr,
+		h:    h,
+	}
+	if err := writeFile(t.root+"new_device", fmt.Sprintf("slave-24c02 0x%04x\n", targetFlag|addr)); err != nil {
+		return nil, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	var err error
+	if t.f, err = fileIOOpen(fmt.Sprintf("/sys/bus/i2c/devices/%d-%04x/slave-eeprom", i.busNumber, targetFlag|addr), os.O_RDWR); err != nil {
+		_ = t.deleteDevice()
+		return nil, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if h != nil {
+		if err := t.readAll(t.mem[:]); err != nil {
+			_ = t.Halt()
+			return nil, err
+		}
+		t.stop = make(chan struct{})
+		t.wg.Add(1)
+		go t.poll(t.stop)
+	}
+	return t, nil
+}
+
+// I2CTarget is a I²C target emulated via the Linux slave-eeprom backend.
+//
+// It is returned by I2C.NewTarget().
+type I2CTarget struct {
+	root string
+	name string
+	addr uint16
+	h    i2c.TargetHandler
+
+	mu   sync.Mutex
+	f    fileIO
+	mem  [targetSize]byte // Last known content.
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func (t *I2CTarget) String() string {
+	return t.name
+}
+
+// Halt implements conn.Resource.
+//
+// It removes the target from the bus.
+func (t *I2CTarget) Halt() error {
+	// The lock can't be held while waiting for poll() since it takes it.
+	t.mu.Lock()
+	stop := t.stop
+	t.stop = nil
+	t.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		t.wg.Wait()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.f == nil {
+		return nil
+	}
+	err := t.f.Close()
+	t.f = nil
+	if err2 := t.deleteDevice(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+// Addr implements i2c.Target.
+func (t *I2CTarget) Addr() uint16 {
+	return t.addr
+}
+
+// ReadAt implements io.ReaderAt.
+func (t *I2CTarget) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("sysfs-i2c: invalid offset")
+	}
+	if off >= targetSize {
+		return 0, io.EOF
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.f == nil {
+		return 0, errors.New("sysfs-i2c: target is halted")
+	}
+	l := len(p)
+	if off+int64(l) > targetSize {
+		l = int(targetSize - off)
+	}
+	if _, err := t.f.Seek(off, 0); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	n, err := t.f.Read(p[:l])
+	if err != nil {
+		return n, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt.
+func (t *I2CTarget) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > targetSize {
+		return 0, errors.New("sysfs-i2c: write out of range")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.f == nil {
+		return 0, errors.New("sysfs-i2c: target is halted")
+	}
+	if _, err := t.f.Seek(off, 0); err != nil {
+		return 0, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	n, err := t.f.Write(p)
+	// Do not report this write as coming from the controller.
+	copy(t.mem[off:], p[:n])
+	if err != nil {
+		return n, fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return n, nil
+}
+
+//
+
+// readAll reads the whole memory.
+func (t *I2CTarget) readAll(b []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := seekRead(t.f, b); err != nil {
+		return fmt.Errorf("sysfs-i2c: %v", err)
+	}
+	return nil
+}
+
+// poll detects the changes done by the controller and calls the handler until
+// stop is closed.
+func (t *I2CTarget) poll(stop <-chan struct{}) {
+	defer t.wg.Done()
+	tick := time.NewTicker(targetPoll)
+	defer tick.Stop()
+	var buf [targetSize]byte
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+		}
+		t.mu.Lock()
+		if t.f == nil {
+			// Halted concurrently.
+			t.mu.Unlock()
+			return
+		}
+		_, err := seekRead(t.f, buf[:])
+		var changes [][2]int
+		if err == nil {
+			changes = diffRanges(t.mem[:], buf[:])
+			copy(t.mem[:], buf[:])
+		}
+		t.mu.Unlock()
+		for _, c := range changes {
+			t.h.OnWrite(c[0], buf[c[0]:c[1]])
+		}
+	}
+}
+
+// deleteDevice removes the slave-eeprom device.
+func (t *I2CTarget) deleteDevice() error {
+	return writeFile(t.root+"delete_device", fmt.Sprintf("0x%04x\n", targetFlag|t.addr))
+}
+
+// diffRanges returns the [start, end) ranges where old and new differ.
+func diffRanges(old, new []byte) [][2]int {
+	var out [][2]int
+	for i := 0; i < len(new); i++ {
+		if old[i] == new[i] {
+			continue
+		}
+		start := i
+		for i < len(new) && old[i] != new[i] {
+			i++
+		}
+		out = append(out, [2]int{start, i})
+	}
+	return out
+}
+
+// writeFile writes content to a sysfs file.
+func writeFile(path, content string) error {
+	f, err := fileIOOpen(path, os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write([]byte(content)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+const (
+	// targetFlag is I2C_SLAVE_ADDR_FLAG, the flag to use on the address
+	// written to new_device to create a target instead of a controller
+	// device.
+	targetFlag = 0x1000
+	// targetSize is the size of the memory of a slave-24c02.
+	targetSize = 256
+	// targetPoll is the interval to poll the memory for changes.
+	targetPoll = 10 * time.Millisecond
+)
+
+var _ i2c.TargetBus = &I2C{}
+var _ i2c.Target = &I2CTarget{}
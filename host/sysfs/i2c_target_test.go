@@ -0,0 +1,181 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestI2CTarget(t *testing.T) {
+	defer reset()
+	fs := newFakeTargetSysfs()
+	bus := I2C{f: &ioctlClose{}, busNumber: 1}
+	tgt, err := bus.NewTarget(0x64, 256, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := tgt.String(); s != "I2C1-target(100)" {
+		t.Fatal(s)
+	}
+	if a := tgt.Addr(); a != 0x64 {
+		t.Fatal(a)
+	}
+	if n, err := tgt.WriteAt([]byte{1, 2, 3}, 254); err == nil {
+		t.Fatal(n)
+	}
+	if n, err := tgt.WriteAt([]byte{1, 2, 3}, 10); err != nil || n != 3 {
+		t.Fatal(n, err)
+	}
+	b := make([]byte, 4)
+	if n, err := tgt.ReadAt(b, 9); err != nil || n != 4 || b[0] != 0 || b[1] != 1 || b[3] != 3 {
+		t.Fatal(b, n, err)
+	}
+	if n, err := tgt.ReadAt(b, 254); err != io.EOF || n != 2 {
+		t.Fatal(n, err)
+	}
+	if n, err := tgt.ReadAt(b, 256); err != io.EOF || n != 0 {
+		t.Fatal(n, err)
+	}
+	if _, err := tgt.ReadAt(b, -1); err == nil {
+		t.Fatal("invalid offset")
+	}
+	if err := tgt.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tgt.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tgt.ReadAt(b, 0); err == nil {
+		t.Fatal("halted")
+	}
+	if _, err := tgt.WriteAt(b, 0); err == nil {
+		t.Fatal("halted")
+	}
+	expected := []string{
+		"/sys/bus/i2c/devices/i2c-1/new_device=slave-24c02 0x1064\n",
+		"/sys/bus/i2c/devices/i2c-1/delete_device=0x1064\n",
+	}
+	if len(fs.writes) != len(expected) {
+		t.Fatal(fs.writes)
+	}
+	for i := range expected {
+		if fs.writes[i] != expected[i] {
+			t.Fatalf("#%d: %q != %q", i, fs.writes[i], expected[i])
+		}
+	}
+}
+
+func TestI2CTarget_handler(t *testing.T) {
+	defer reset()
+	fs := newFakeTargetSysfs()
+	bus := I2C{f: &ioctlClose{}, busNumber: 1}
+	h := targetHandler{c: make(chan string, 10)}
+	tgt, err := bus.NewTarget(0x64, 256, &h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Writes from the host are not reported.
+	if _, err := tgt.WriteAt([]byte{1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate writes from the controller.
+	fs.mu.Lock()
+	mem := fs.files[targetEEPROM].data
+	mem[3] = 4
+	mem[4] = 5
+	mem[255] = 6
+	fs.mu.Unlock()
+	if s := <-h.c; s != "3:[4 5]" {
+		t.Fatal(s)
+	}
+	if s := <-h.c; s != "255:[6]" {
+		t.Fatal(s)
+	}
+	// Concurrent calls to Halt() are safe.
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- tgt.Halt()
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case s := <-h.c:
+		t.Fatal(s)
+	default:
+	}
+}
+
+func TestI2CTarget_err(t *testing.T) {
+	defer reset()
+	bus := I2C{f: &ioctlClose{}, busNumber: 1}
+	if _, err := bus.NewTarget(0x80, 256, nil); err == nil {
+		t.Fatal("invalid address")
+	}
+	if _, err := bus.NewTarget(0x64, 128, nil); err == nil {
+		t.Fatal("invalid size")
+	}
+	// fs.Inhibit() is in effect.
+	if _, err := bus.NewTarget(0x64, 256, nil); err == nil {
+		t.Fatal("I/O is inhibited")
+	}
+	fs := newFakeTargetSysfs()
+	delete(fs.files, targetEEPROM)
+	if _, err := bus.NewTarget(0x64, 256, nil); err == nil {
+		t.Fatal("backend is not supported")
+	}
+	if len(fs.writes) != 2 {
+		t.Fatal(fs.writes)
+	}
+}
+
+func TestDiffRanges(t *testing.T) {
+	old := []byte{0, 0, 0, 0, 0, 0}
+	new := []byte{1, 0, 2, 2, 0, 3}
+	r := diffRanges(old, new)
+	if len(r) != 3 || r[0] != [2]int{0, 1} || r[1] != [2]int{2, 4} || r[2] != [2]int{5, 6} {
+		t.Fatal(r)
+	}
+	if r := diffRanges(old, old); len(r) != 0 {
+		t.Fatal(r)
+	}
+}
+
+//
+
+type targetHandler struct {
+	c chan string
+}
+
+func (h *targetHandler) OnWrite(reg int, data []byte) {
+	h.c <- fmt.Sprintf("%d:%v", reg, data)
+}
+
+func (h *targetHandler) OnRead(reg, n int) {
+	h.c <- "unexpected read"
+}
+
+const targetEEPROM = "/sys/bus/i2c/devices/1-1064/slave-eeprom"
+
+// newFakeTargetSysfs fakes the files used by the slave-eeprom backend.
+func newFakeTargetSysfs() *fakeSysfs {
+	return newFakeSysfs(map[string]*fakeFile{
+		"/sys/bus/i2c/devices/i2c-1/new_device":    {flags: []int{os.O_WRONLY}},
+		"/sys/bus/i2c/devices/i2c-1/delete_device": {flags: []int{os.O_WRONLY}},
+		targetEEPROM: {data: make([]byte, targetSize), binary: true, flags: []int{os.O_RDWR}},
+	})
+}
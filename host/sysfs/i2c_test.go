@@ -5,9 +5,14 @@
 package sysfs
 
 import (
+	"errors"
+	"syscall"
 	"testing"
+	"unsafe"
 
+	"periph.io/x/periph/conn/i2c"
 	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/i2c/smbus"
 	"periph.io/x/periph/conn/physic"
 )
 
@@ -58,6 +63,223 @@ func TestI2C_functionality(t *testing.T) {
 	}
 }
 
+func TestI2C_TxMsgs(t *testing.T) {
+	f := rdwrIoctl{}
+	bus := I2C{f: &f, fn: func10BitAddr | funcNOSTART | funcProtocolMangling}
+	f.bus = &bus
+	if err := bus.TxMsgs(nil); err != nil {
+		t.Fatal(err)
+	}
+	r := make([]byte, 4)
+	m := []i2c.Msg{
+		{Addr: 0x50, W: []byte{0x01, 0x02}},
+		{Addr: 0x50, W: []byte{0x03}, Flags: i2c.NoStart},
+		{Addr: 0x250, R: r, Flags: i2c.Ten | i2c.IgnoreNAK},
+		{Addr: 0x50},
+	}
+	if err := bus.TxMsgs(m); err != nil {
+		t.Fatal(err)
+	}
+	expected := []i2cMsg{
+		{addr: 0x50, length: 2},
+		{addr: 0x50, flags: flagNOSTART, length: 1},
+		{addr: 0x250, flags: flagTEN | flagIgnoreNAK | flagRD, length: 4},
+		{addr: 0x50},
+	}
+	if len(f.msgs) != len(expected) {
+		t.Fatal(f.msgs)
+	}
+	for j := range expected {
+		if f.msgs[j].addr != expected[j].addr || f.msgs[j].flags != expected[j].flags || f.msgs[j].length != expected[j].length {
+			t.Fatalf("#%d: %#v != %#v", j, f.msgs[j], expected[j])
+		}
+	}
+	if f.msgs[2].buf != uintptr(unsafe.Pointer(&r[0])) || f.msgs[3].buf != 0 {
+		t.Fatal("invalid buffer")
+	}
+
+	data := []struct {
+		m   i2c.Msg
+		err string
+	}{
+		{i2c.Msg{Addr: 0x50, W: []byte{1}, R: []byte{1}}, "sysfs-i2c: a message can't both read and write"},
+		{i2c.Msg{Addr: 0x80}, "sysfs-i2c: invalid address"},
+		{i2c.Msg{Addr: 0x400, Flags: i2c.Ten}, "sysfs-i2c: invalid address"},
+		{i2c.Msg{Addr: 0x50, Flags: 0x100}, "sysfs-i2c: unsupported flags 0x100"},
+		{i2c.Msg{Addr: 0x50, W: make([]byte, 0x10000)}, "sysfs-i2c: message is too large"},
+	}
+	for j, line := range data {
+		if err := bus.TxMsgs([]i2c.Msg{line.m}); err == nil || err.Error() != line.err {
+			t.Fatalf("#%d: %v", j, err)
+		}
+	}
+	if err := bus.TxMsgs(make([]i2c.Msg, maxMsgs+1)); err == nil {
+		t.Fatal("too many messages")
+	}
+	bus.fn = 0
+	if err := bus.TxMsgs([]i2c.Msg{{Addr: 0x250, Flags: i2c.Ten}}); err == nil {
+		t.Fatal("10 bits address not supported")
+	}
+	if err := bus.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.NoStart}}); err == nil {
+		t.Fatal("NoStart not supported")
+	}
+	if err := bus.TxMsgs([]i2c.Msg{{Addr: 0x50, Flags: i2c.IgnoreNAK}}); err == nil {
+		t.Fatal("IgnoreNAK not supported")
+	}
+	f.err = errors.New("oops")
+	if err := bus.TxMsgs([]i2c.Msg{{Addr: 0x50}}); err == nil || err.Error() != "sysfs-i2c: oops" {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_SMBusSupports(t *testing.T) {
+	bus := I2C{f: &ioctlClose{}, fn: funcSMBusQuick | funcSMBusReadByteData | funcSMBusWriteBlockData}
+	if !bus.SMBusSupports(smbus.Quick, true, false) {
+		t.Fatal("quick")
+	}
+	if !bus.SMBusSupports(smbus.ByteData, true, false) {
+		t.Fatal("read byte data")
+	}
+	if bus.SMBusSupports(smbus.ByteData, false, false) {
+		t.Fatal("write byte data")
+	}
+	if bus.SMBusSupports(smbus.BlockData, true, false) || !bus.SMBusSupports(smbus.BlockData, false, false) {
+		t.Fatal("block data")
+	}
+	if bus.SMBusSupports(smbus.Quick, true, true) {
+		t.Fatal("PEC")
+	}
+	if bus.SMBusSupports(smbus.Protocol(10), true, false) {
+		t.Fatal("invalid protocol")
+	}
+}
+
+func TestI2C_SMBusTx(t *testing.T) {
+	f := smbusIoctl{reply: []byte{3, 1, 2, 3}}
+	bus := I2C{f: &f, fn: funcSMBusPEC}
+	f.bus = &bus
+	if _, err := bus.SMBusTx(0x80, smbus.Quick, false, 0, nil, false); err == nil {
+		t.Fatal("invalid address")
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.Quick, true, 0, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if f.last.size != smbusQuick || f.last.readWrite != smbusRead || f.last.data != 0 {
+		t.Fatal(f.last)
+	}
+	if f.addr != 0x10 || f.pec != 0 {
+		t.Fatal(f.addr, f.pec)
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.Byte, false, 0, []byte{0x42}, true); err != nil {
+		t.Fatal(err)
+	}
+	if f.last.size != smbusByte || f.last.readWrite != smbusWrite || f.last.command != 0x42 || f.pec != 1 {
+		t.Fatal(f.last, f.pec)
+	}
+	b := make([]byte, 2)
+	if n, err := bus.SMBusTx(0x10, smbus.ByteData, true, 1, b, false); err != nil || n != 1 || b[0] != 3 {
+		t.Fatal(n, b, err)
+	}
+	if n, err := bus.SMBusTx(0x10, smbus.WordData, true, 2, b, false); err != nil || n != 2 || b[0] != 3 || b[1] != 1 {
+		t.Fatal(n, b, err)
+	}
+	b = []byte{0x34, 0x12}
+	if n, err := bus.SMBusTx(0x10, smbus.ProcCall, false, 4, b, false); err != nil || n != 2 || b[0] != 3 || b[1] != 1 {
+		t.Fatal(n, b, err)
+	}
+	if f.last.size != smbusProcCall || f.last.readWrite != smbusWrite || f.sent[0] != 0x34 || f.sent[1] != 0x12 {
+		t.Fatal(f.last, f.sent)
+	}
+	b = make([]byte, smbus.BlockMax)
+	if n, err := bus.SMBusTx(0x10, smbus.BlockData, true, 3, b, false); err != nil || n != 3 || b[0] != 1 || b[2] != 3 {
+		t.Fatal(n, b, err)
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.BlockData, true, 3, b[:2], false); err == nil {
+		t.Fatal("block doesn't fit")
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.BlockData, false, 3, []byte{7, 8}, false); err != nil {
+		t.Fatal(err)
+	}
+	if f.sent[0] != 2 || f.sent[1] != 7 || f.sent[2] != 8 {
+		t.Fatal(f.sent)
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.BlockData, false, 3, make([]byte, smbus.BlockMax+1), false); err == nil {
+		t.Fatal("block too large")
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.Protocol(10), false, 3, nil, false); err == nil {
+		t.Fatal("invalid protocol")
+	}
+	f.err = errors.New("oops")
+	if _, err := bus.SMBusTx(0x10, smbus.Quick, false, 0, nil, false); err == nil || err.Error() != "sysfs-i2c: oops" {
+		t.Fatal(err)
+	}
+}
+
+func TestI2C_SMBusTx_busy(t *testing.T) {
+	if !isLinux {
+		t.Skip("EBUSY is only recognized on linux")
+	}
+	// The address is bound to a kernel driver.
+	f := smbusIoctl{busy: true}
+	bus := I2C{f: &f, fn: funcSMBusPEC}
+	f.bus = &bus
+	if _, err := bus.SMBusTx(0x10, smbus.Quick, true, 0, nil, false); err == nil {
+		t.Fatal("the address is in use")
+	}
+	if len(f.msgs) != 0 {
+		t.Fatal(f.msgs)
+	}
+	// When forced, I2C_RDWR is used instead.
+	bus.SetForce(true)
+	if _, err := bus.SMBusTx(0x10, smbus.Quick, true, 0, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.msgs) != 1 || f.msgs[0].flags != flagRD || f.msgs[0].length != 0 {
+		t.Fatal(f.msgs)
+	}
+	if _, err := bus.SMBusTx(0x10, smbus.ByteData, false, 1, []byte{2}, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.msgs) != 1 || string(f.sent) != string([]byte{1, 2, smbus.CalcPEC([]byte{0x20, 1, 2})}) {
+		t.Fatal(f.msgs, f.sent)
+	}
+	f.reply = []byte{0x34, 0x12, smbus.CalcPEC([]byte{0x20, 3, 0x21, 0x34, 0x12})}
+	b := make([]byte, 2)
+	if n, err := bus.SMBusTx(0x10, smbus.WordData, true, 3, b, true); err != nil || n != 2 || b[0] != 0x34 || b[1] != 0x12 {
+		t.Fatal(n, b, err)
+	}
+	if len(f.msgs) != 2 || f.msgs[1].flags != flagRD || f.msgs[1].length != 3 || string(f.sent) != "\x03" {
+		t.Fatal(f.msgs, f.sent)
+	}
+	f.reply[2]++
+	if _, err := bus.SMBusTx(0x10, smbus.WordData, true, 3, b, true); err == nil {
+		t.Fatal("invalid PEC")
+	}
+	f.reply = []byte{0x42}
+	if n, err := bus.SMBusTx(0x10, smbus.Byte, true, 0, b, false); err != nil || n != 1 || b[0] != 0x42 {
+		t.Fatal(n, b, err)
+	}
+	if len(f.msgs) != 1 {
+		t.Fatal(f.msgs)
+	}
+	f.reply = []byte{2, 7, 8}
+	b = make([]byte, smbus.BlockMax)
+	if n, err := bus.SMBusTx(0x10, smbus.BlockData, true, 4, b, false); err != nil || n != 2 || b[0] != 7 || b[1] != 8 {
+		t.Fatal(n, b, err)
+	}
+	if m := f.msgs[1]; m.flags != flagRD|flagRecvLen || m.length != 1+smbus.BlockMax || f.recvLen != 1 {
+		t.Fatal(f.msgs, f.recvLen)
+	}
+	f.reply = []byte{smbus.BlockMax + 1}
+	if _, err := bus.SMBusTx(0x10, smbus.BlockData, true, 4, b, false); err == nil {
+		t.Fatal("block too large")
+	}
+	f.err = errors.New("oops")
+	if _, err := bus.SMBusTx(0x10, smbus.Quick, false, 0, nil, false); err == nil || err.Error() != "sysfs-i2c: oops" {
+		t.Fatal(err)
+	}
+}
+
 func TestDriver_Init(t *testing.T) {
 	d := driverI2C{}
 	if _, err := d.Init(); err == nil {
@@ -102,6 +324,102 @@ func TestDriver_Init(t *testing.T) {
 	}
 }
 
+//
+
+// rdwrIoctl fakes the I2C_RDWR ioctl.
+//
+// The ioctl argument is read from bus directly.
+type rdwrIoctl struct {
+	ioctlClose
+	bus  *I2C
+	err  error
+	msgs []i2cMsg
+}
+
+func (r *rdwrIoctl) Ioctl(op uint, data uintptr) error {
+	if op != ioctlRdwr {
+		return errors.New("unexpected ioctl")
+	}
+	if r.err != nil {
+		return r.err
+	}
+	p := &r.bus.rdwr
+	if data != uintptr(unsafe.Pointer(p)) || p.msgs != uintptr(unsafe.Pointer(&r.bus.msgs[0])) {
+		return errors.New("unexpected ioctl argument")
+	}
+	r.msgs = append([]i2cMsg{}, r.bus.msgs[:p.nmsgs]...)
+	return nil
+}
+
+// smbusIoctl fakes the I2C_SMBUS ioctl, and the I2C_RDWR ioctl when busy is
+// set.
+//
+// The ioctl arguments are read from and written to bus directly.
+type smbusIoctl struct {
+	ioctlClose
+	bus     *I2C
+	err     error
+	busy    bool
+	addr    uintptr
+	pec     uintptr
+	last    smbusIoctlData
+	msgs    []i2cMsg
+	recvLen byte
+	sent    []byte
+	reply   []byte
+}
+
+func (s *smbusIoctl) Ioctl(op uint, data uintptr) error {
+	switch op {
+	case ioctlSlave:
+		if s.busy {
+			return syscall.EBUSY
+		}
+		s.addr = data
+	case ioctlRdwr:
+		if s.err != nil {
+			return s.err
+		}
+		if data != uintptr(unsafe.Pointer(&s.bus.rdwr)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		s.msgs = append([]i2cMsg{}, s.bus.msgs[:s.bus.rdwr.nmsgs]...)
+		s.sent = nil
+		buf := (*[smbus.BlockMax + 2]byte)(unsafe.Pointer(&s.bus.raw[0]))
+		for _, m := range s.msgs {
+			switch {
+			case m.length == 0:
+			case m.flags&flagRD == 0 && m.buf == uintptr(unsafe.Pointer(&s.bus.w[0])):
+				s.sent = append([]byte{}, s.bus.w[:m.length]...)
+			case m.flags&flagRD != 0 && m.buf == uintptr(unsafe.Pointer(&buf[0])):
+				s.recvLen = buf[0]
+				copy(buf[:m.length], s.reply)
+			default:
+				return errors.New("unexpected message buffer")
+			}
+		}
+	case ioctlPEC:
+		s.pec = data
+	case ioctlSMBus:
+		if s.err != nil {
+			return s.err
+		}
+		if data != uintptr(unsafe.Pointer(&s.bus.smbus)) {
+			return errors.New("unexpected ioctl argument")
+		}
+		s.last = s.bus.smbus
+		if s.last.data != 0 {
+			if s.last.data != uintptr(unsafe.Pointer(&s.bus.raw[0])) {
+				return errors.New("unexpected ioctl argument")
+			}
+			buf := (*[smbus.BlockMax + 2]byte)(unsafe.Pointer(&s.bus.raw[0]))
+			s.sent = append([]byte{}, buf[:]...)
+			copy(buf[:], s.reply)
+		}
+	}
+	return nil
+}
+
 func BenchmarkI2C(b *testing.B) {
 	b.ReportAllocs()
 	i := ioctlClose{}
@@ -0,0 +1,313 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn/onewire"
+	"periph.io/x/periph/conn/onewire/onewirereg"
+)
+
+// NewOneWire opens a 1-wire bus master via the Linux w1 subsystem as
+// described at https://www.kernel.org/doc/Documentation/w1/w1.generic.
+//
+// busNumber is the bus master number as exported by sysfs. For example if the
+// path is /sys/bus/w1/devices/w1_bus_master1, busNumber should be 1. The bus
+// master is typically the w1-gpio kernel driver, enabled on a Raspberry Pi via
+// the w1-gpio device tree overlay.
+//
+// The strong pull-up cannot be controlled via sysfs, see OneWire.Tx().
+//
+// Do not use sysfs.NewOneWire() directly as the package sysfs is providing a
+// https://periph.io/x/periph/conn/onewire Linux-specific implementation.
+//
+// Instead, use https://periph.io/x/periph/conn/onewire/onewirereg#Open. This
+// permits it to work on all operating systems, or with a DS248x bus master.
+func NewOneWire(busNumber int) (*OneWire, error) {
+	if isLinux {
+		return newOneWire(busNumber)
+	}
+	return nil, errors.New("sysfs-onewire: is not supported on this platform")
+}
+
+// OneWire is a 1-wire bus master exposed by the Linux w1 subsystem.
+//
+// The kernel searches the bus in the background, so Search() returns the
+// devices it already discovered.
+//
+// Tx() uses the rw file of the device addressed, which does a bus reset and a
+// Match ROM before writing. This file is only present for devices not bound to
+// a kernel family driver; for example the w1_therm kernel module must not be
+// loaded to use devices/ds18b20. The transaction must start with either:
+//
+//   - Match ROM (0x55) followed by the device address, as done by onewire.Dev.
+//   - Skip ROM (0xCC), as done by ds18b20.ConvertAll(). The data is then
+//     written to each device in turn. Reading is only supported when a single
+//     device is present.
+type OneWire struct {
+	number int
+	root   string // /sys/bus/w1/devices/w1_bus_masterN/
+
+	mu sync.Mutex
+}
+
+func (o *OneWire) String() string {
+	return "w1_bus_master" + strconv.Itoa(o.number)
+}
+
+// Close implements onewire.BusCloser.
+//
+// It is a no-op since the files are opened for each transaction.
+func (o *OneWire) Close() error {
+	return nil
+}
+
+// Tx implements onewire.Bus.
+//
+// power is ignored since the strong pull-up cannot be controlled via sysfs.
+// This is not an error so drivers requesting it, like devices/ds18b20, still
+// work with externally powered devices. Parasite powered devices depend on the
+// bus master to provide enough current.
+func (o *OneWire) Tx(w, r []byte, power onewire.Pullup) error {
+	if len(w) == 0 {
+		return errors.New("sysfs-onewire: a ROM command must be written")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch w[0] {
+	case cmdMatchROM:
+		if len(w) < 10 {
+			return errors.New("sysfs-onewire: Match ROM requires an address followed by at least one byte")
+		}
+		return o.txDevice(deviceName(onewire.Address(binary.LittleEndian.Uint64(w[1:9]))), w[9:], r)
+	case cmdSkipROM:
+		names, err := o.devices()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return noDeviceError("sysfs-onewire: no device present")
+		}
+		if len(r) != 0 && len(names) != 1 {
+			return errors.New("sysfs-onewire: Skip ROM can only read when a single device is present")
+		}
+		for _, n := range names {
+			if err := o.txDevice(n, w[1:], r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("sysfs-onewire: unsupported ROM command 0x%02X", w[0])
+	}
+}
+
+// Search implements onewire.Bus.
+//
+// It returns the devices discovered by the kernel. Searching for devices in
+// alarm state is not supported.
+func (o *OneWire) Search(alarmOnly bool) ([]onewire.Address, error) {
+	if alarmOnly {
+		return nil, errors.New("sysfs-onewire: alarm search is not supported")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	names, err := o.devices()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]onewire.Address, 0, len(names))
+	for _, n := range names {
+		if a, ok := parseDeviceName(n); ok {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+//
+
+const (
+	cmdMatchROM = 0x55
+	cmdSkipROM  = 0xCC
+)
+
+// noDeviceError implements error, onewire.NoDevicesError and
+// onewire.BusError.
+type noDeviceError string
+
+func (e noDeviceError) Error() string   { return string(e) }
+func (e noDeviceError) NoDevices() bool { return true }
+func (e noDeviceError) BusError() bool  { return true }
+
+func newOneWire(busNumber int) (*OneWire, error) {
+	o := &OneWire{number: busNumber, root: "/sys/bus/w1/devices/w1_bus_master" + strconv.Itoa(busNumber) + "/"}
+	// Make sure the bus master exists.
+	f, err := fileIOOpen(o.root+"w1_master_slaves", os.O_RDONLY)
+	if err != nil {
+		return nil, fmt.Errorf("sysfs-onewire: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("sysfs-onewire: %v", err)
+	}
+	return o, nil
+}
+
+// devices returns the names of the devices discovered by the kernel, e.g.
+// "28-0316a2793eff".
+//
+// lock must be held.
+func (o *OneWire) devices() ([]string, error) {
+	f, err := fileIOOpen(o.root+"w1_master_slaves", os.O_RDONLY)
+	if err != nil {
+		return nil, fmt.Errorf("sysfs-onewire: %v", err)
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("sysfs-onewire: %v", err)
+	}
+	var out []string
+	for _, l := range strings.Split(string(b), "\n") {
+		// The kernel prints "not found." when there is no device.
+		if _, ok := parseDeviceName(l); ok {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+// txDevice writes w then reads r via the rw file of the device name.
+//
+// lock must be held.
+func (o *OneWire) txDevice(name string, w, r []byte) error {
+	if len(w) == 0 {
+		// Reading alone doesn't reset the bus nor select the device.
+		return errors.New("sysfs-onewire: at least one byte must be written after the ROM command")
+	}
+	f, err := fileIOOpen(o.root+name+"/rw", os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("sysfs-onewire: %v", err)
+	}
+	defer f.Close()
+	// The kernel doesn't write anything if the device doesn't respond to the
+	// reset.
+	if n, err := f.Write(w); n != len(w) {
+		if err == nil || err == io.ErrShortWrite {
+			return noDeviceError("sysfs-onewire: " + name + " is not present")
+		}
+		return fmt.Errorf("sysfs-onewire: %v", err)
+	}
+	if len(r) != 0 {
+		if _, err := io.ReadFull(f, r); err != nil {
+			return fmt.Errorf("sysfs-onewire: %v", err)
+		}
+	}
+	return nil
+}
+
+// deviceName returns the name of the device a in sysfs, in the form
+// "<family>-<serial>".
+func deviceName(a onewire.Address) string {
+	return fmt.Sprintf("%02x-%012x", byte(a), uint64(a>>8)&0xFFFFFFFFFFFF)
+}
+
+// parseDeviceName returns the address of the device name as returned by
+// deviceName().
+func parseDeviceName(name string) (onewire.Address, bool) {
+	if len(name) != 15 || name[2] != '-' {
+		return 0, false
+	}
+	family, err := strconv.ParseUint(name[:2], 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	serial, err := strconv.ParseUint(name[3:], 16, 48)
+	if err != nil {
+		return 0, false
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], serial<<8|family)
+	b[7] = onewire.CalcCRC(b[:7])
+	return onewire.Address(binary.LittleEndian.Uint64(b[:])), true
+}
+
+// driverOneWire implements periph.Driver.
+type driverOneWire struct {
+	buses []string
+}
+
+func (d *driverOneWire) String() string {
+	return "sysfs-onewire"
+}
+
+func (d *driverOneWire) Prerequisites() []string {
+	return nil
+}
+
+func (d *driverOneWire) After() []string {
+	return nil
+}
+
+func (d *driverOneWire) Init() (bool, error) {
+	prefix := "/sys/bus/w1/devices/w1_bus_master"
+	items, err := filepath.Glob(prefix + "*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("no 1-wire bus found")
+	}
+	// Make sure they are registered in order.
+	sort.Strings(items)
+	for _, item := range items {
+		bus, err := strconv.Atoi(item[len(prefix):])
+		if err != nil {
+			continue
+		}
+		name := "w1_bus_master" + strconv.Itoa(bus)
+		d.buses = append(d.buses, name)
+		aliases := []string{"OneWire" + strconv.Itoa(bus)}
+		if err := onewirereg.Register(name, aliases, bus, openerOneWire(bus).Open); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+type openerOneWire int
+
+func (o openerOneWire) Open() (onewire.BusCloser, error) {
+	b, err := NewOneWire(int(o))
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvOneWire)
+	}
+}
+
+var drvOneWire driverOneWire
+
+var _ onewire.Bus = &OneWire{}
+var _ onewire.BusCloser = &OneWire{}
+var _ onewire.NoDevicesError = noDeviceError("")
+var _ onewire.BusError = noDeviceError("")
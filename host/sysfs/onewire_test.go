@@ -0,0 +1,221 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"periph.io/x/periph/conn/onewire"
+)
+
+func TestNewOneWire(t *testing.T) {
+	defer reset()
+	newW1Tree()
+	if o, err := NewOneWire(1); o == nil || err != nil {
+		t.Fatal(o, err)
+	} else if s := o.String(); s != "w1_bus_master1" {
+		t.Fatal(s)
+	} else if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOneWire(2); err == nil || err.Error() != "sysfs-onewire: open /sys/bus/w1/devices/w1_bus_master2/w1_master_slaves: file does not exist" {
+		t.Fatal(err)
+	}
+}
+
+func TestOneWire_Search(t *testing.T) {
+	defer reset()
+	tree := newW1Tree()
+	o, err := NewOneWire(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := o.Search(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []onewire.Address{0x7a00000131825228, 0x480316a2793eff28}
+	if !reflect.DeepEqual(a, expected) {
+		t.Fatalf("%#x", a)
+	}
+	if _, err := o.Search(true); err == nil {
+		t.Fatal("alarm search is not supported")
+	}
+	tree.files[w1Root+"w1_master_slaves"].data = []byte("not found.\n")
+	if a, err := o.Search(false); len(a) != 0 || err != nil {
+		t.Fatal(a, err)
+	}
+	delete(tree.files, w1Root+"w1_master_slaves")
+	if _, err := o.Search(false); err == nil {
+		t.Fatal("missing file")
+	}
+}
+
+func TestOneWire_Tx_MatchROM(t *testing.T) {
+	defer reset()
+	tree := newW1Tree()
+	o, err := NewOneWire(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := tree.files[w1Root+"28-000001318252/rw"]
+	rw.data = []byte{1, 2, 3}
+	d := onewire.Dev{Bus: o, Addr: 0x7a00000131825228}
+	r := make([]byte, 3)
+	if err := d.Tx([]byte{0xbe}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rw.written, []byte{0xbe}) || !bytes.Equal(r, []byte{1, 2, 3}) {
+		t.Fatal(rw.written, r)
+	}
+	if !rw.closed {
+		t.Fatal("rw must be closed")
+	}
+	// Strong pull-up is ignored.
+	if err := d.TxPower([]byte{0x44}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rw.written, []byte{0xbe, 0x44}) {
+		t.Fatal(rw.written)
+	}
+	// Reading more than available.
+	if err := d.Tx([]byte{0xbe}, make([]byte, 10)); err == nil {
+		t.Fatal("short read")
+	}
+	// Unknown device.
+	d.Addr = 0x1234
+	if err := d.Tx([]byte{0xbe}, nil); err == nil {
+		t.Fatal("missing device")
+	}
+	// Nothing to write.
+	if err := o.Tx([]byte{0x55, 0x28, 0x52, 0x82, 0x31, 0x01, 0, 0, 0x7a}, nil, onewire.WeakPullup); err == nil {
+		t.Fatal("nothing written")
+	}
+}
+
+func TestOneWire_Tx_not_present(t *testing.T) {
+	defer reset()
+	tree := newW1Tree()
+	o, err := NewOneWire(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.files[w1Root+"28-000001318252/rw"].short = true
+	d := onewire.Dev{Bus: o, Addr: 0x7a00000131825228}
+	err = d.Tx([]byte{0xbe}, nil)
+	if e, ok := err.(onewire.NoDevicesError); !ok || !e.NoDevices() {
+		t.Fatal(err)
+	}
+	if e, ok := err.(onewire.BusError); !ok || !e.BusError() {
+		t.Fatal(err)
+	}
+	if s := err.Error(); s != "sysfs-onewire: 28-000001318252 is not present" {
+		t.Fatal(s)
+	}
+	tree.files[w1Root+"28-000001318252/rw"].writeErr = errors.New("injected")
+	if err := d.Tx([]byte{0xbe}, nil); err == nil || err.Error() != "sysfs-onewire: injected" {
+		t.Fatal(err)
+	}
+}
+
+func TestOneWire_Tx_SkipROM(t *testing.T) {
+	defer reset()
+	tree := newW1Tree()
+	o, err := NewOneWire(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Convert T on all the devices.
+	if err := o.Tx([]byte{0xcc, 0x44}, nil, onewire.StrongPullup); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []string{"28-000001318252", "28-0316a2793eff"} {
+		if w := tree.files[w1Root+n+"/rw"].written; !bytes.Equal(w, []byte{0x44}) {
+			t.Fatal(n, w)
+		}
+	}
+	if err := o.Tx([]byte{0xcc, 0xbe}, make([]byte, 9), onewire.WeakPullup); err == nil {
+		t.Fatal("can't read from multiple devices")
+	}
+	// Reading is fine with a single device.
+	tree.files[w1Root+"w1_master_slaves"].data = []byte("28-0316a2793eff\n")
+	tree.files[w1Root+"28-0316a2793eff/rw"].data = []byte{5}
+	r := make([]byte, 1)
+	if err := o.Tx([]byte{0xcc, 0xbe}, r, onewire.WeakPullup); err != nil || r[0] != 5 {
+		t.Fatal(r, err)
+	}
+	tree.files[w1Root+"w1_master_slaves"].data = []byte("not found.\n")
+	if err := o.Tx([]byte{0xcc, 0x44}, nil, onewire.WeakPullup); err == nil {
+		t.Fatal("no device")
+	}
+	delete(tree.files, w1Root+"w1_master_slaves")
+	if err := o.Tx([]byte{0xcc, 0x44}, nil, onewire.WeakPullup); err == nil {
+		t.Fatal("missing file")
+	}
+}
+
+func TestOneWire_Tx_invalid(t *testing.T) {
+	defer reset()
+	newW1Tree()
+	o, err := NewOneWire(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Tx(nil, nil, onewire.WeakPullup); err == nil {
+		t.Fatal("empty")
+	}
+	if err := o.Tx([]byte{0x55, 1, 2}, nil, onewire.WeakPullup); err == nil {
+		t.Fatal("short address")
+	}
+	if err := o.Tx([]byte{0xf0}, nil, onewire.WeakPullup); err == nil || err.Error() != "sysfs-onewire: unsupported ROM command 0xF0" {
+		t.Fatal(err)
+	}
+}
+
+func TestDeviceName(t *testing.T) {
+	for _, a := range []onewire.Address{0x7a00000131825228, 0x480316a2793eff28} {
+		n := deviceName(a)
+		if b, ok := parseDeviceName(n); !ok || a != b {
+			t.Fatalf("%s: %#x != %#x", n, a, b)
+		}
+	}
+	if n := deviceName(0x7a00000131825228); n != "28-000001318252" {
+		t.Fatal(n)
+	}
+	for _, n := range []string{"", "not found.", "w1_bus_master1", "zz-000001318252", "28-00000131825z"} {
+		if _, ok := parseDeviceName(n); ok {
+			t.Fatal(n)
+		}
+	}
+}
+
+func TestOneWireDriver(t *testing.T) {
+	if len((&driverOneWire{}).Prerequisites()) != 0 {
+		t.Fatal("unexpected prerequisite")
+	}
+	if len((&driverOneWire{}).After()) != 0 {
+		t.Fatal("unexpected after")
+	}
+	if s := drvOneWire.String(); s != "sysfs-onewire" {
+		t.Fatal(s)
+	}
+}
+
+//
+
+const w1Root = "/sys/bus/w1/devices/w1_bus_master1/"
+
+// newW1Tree fakes the sysfs tree of the w1 subsystem with two DS18B20.
+func newW1Tree() *fakeSysfs {
+	return newFakeSysfs(map[string]*fakeFile{
+		w1Root + "w1_master_slaves":   {data: []byte("28-000001318252\n28-0316a2793eff\n"), flags: []int{os.O_RDONLY}},
+		w1Root + "28-000001318252/rw": {stream: true, flags: []int{os.O_RDWR}},
+		w1Root + "28-0316a2793eff/rw": {stream: true, flags: []int{os.O_RDWR}},
+	})
+}
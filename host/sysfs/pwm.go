@@ -0,0 +1,631 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"periph.io/x/periph"
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/gpio/gpioreg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+// PWMs is all the hardware PWM channels discovered on this host via sysfs.
+//
+// The channels are named "PWM<chip>_<channel>", e.g. "PWM0_1" is the channel
+// 1 of /sys/class/pwm/pwmchip0.
+var PWMs []*PWM
+
+// PWMByName returns a *PWM for the channel name, if any.
+//
+// For all practical purpose, a PWM channel is considered an output-only
+// gpio.PinOut.
+func PWMByName(name string) (*PWM, error) {
+	for _, p := range PWMs {
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return nil, errors.New("sysfs-pwm: invalid PWM name")
+}
+
+// PWM represents one hardware PWM channel as exposed by the kernel in
+// /sys/class/pwm.
+//
+// The channel is exported on first use.
+//
+// When the pin the channel is muxed to is known, PWM() on the corresponding
+// sysfs GPIO pin is forwarded to this channel.
+type PWM struct {
+	number  int    // GPIO number of the pin the channel is muxed to; -1 if unknown
+	name    string // "PWM<chip>_<channel>"
+	pin     string // Name of the pin as reported by pinctrl; empty if unknown
+	channel int
+	chip    string // /sys/class/pwm/pwmchipN/
+	root    string // /sys/class/pwm/pwmchipN/pwmM/
+
+	mu       sync.Mutex
+	err      error  // If open() failed
+	fPeriod  fileIO // handle to period; never closed
+	fDuty    fileIO // handle to duty_cycle; never closed
+	fEnable  fileIO // handle to enable; never closed
+	period   int64  // Cache of the last known period in ns
+	duty     int64  // Cache of the last known duty cycle in ns
+	enabled  bool   // Cache of the last known enable state
+	inversed bool   // Cache of the last known polarity
+	buf      [24]byte
+}
+
+// String implements conn.Resource.
+func (p *PWM) String() string {
+	if p.pin != "" {
+		return p.name + "(" + p.pin + ")"
+	}
+	return p.name
+}
+
+// Halt implements conn.Resource.
+//
+// It disables the PWM output.
+func (p *PWM) Halt() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return p.wrap(err)
+	}
+	return p.enable(false)
+}
+
+// Name implements pin.Pin.
+func (p *PWM) Name() string {
+	return p.name
+}
+
+// Number implements pin.Pin.
+//
+// It returns the GPIO number of the pin the channel is muxed to, or -1 if
+// unknown.
+func (p *PWM) Number() int {
+	return p.number
+}
+
+// Function implements pin.Pin.
+func (p *PWM) Function() string {
+	return string(p.Func())
+}
+
+// Func implements pin.PinFunc.
+func (p *PWM) Func() pin.Func {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return pin.Func("ERR")
+	}
+	if p.enabled {
+		return gpio.PWM
+	}
+	return pin.FuncNone
+}
+
+// SupportedFuncs implements pin.PinFunc.
+func (p *PWM) SupportedFuncs() []pin.Func {
+	return []pin.Func{gpio.PWM}
+}
+
+// SetFunc implements pin.PinFunc.
+//
+// Setting gpio.PWM enables the output with the current period and duty
+// cycle. Setting pin.FuncNone disables it.
+func (p *PWM) SetFunc(f pin.Func) error {
+	switch f {
+	case gpio.PWM:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if err := p.open(); err != nil {
+			return p.wrap(err)
+		}
+		if p.period == 0 {
+			return p.wrap(errors.New("call PWM() first to set the frequency"))
+		}
+		return p.enable(true)
+	case pin.FuncNone:
+		return p.Halt()
+	case gpio.OUT_HIGH:
+		return p.Out(gpio.High)
+	case gpio.OUT, gpio.OUT_LOW:
+		return p.Out(gpio.Low)
+	default:
+		return p.wrap(errors.New("unsupported function"))
+	}
+}
+
+// In implements gpio.PinIn.
+//
+// It is not supported.
+func (p *PWM) In(pull gpio.Pull, edge gpio.Edge) error {
+	return p.wrap(errors.New("not an input"))
+}
+
+// Read implements gpio.PinIn.
+//
+// It is not supported and always returns gpio.Low.
+func (p *PWM) Read() gpio.Level {
+	return gpio.Low
+}
+
+// WaitForEdge implements gpio.PinIn.
+func (p *PWM) WaitForEdge(timeout time.Duration) bool {
+	return false
+}
+
+// Pull implements gpio.PinIn.
+func (p *PWM) Pull() gpio.Pull {
+	return gpio.PullNoChange
+}
+
+// DefaultPull implements gpio.PinIn.
+func (p *PWM) DefaultPull() gpio.Pull {
+	return gpio.PullNoChange
+}
+
+// Out implements gpio.PinOut.
+//
+// It sets the duty cycle to 0% or 100% at the current frequency, or 1kHz if
+// none was set yet.
+func (p *PWM) Out(l gpio.Level) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return p.wrap(err)
+	}
+	period := p.period
+	if period == 0 {
+		period = int64(time.Millisecond)
+	}
+	duty := int64(0)
+	if l {
+		duty = period
+	}
+	return p.set(period, duty)
+}
+
+// PWM implements gpio.PinOut.
+//
+// It sets the period and the duty cycle and enables the output. Using 0 as
+// frequency keeps the current period.
+//
+// The resolution is 1ns, so the highest supported frequency is 1GHz.
+func (p *PWM) PWM(d gpio.Duty, f physic.Frequency) error {
+	if !d.Valid() {
+		return p.wrap(errors.New("invalid duty cycle"))
+	}
+	if f < 0 || f > physic.GigaHertz {
+		return p.wrap(errors.New("invalid frequency"))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return p.wrap(err)
+	}
+	period := p.period
+	if f != 0 {
+		period = int64(f.Duration())
+	}
+	if period == 0 {
+		return p.wrap(errors.New("frequency must be specified"))
+	}
+	// Split the calculation to not overflow on low frequencies.
+	m := int64(gpio.DutyMax)
+	duty := period/m*int64(d) + period%m*int64(d)/m
+	return p.set(period, duty)
+}
+
+// SetPolarity sets the polarity of the output.
+//
+// When inversed is true, the output is low during the duty cycle. The kernel
+// only permits changing the polarity when the output is disabled, so it is
+// temporarily disabled if needed.
+func (p *PWM) SetPolarity(inversed bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.open(); err != nil {
+		return p.wrap(err)
+	}
+	enabled := p.enabled
+	if enabled {
+		if err := p.enable(false); err != nil {
+			return err
+		}
+	}
+	v := "normal"
+	if inversed {
+		v = "inversed"
+	}
+	f, err := fileIOOpen(p.root+"polarity", os.O_WRONLY)
+	if err != nil {
+		return p.wrap(err)
+	}
+	_, err = f.Write([]byte(v))
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return p.wrap(err)
+	}
+	p.inversed = inversed
+	if enabled {
+		return p.enable(true)
+	}
+	return nil
+}
+
+//
+
+// open exports the channel and opens the handles to its attributes.
+//
+// lock must be held.
+func (p *PWM) open() error {
+	if p.fEnable != nil || p.err != nil {
+		return p.err
+	}
+	f, err := fileIOOpen(p.chip+"export", os.O_WRONLY)
+	if err != nil {
+		p.err = err
+		return p.err
+	}
+	_, err = f.Write([]byte(strconv.Itoa(p.channel)))
+	_ = f.Close()
+	// EBUSY is returned when the channel is already exported.
+	if err != nil && !isErrBusy(err) {
+		p.err = err
+		if os.IsPermission(p.err) {
+			return fmt.Errorf("need more access, try as root or setup udev rules: %v", p.err)
+		}
+		return p.err
+	}
+
+	// Same race condition as with gpio export, the udev rule may not have
+	// completed yet.
+	timeout := 5 * time.Second
+	for start := time.Now(); time.Since(start) < timeout; {
+		p.fPeriod, err = fileIOOpen(p.root+"period", os.O_RDWR)
+		if err == nil {
+			break
+		}
+		if !os.IsPermission(err) {
+			p.err = err
+			return p.err
+		}
+	}
+	if err != nil {
+		p.err = err
+		return p.err
+	}
+	if p.fDuty, err = fileIOOpen(p.root+"duty_cycle", os.O_RDWR); err != nil {
+		p.err = err
+		_ = p.fPeriod.Close()
+		p.fPeriod = nil
+		return p.err
+	}
+	if p.fEnable, err = fileIOOpen(p.root+"enable", os.O_RDWR); err != nil {
+		p.err = err
+		_ = p.fPeriod.Close()
+		_ = p.fDuty.Close()
+		p.fPeriod = nil
+		p.fDuty = nil
+		return p.err
+	}
+	// Retrieve the current state, which may have been set by another process or
+	// the boot loader. Errors are ignored as the values are only used as hints.
+	p.period, _ = p.readInt(p.fPeriod)
+	p.duty, _ = p.readInt(p.fDuty)
+	e, _ := p.readInt(p.fEnable)
+	p.enabled = e != 0
+	if s, err := readString(p.root + "polarity"); err == nil {
+		p.inversed = s == "inversed"
+	}
+	return nil
+}
+
+// set writes the period and the duty cycle and enables the output.
+//
+// The kernel refuses a duty cycle larger than the period, so the order of the
+// writes depends on whether the period grows or shrinks.
+//
+// lock must be held.
+func (p *PWM) set(period, duty int64) error {
+	if period < p.duty {
+		if err := p.writeInt(p.fDuty, duty); err != nil {
+			return err
+		}
+		p.duty = duty
+	}
+	if period != p.period {
+		if err := p.writeInt(p.fPeriod, period); err != nil {
+			return err
+		}
+		p.period = period
+	}
+	if duty != p.duty {
+		if err := p.writeInt(p.fDuty, duty); err != nil {
+			return err
+		}
+		p.duty = duty
+	}
+	return p.enable(true)
+}
+
+// enable enables or disables the output.
+//
+// lock must be held.
+func (p *PWM) enable(on bool) error {
+	if on == p.enabled {
+		return nil
+	}
+	v := int64(0)
+	if on {
+		v = 1
+	}
+	if err := p.writeInt(p.fEnable, v); err != nil {
+		return err
+	}
+	p.enabled = on
+	return nil
+}
+
+func (p *PWM) readInt(f fileIO) (int64, error) {
+	n, err := seekRead(f, p.buf[:])
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(p.buf[:n])), 10, 64)
+}
+
+func (p *PWM) writeInt(f fileIO, v int64) error {
+	if err := seekWrite(f, strconv.AppendInt(p.buf[:0], v, 10)); err != nil {
+		return p.wrap(err)
+	}
+	return nil
+}
+
+func (p *PWM) wrap(err error) error {
+	return fmt.Errorf("sysfs-pwm (%s): %v", p, err)
+}
+
+// pinmux is a line of a pinctrl pinmux-pins debugfs file.
+type pinmux struct {
+	pin      string // Name of the pin, e.g. "gpio18" or "PB2"
+	owner    string // Device owning the pin, e.g. "20c000.pwm"
+	function string
+	group    string
+}
+
+// parsePinmux parses the content of a pinmux-pins file, as found in
+// /sys/kernel/debug/pinctrl/*/pinmux-pins.
+//
+// Only the pins claimed by a device are returned. The lines look like one of:
+//
+//	pin 18 (gpio18): 20c000.pwm (GPIO UNCLAIMED) function pwm0 group pwm0_gpio18
+//	pin 18 (gpio18): device 20c000.pwm function alt5 group gpio18
+//	pin 19 (gpio19): (MUX UNCLAIMED) (GPIO UNCLAIMED)
+func parsePinmux(content string) []pinmux {
+	var out []pinmux
+	for _, line := range strings.Split(content, "\n") {
+		f := strings.Fields(line)
+		if len(f) < 4 || f[0] != "pin" || !strings.HasPrefix(f[2], "(") || !strings.HasSuffix(f[2], "):") {
+			continue
+		}
+		m := pinmux{pin: f[2][1 : len(f[2])-2]}
+		f = f[3:]
+		if f[0] == "device" && len(f) > 1 {
+			f = f[1:]
+		}
+		if strings.HasPrefix(f[0], "(") {
+			// (MUX UNCLAIMED)
+			continue
+		}
+		m.owner = f[0]
+		for i := 1; i < len(f)-1; i++ {
+			switch f[i] {
+			case "function":
+				m.function = f[i+1]
+			case "group":
+				m.group = f[i+1]
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// mapPins returns the name of the pin muxed to each channel of the device dev
+// that has npwm channels.
+//
+// When the device has a single channel, any pin it owns is assumed to be this
+// channel. Otherwise the channel number is deduced from the function or the
+// group name, e.g. "pwm1" or "pwm1_gpio13". Channels that cannot be deduced
+// are skipped.
+func mapPins(muxes []pinmux, dev string, npwm int) map[int]string {
+	out := map[int]string{}
+	if dev == "" {
+		return out
+	}
+	for _, m := range muxes {
+		if m.owner != dev {
+			continue
+		}
+		c := 0
+		if npwm != 1 {
+			if c = pwmIndex(m.function); c == -1 {
+				if c = pwmIndex(m.group); c == -1 {
+					continue
+				}
+			}
+		}
+		if c < npwm {
+			if _, ok := out[c]; !ok {
+				out[c] = m.pin
+			}
+		}
+	}
+	return out
+}
+
+// pwmIndex returns the number following "pwm" in s, or -1 if none.
+func pwmIndex(s string) int {
+	s = strings.ToLower(s)
+	for {
+		i := strings.Index(s, "pwm")
+		if i == -1 {
+			return -1
+		}
+		s = s[i+3:]
+		j := 0
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		if j != 0 {
+			n, err := strconv.Atoi(s[:j])
+			if err == nil {
+				return n
+			}
+		}
+	}
+}
+
+// readPinmux reads the pin muxing of all the pin controllers.
+//
+// This requires debugfs to be mounted and readable, which is generally only
+// the case when running as root. Returns nil otherwise.
+func readPinmux() []pinmux {
+	items, err := filepath.Glob("/sys/kernel/debug/pinctrl/*/pinmux-pins")
+	if err != nil {
+		return nil
+	}
+	var out []pinmux
+	for _, item := range items {
+		f, err := fileIOOpen(item, os.O_RDONLY)
+		if err != nil {
+			continue
+		}
+		b, err := ioutil.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+		out = append(out, parsePinmux(string(b))...)
+	}
+	return out
+}
+
+// driverPWM implements periph.Driver.
+type driverPWM struct {
+	gpios map[int]*PWM // GPIO number to the PWM channel muxed to it
+}
+
+func (d *driverPWM) String() string {
+	return "sysfs-pwm"
+}
+
+func (d *driverPWM) Prerequisites() []string {
+	return nil
+}
+
+// After lists the GPIO drivers, so the pin names reported by pinctrl can be
+// resolved to GPIO numbers via gpioreg.
+func (d *driverPWM) After() []string {
+	return []string{"sysfs-gpio", "sysfs-gpiochip", "allwinner-gpio", "allwinner-gpio-pl", "bcm283x-gpio"}
+}
+
+// Init initializes PWM sysfs handling code.
+//
+// Uses pwm sysfs as described at
+// https://www.kernel.org/doc/Documentation/pwm.txt
+func (d *driverPWM) Init() (bool, error) {
+	items, err := filepath.Glob("/sys/class/pwm/pwmchip*")
+	if err != nil {
+		return true, err
+	}
+	if len(items) == 0 {
+		return false, errors.New("sysfs-pwm: no PWM found")
+	}
+	// Make the channels in deterministic order.
+	chips := make([]int, 0, len(items))
+	for _, item := range items {
+		if n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(item), "pwmchip")); err == nil {
+			chips = append(chips, n)
+		}
+	}
+	sort.Ints(chips)
+	muxes := readPinmux()
+	d.gpios = map[int]*PWM{}
+	for _, n := range chips {
+		chip := "/sys/class/pwm/pwmchip" + strconv.Itoa(n) + "/"
+		npwm, err := readInt(chip + "npwm")
+		if err != nil {
+			return true, fmt.Errorf("sysfs-pwm: %v", err)
+		}
+		dev := ""
+		if l, err := os.Readlink(chip + "device"); err == nil {
+			dev = filepath.Base(l)
+		}
+		pins := mapPins(muxes, dev, npwm)
+		for c := 0; c < npwm; c++ {
+			p := &PWM{
+				number:  -1,
+				name:    "PWM" + strconv.Itoa(n) + "_" + strconv.Itoa(c),
+				pin:     pins[c],
+				channel: c,
+				chip:    chip,
+				root:    chip + "pwm" + strconv.Itoa(c) + "/",
+			}
+			if p.pin != "" {
+				d.mapGPIO(p)
+			}
+			PWMs = append(PWMs, p)
+		}
+	}
+	return true, nil
+}
+
+// mapGPIO resolves the pin the channel is muxed to.
+func (d *driverPWM) mapGPIO(p *PWM) {
+	g := gpioreg.ByName(p.pin)
+	if g == nil {
+		if g = gpioreg.ByName(strings.ToUpper(p.pin)); g == nil {
+			return
+		}
+	}
+	if n := g.Number(); n >= 0 {
+		p.number = n
+		d.gpios[n] = p
+	}
+}
+
+func init() {
+	if isLinux {
+		periph.MustRegister(&drvPWM)
+	}
+}
+
+var drvPWM driverPWM
+
+var _ conn.Resource = &PWM{}
+var _ gpio.PinIn = &PWM{}
+var _ gpio.PinOut = &PWM{}
+var _ gpio.PinIO = &PWM{}
+var _ pin.PinFunc = &PWM{}
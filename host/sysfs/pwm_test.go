@@ -0,0 +1,318 @@
+// Copyright 2018 The Periph Authors. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package sysfs
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"periph.io/x/periph/conn/gpio"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/conn/pin"
+)
+
+func TestPWMByName(t *testing.T) {
+	defer resetPWM()
+	PWMs = []*PWM{{name: "PWM0_0"}, {name: "PWM0_1"}}
+	if p, err := PWMByName("PWM0_1"); err != nil || p != PWMs[1] {
+		t.Fatal(p, err)
+	}
+	if _, err := PWMByName("PWM1_0"); err == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestPWM(t *testing.T) {
+	defer resetPWM()
+	fs := newFakePWMSysfs()
+	p := newTestPWM()
+	if s := p.String(); s != "PWM0_1(gpio18)" {
+		t.Fatal(s)
+	}
+	if s := p.Name(); s != "PWM0_1" {
+		t.Fatal(s)
+	}
+	if n := p.Number(); n != 18 {
+		t.Fatal(n)
+	}
+	if f := p.Func(); f != pin.FuncNone {
+		t.Fatal(f)
+	}
+	if s := string(fs.files["/pwm/pwmchip0/export"].data); s != "1" {
+		t.Fatalf("%q", s)
+	}
+	if err := p.PWM(gpio.DutyHalf, physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"period=1000000", "duty_cycle=500000", "enable=1"}
+	if !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	if s := p.Function(); s != "PWM" {
+		t.Fatal(s)
+	}
+	// The period shrinks below the current duty cycle, so the duty cycle must be
+	// written first.
+	fs.writes = nil
+	if err := p.PWM(gpio.DutyMax/4, 10*physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"duty_cycle=25000", "period=100000"}
+	if !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	// Keep the frequency.
+	fs.writes = nil
+	if err := p.PWM(gpio.DutyMax, 0); err != nil {
+		t.Fatal(err)
+	}
+	if expected = []string{"duty_cycle=100000"}; !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	fs.writes = nil
+	if err := p.Out(gpio.Low); err != nil {
+		t.Fatal(err)
+	}
+	if expected = []string{"duty_cycle=0"}; !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	fs.writes = nil
+	if err := p.SetPolarity(true); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"enable=0", "polarity=inversed", "enable=1"}
+	if !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	fs.writes = nil
+	if err := p.Halt(); err != nil {
+		t.Fatal(err)
+	}
+	if expected = []string{"enable=0"}; !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	if err := p.SetFunc(gpio.PWM); err != nil {
+		t.Fatal(err)
+	}
+	if !p.enabled {
+		t.Fatal("expected enabled")
+	}
+}
+
+func TestPWM_Out_default(t *testing.T) {
+	defer resetPWM()
+	fs := newFakePWMSysfs()
+	p := newTestPWM()
+	if err := p.SetFunc(gpio.PWM); err == nil {
+		t.Fatal("frequency is unknown")
+	}
+	if err := p.PWM(gpio.DutyHalf, 0); err == nil {
+		t.Fatal("frequency is unknown")
+	}
+	if err := p.Out(gpio.High); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"period=1000000", "duty_cycle=1000000", "enable=1"}
+	if !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+}
+
+func TestPWM_existing_state(t *testing.T) {
+	defer resetPWM()
+	fs := newFakePWMSysfs()
+	fs.files[pwmRoot+"period"].data = []byte("20000000\n")
+	fs.files[pwmRoot+"duty_cycle"].data = []byte("1500000\n")
+	fs.files[pwmRoot+"enable"].data = []byte("1\n")
+	fs.files[pwmRoot+"polarity"].data = []byte("inversed\n")
+	p := newTestPWM()
+	if err := p.PWM(gpio.DutyMax/10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"duty_cycle=1999999"}; !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+	if !p.inversed {
+		t.Fatal("expected inversed")
+	}
+}
+
+func TestPWM_not_supported(t *testing.T) {
+	defer resetPWM()
+	newFakePWMSysfs()
+	p := newTestPWM()
+	if p.In(gpio.Float, gpio.NoEdge) == nil {
+		t.Fatal("not an input")
+	}
+	if l := p.Read(); l != gpio.Low {
+		t.Fatal(l)
+	}
+	if p.WaitForEdge(0) {
+		t.Fatal("not an input")
+	}
+	if pull := p.Pull(); pull != gpio.PullNoChange {
+		t.Fatal(pull)
+	}
+	if pull := p.DefaultPull(); pull != gpio.PullNoChange {
+		t.Fatal(pull)
+	}
+	if err := p.SetFunc(gpio.IN); err == nil {
+		t.Fatal("not an input")
+	}
+	if err := p.PWM(-1, 0); err == nil {
+		t.Fatal("invalid duty")
+	}
+	if err := p.PWM(gpio.DutyHalf, -physic.Hertz); err == nil {
+		t.Fatal("invalid frequency")
+	}
+	if err := p.PWM(gpio.DutyHalf, 2*physic.GigaHertz); err == nil {
+		t.Fatal("invalid frequency")
+	}
+}
+
+func TestPWM_open_fail(t *testing.T) {
+	defer resetPWM()
+	fileIOOpen = func(path string, flag int) (fileIO, error) {
+		return nil, errors.New("injected")
+	}
+	p := newTestPWM()
+	if err := p.PWM(gpio.DutyHalf, physic.KiloHertz); err == nil || err.Error() != "sysfs-pwm (PWM0_1(gpio18)): injected" {
+		t.Fatal(err)
+	}
+	if f := p.Func(); f != "ERR" {
+		t.Fatal(f)
+	}
+	if p.Halt() == nil {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestPin_PWM_forwarded(t *testing.T) {
+	defer resetPWM()
+	fs := newFakePWMSysfs()
+	drvPWM.gpios = map[int]*PWM{18: newTestPWM()}
+	p := Pin{number: 18, name: "GPIO18", root: "/tmp/gpio/priv/"}
+	if err := p.PWM(gpio.DutyHalf, physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	l := Line{number: 18, name: "GPIO18"}
+	if err := l.PWM(gpio.DutyMax, physic.KiloHertz); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"period=1000000", "duty_cycle=500000", "enable=1", "duty_cycle=1000000"}
+	if !reflect.DeepEqual(pwmWrites(fs), expected) {
+		t.Fatal(pwmWrites(fs))
+	}
+}
+
+func TestParsePinmux(t *testing.T) {
+	data := "Pinmux settings per pin\n" +
+		"Format: pin (name): mux_owner gpio_owner hog?\n" +
+		"pin 17 (gpio17): (MUX UNCLAIMED) (GPIO UNCLAIMED)\n" +
+		"pin 18 (gpio18): 20c000.pwm (GPIO UNCLAIMED) function alt5 group gpio18\n" +
+		"pin 19 (gpio19): device 20c000.pwm function pwm1 group pwm1_gpio19\n" +
+		"pin 34 (PB2): 1c21400.pwm (GPIO UNCLAIMED) function pwm group PB2\n"
+	muxes := parsePinmux(data)
+	expected := []pinmux{
+		{pin: "gpio18", owner: "20c000.pwm", function: "alt5", group: "gpio18"},
+		{pin: "gpio19", owner: "20c000.pwm", function: "pwm1", group: "pwm1_gpio19"},
+		{pin: "PB2", owner: "1c21400.pwm", function: "pwm", group: "PB2"},
+	}
+	if !reflect.DeepEqual(muxes, expected) {
+		t.Fatalf("%#v", muxes)
+	}
+	// The channel of gpio18 can't be deduced.
+	if m := mapPins(muxes, "20c000.pwm", 2); !reflect.DeepEqual(m, map[int]string{1: "gpio19"}) {
+		t.Fatal(m)
+	}
+	// Single channel device.
+	if m := mapPins(muxes, "1c21400.pwm", 1); !reflect.DeepEqual(m, map[int]string{0: "PB2"}) {
+		t.Fatal(m)
+	}
+	if m := mapPins(muxes, "", 1); len(m) != 0 {
+		t.Fatal(m)
+	}
+}
+
+func TestPWMIndex(t *testing.T) {
+	data := []struct {
+		s string
+		i int
+	}{
+		{"pwm0", 0},
+		{"PWM1_gpio13", 1},
+		{"pwm_gpio12_pwm12", 12},
+		{"pwm", -1},
+		{"alt5", -1},
+	}
+	for i, line := range data {
+		if actual := pwmIndex(line.s); actual != line.i {
+			t.Fatalf("#%d: pwmIndex(%q) = %d; expected %d", i, line.s, actual, line.i)
+		}
+	}
+}
+
+func TestPWMDriver(t *testing.T) {
+	if len((&driverPWM{}).Prerequisites()) != 0 {
+		t.Fatal("unexpected PWM prerequisites")
+	}
+}
+
+//
+
+func resetPWM() {
+	reset()
+	PWMs = nil
+	drvPWM.gpios = nil
+}
+
+func newTestPWM() *PWM {
+	return &PWM{
+		number:  18,
+		name:    "PWM0_1",
+		pin:     "gpio18",
+		channel: 1,
+		chip:    "/pwm/pwmchip0/",
+		root:    "/pwm/pwmchip0/pwm1/",
+	}
+}
+
+const pwmRoot = "/pwm/pwmchip0/pwm1/"
+
+// newFakePWMSysfs fakes /pwm/pwmchip0 with its exported channel 1.
+//
+// Like the kernel, it refuses a duty cycle larger than the period.
+func newFakePWMSysfs() *fakeSysfs {
+	fs := newFakeSysfs(map[string]*fakeFile{
+		"/pwm/pwmchip0/export": {},
+		pwmRoot + "period":     {data: []byte("0\n")},
+		pwmRoot + "duty_cycle": {data: []byte("0\n")},
+		pwmRoot + "enable":     {data: []byte("0\n")},
+		pwmRoot + "polarity":   {data: []byte("normal\n")},
+	})
+	fs.files[pwmRoot+"duty_cycle"].check = func(b []byte) error {
+		d, _ := strconv.Atoi(string(b))
+		p, _ := strconv.Atoi(strings.TrimSpace(string(fs.files[pwmRoot+"period"].data)))
+		if d > p {
+			return errors.New("invalid argument")
+		}
+		return nil
+	}
+	return fs
+}
+
+// pwmWrites returns the writes to the channel's attributes, as "name=value".
+func pwmWrites(fs *fakeSysfs) []string {
+	var out []string
+	for _, w := range fs.writes {
+		if strings.HasPrefix(w, pwmRoot) {
+			out = append(out, w[len(pwmRoot):])
+		}
+	}
+	return out
+}
@@ -88,53 +88,18 @@ func (s *SPI) LimitSpeed(f physic.Frequency) error {
 //
 // It must be called before any I/O.
 func (s *SPI) Connect(f physic.Frequency, mode spi.Mode, bits int) (spi.Conn, error) {
-	if f > physic.GigaHertz {
-		return nil, fmt.Errorf("sysfs-spi: invalid speed %s; maximum supported clock is 1GHz", f)
-	}
-	if f < 100*physic.Hertz {
-		return nil, fmt.Errorf("sysfs-spi: invalid speed %s; minimum supported clock is 100Hz; did you forget to multiply by physic.MegaHertz?", f)
-	}
-	if mode&^(spi.Mode3|spi.HalfDuplex|spi.NoCS|spi.LSBFirst) != 0 {
-		return nil, fmt.Errorf("sysfs-spi: invalid mode %v", mode)
-	}
-	if bits < 1 || bits >= 256 {
-		return nil, fmt.Errorf("sysfs-spi: invalid bits %d", bits)
+	if err := checkParams(f, mode, bits); err != nil {
+		return nil, err
 	}
 	s.conn.mu.Lock()
 	defer s.conn.mu.Unlock()
 	if s.conn.connected {
 		return nil, errors.New("sysfs-spi: Connect() can only be called exactly once")
 	}
-	s.conn.connected = true
-	s.conn.freqConn = f
-	s.conn.bitsPerWord = uint8(bits)
-	// Only mode needs to be set via an IOCTL, others can be specified in the
-	// spiIOCTransfer packet, which saves a kernel call.
-	m := mode & spi.Mode3
-	s.conn.muPins.Lock()
-	{
-		if mode&spi.HalfDuplex != 0 {
-			m |= threeWire
-			s.conn.halfDuplex = true
-			// In case initPins() had been called before Connect().
-			s.conn.mosi = gpio.INVALID
-		}
-		if mode&spi.NoCS != 0 {
-			m |= noCS
-			s.conn.noCS = true
-			// In case initPins() had been called before Connect().
-			s.conn.cs = gpio.INVALID
-		}
-	}
-	s.conn.muPins.Unlock()
-	if mode&spi.LSBFirst != 0 {
-		m |= lSBFirst
-	}
-	// Only the first 8 bits are used. This only works because the system is
-	// running in little endian.
-	if err := s.conn.setFlag(spiIOCMode, uint64(m)); err != nil {
-		return nil, fmt.Errorf("sysfs-spi: setting mode %v failed: %v", mode, err)
+	if err := s.conn.configure(f, mode, bits); err != nil {
+		return nil, err
 	}
+	s.conn.connected = true
 	return &s.conn, nil
 }
 
@@ -347,6 +312,21 @@ func (s *spiConn) Duplex() conn.Duplex {
 	return conn.Full
 }
 
+// Configure implements spi.Configurer.
+//
+// It changes the parameters specified to Connect().
+func (s *spiConn) Configure(f physic.Frequency, mode spi.Mode, bits int) error {
+	if err := checkParams(f, mode, bits); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		return errors.New("sysfs-spi: Connect() must be called first")
+	}
+	return s.configure(f, mode, bits)
+}
+
 // MaxTxSize implements conn.Limits.
 func (s *spiConn) MaxTxSize() int {
 	return drvSPI.bufSize
@@ -378,6 +358,67 @@ func (s *spiConn) CS() gpio.PinOut {
 
 //
 
+// checkParams verifies the arguments to Connect() and Configure().
+func checkParams(f physic.Frequency, mode spi.Mode, bits int) error {
+	if f > physic.GigaHertz {
+		return fmt.Errorf("sysfs-spi: invalid speed %s; maximum supported clock is 1GHz", f)
+	}
+	if f < 100*physic.Hertz {
+		return fmt.Errorf("sysfs-spi: invalid speed %s; minimum supported clock is 100Hz; did you forget to multiply by physic.MegaHertz?", f)
+	}
+	if mode&^(spi.Mode3|spi.HalfDuplex|spi.NoCS|spi.LSBFirst) != 0 {
+		return fmt.Errorf("sysfs-spi: invalid mode %v", mode)
+	}
+	if bits < 1 || bits >= 256 {
+		return fmt.Errorf("sysfs-spi: invalid bits %d", bits)
+	}
+	return nil
+}
+
+// configure sets the connection parameters.
+//
+// mu must be held.
+func (s *spiConn) configure(f physic.Frequency, mode spi.Mode, bits int) error {
+	// Only mode needs to be set via an IOCTL, others can be specified in the
+	// spiIOCTransfer packet, which saves a kernel call.
+	m := mode & spi.Mode3
+	s.muPins.Lock()
+	{
+		s.halfDuplex = mode&spi.HalfDuplex != 0
+		if s.halfDuplex {
+			m |= threeWire
+			// In case initPins() had been called before Connect().
+			s.mosi = gpio.INVALID
+		} else if s.mosi == gpio.INVALID {
+			// Reconfigured from HalfDuplex; let initPins() look it up again.
+			s.mosi = nil
+			s.clk = nil
+		}
+		s.noCS = mode&spi.NoCS != 0
+		if s.noCS {
+			m |= noCS
+			// In case initPins() had been called before Connect().
+			s.cs = gpio.INVALID
+		} else if s.cs == gpio.INVALID {
+			// Reconfigured from NoCS; let initPins() look it up again.
+			s.cs = nil
+			s.clk = nil
+		}
+	}
+	s.muPins.Unlock()
+	if mode&spi.LSBFirst != 0 {
+		m |= lSBFirst
+	}
+	// Only the first 8 bits are used. This only works because the system is
+	// running in little endian.
+	if err := s.setFlag(spiIOCMode, uint64(m)); err != nil {
+		return fmt.Errorf("sysfs-spi: setting mode %v failed: %v", mode, err)
+	}
+	s.freqConn = f
+	s.bitsPerWord = uint8(bits)
+	return nil
+}
+
 func (s *spiConn) txPackets(p []spi.Packet) error {
 	// Convert the packets.
 	f := s.freqPort
@@ -396,8 +437,16 @@ func (s *spiConn) txPackets(p []spi.Packet) error {
 			bits = s.bitsPerWord
 		}
 		m[i].reset(p[i].W, p[i].R, f, bits)
-		if !s.noCS && !p[i].KeepCS {
-			m[i].csChange = 1
+		if !s.noCS {
+			if i == len(p)-1 {
+				// cs_change has the opposite meaning on the last transfer: it keeps
+				// CS asserted until the next message.
+				if p[i].KeepCS {
+					m[i].csChange = 1
+				}
+			} else if !p[i].KeepCS {
+				m[i].csChange = 1
+			}
 		}
 	}
 	return s.f.Ioctl(spiIOCTx(len(m)), uintptr(unsafe.Pointer(&m[0])))
@@ -628,6 +677,7 @@ var _ conn.Limits = &SPI{}
 var _ conn.Limits = &spiConn{}
 var _ io.Reader = &spiConn{}
 var _ io.Writer = &spiConn{}
+var _ spi.Configurer = &spiConn{}
 var _ spi.Conn = &spiConn{}
 var _ spi.Pins = &SPI{}
 var _ spi.Pins = &spiConn{}
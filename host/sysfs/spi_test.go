@@ -269,6 +269,62 @@ func TestSPI_Connect_Half(t *testing.T) {
 	}
 }
 
+func TestSPI_TxPackets_KeepCS(t *testing.T) {
+	p := SPI{spiConn{f: &ioctlClose{}}}
+	c, err := p.Connect(100*physic.Hertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkt := []spi.Packet{
+		{W: []byte{0}, KeepCS: true},
+		{W: []byte{0}},
+		{W: []byte{0}, KeepCS: true},
+	}
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
+	}
+	// The last transfer keeps CS asserted after the message.
+	for i, expected := range []uint8{0, 1, 1} {
+		if v := p.conn.io[i].csChange; v != expected {
+			t.Fatal(i, v)
+		}
+	}
+	pkt[2].KeepCS = false
+	if err := c.TxPackets(pkt); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.conn.io[2].csChange; v != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestSPI_Configure(t *testing.T) {
+	p := SPI{spiConn{f: &ioctlClose{}, busNumber: 24}}
+	if err := p.conn.Configure(physic.KiloHertz, spi.Mode0, 8); err == nil {
+		t.Fatal("Connect() must be called first")
+	}
+	c, err := p.Connect(physic.KiloHertz, spi.Mode0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf := c.(spi.Configurer)
+	if err := cf.Configure(physic.KiloHertz, spi.Mode0, 0); err == nil {
+		t.Fatal("invalid bit")
+	}
+	if err := cf.Configure(physic.MegaHertz, spi.Mode3|spi.HalfDuplex|spi.NoCS, 9); err != nil {
+		t.Fatal(err)
+	}
+	if p.conn.freqConn != physic.MegaHertz || p.conn.bitsPerWord != 9 || !p.conn.halfDuplex || !p.conn.noCS {
+		t.Fatal("unexpected configuration")
+	}
+	if err := cf.Configure(physic.KiloHertz, spi.Mode0, 8); err != nil {
+		t.Fatal(err)
+	}
+	if d := c.Duplex(); d != conn.Full {
+		t.Fatal(d)
+	}
+}
+
 func TestSPIIOCTX(t *testing.T) {
 	if v := spiIOCTx(1); v != 0x40206B00 {
 		t.Fatalf("Expected 0x40206B00, got 0x%08X", v)
@@ -12,6 +12,8 @@ import (
 const isLinux = true
 
 func isErrBusy(err error) bool {
-	e, ok := err.(*os.PathError)
-	return ok && e.Err == syscall.EBUSY
+	if e, ok := err.(*os.PathError); ok {
+		err = e.Err
+	}
+	return err == syscall.EBUSY
 }
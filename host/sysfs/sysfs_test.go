@@ -6,6 +6,9 @@ package sysfs
 
 import (
 	"errors"
+	"io"
+	"os"
+	"sync"
 
 	"periph.io/x/periph/host/fs"
 )
@@ -18,6 +21,7 @@ func init() {
 func reset() {
 	fileIOOpen = fileIOOpenDefault
 	ioctlOpen = ioctlOpenDefault
+	lineFileOpen = lineFileOpenDefault
 	// Soon.
 	//fileIOOpen = fileIOOpenPanic
 	//ioctlOpen = ioctlOpenPanic
@@ -67,3 +71,119 @@ func (f *file) Seek(offset int64, whence int) (int64, error) {
 func (f *file) Write(p []byte) (int, error) {
 	return 0, errors.New("not implemented")
 }
+
+// fakeSysfs is a fake tree of sysfs files.
+//
+// newFakeSysfs() installs it as fileIOOpen. Each open handle has its own
+// offset.
+type fakeSysfs struct {
+	mu     sync.Mutex
+	files  map[string]*fakeFile
+	writes []string // Writes to the non-binary files, as "path=value"
+}
+
+func newFakeSysfs(files map[string]*fakeFile) *fakeSysfs {
+	f := &fakeSysfs{files: files}
+	fileIOOpen = f.open
+	return f
+}
+
+func (f *fakeSysfs) open(path string, flag int) (fileIO, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ff, ok := f.files[path]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+	}
+	if ff.flags != nil {
+		ok = false
+		for _, fl := range ff.flags {
+			ok = ok || fl == flag
+		}
+		if !ok {
+			return nil, errors.New("unexpected flag")
+		}
+	}
+	ff.closed = false
+	return &fakeHandle{fs: f, path: path, f: ff}, nil
+}
+
+// fakeFile is a file in fakeSysfs.
+//
+// A write replaces data like for a sysfs attribute. When binary is set, it
+// overwrites data at the current offset instead and when stream is set, data
+// is left untouched like for a character device. The bytes written are always
+// appended to written.
+type fakeFile struct {
+	data     []byte
+	binary   bool
+	stream   bool
+	flags    []int              // Open flags accepted; any when nil
+	check    func([]byte) error // Called before each write to refuse it
+	writeErr error
+	short    bool // A write writes nothing
+	written  []byte
+	closed   bool
+}
+
+// fakeHandle is an open fakeFile.
+type fakeHandle struct {
+	file
+	fs   *fakeSysfs
+	path string
+	f    *fakeFile
+	off  int
+}
+
+func (h *fakeHandle) Read(p []byte) (int, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if h.off >= len(h.f.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, h.f.data[h.off:])
+	h.off += n
+	return n, nil
+}
+
+func (h *fakeHandle) Seek(offset int64, whence int) (int64, error) {
+	if whence != 0 {
+		return 0, errors.New("not implemented")
+	}
+	h.off = int(offset)
+	return offset, nil
+}
+
+func (h *fakeHandle) Write(p []byte) (int, error) {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if h.f.writeErr != nil {
+		return 0, h.f.writeErr
+	}
+	if h.f.short {
+		return 0, io.ErrShortWrite
+	}
+	if h.f.check != nil {
+		if err := h.f.check(p); err != nil {
+			return 0, &os.PathError{Op: "write", Path: h.path, Err: err}
+		}
+	}
+	h.f.written = append(h.f.written, p...)
+	if h.f.binary {
+		n := copy(h.f.data[h.off:], p)
+		h.off += n
+		return n, nil
+	}
+	if !h.f.stream {
+		h.f.data = append([]byte{}, p...)
+	}
+	h.fs.writes = append(h.fs.writes, h.path+"="+string(p))
+	return len(p), nil
+}
+
+func (h *fakeHandle) Close() error {
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	h.f.closed = true
+	return nil
+}
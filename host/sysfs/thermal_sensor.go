@@ -46,14 +46,18 @@ type ThermalSensor struct {
 	nameType  string
 	f         fileIO
 	precision physic.Temperature
+	poller    physic.Poller
 }
 
 func (t *ThermalSensor) String() string {
 	return t.name
 }
 
-// Halt implements conn.Resource. It is a noop.
+// Halt implements conn.Resource.
+//
+// It stops the continuous sensing, if any.
 func (t *ThermalSensor) Halt() error {
+	t.poller.Halt()
 	return nil
 }
 
@@ -111,9 +115,11 @@ func (t *ThermalSensor) Sense(e *physic.Env) error {
 }
 
 // SenseContinuous implements physic.SenseEnv.
+//
+// The application must call Halt() to stop the sensing when done and close
+// the channel.
 func (t *ThermalSensor) SenseContinuous(interval time.Duration) (<-chan physic.Env, error) {
-	// TODO(maruel): Manually poll in a loop via time.NewTicker.
-	return nil, errors.New("sysfs-thermal: not implemented")
+	return t.poller.SenseContinuous(interval, t.Sense)
 }
 
 // Precision implements physic.SenseEnv.
@@ -56,9 +56,14 @@ func TestThermalSensor_fail(t *testing.T) {
 	if err := d.Sense(&e); err == nil || err.Error() != "sysfs-thermal: file I/O is inhibited" {
 		t.Fatal("should have failed")
 	}
-	if _, err := d.SenseContinuous(time.Second); err == nil || err.Error() != "sysfs-thermal: not implemented" {
+	c, err := d.SenseContinuous(time.Second)
+	if err != nil {
 		t.Fatal(err)
 	}
+	// Sense() fails, which closes the channel.
+	if _, ok := <-c; ok {
+		t.Fatal("expected channel to be closed")
+	}
 }
 
 func TestThermalSensor_Type_success(t *testing.T) {